@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// redactedPatterns patterns of keys of sensitive fields whose values are not
+// kept in audit logs, e.g. git_password of spiders, webhook_secret of gits and
+// private_key and passphrase of ssh keys
+var redactedPatterns = []string{
+	"password",
+	"secret",
+	"token",
+	"passphrase",
+	"private_key",
+}
+
+const redactedValue = "******"
+
+// Record append an audit log of the change on the document of given id in collection col,
+// where old and new are the document before and after the change, either of which can be nil.
+// Nothing is recorded if a saved or updated document has no change
+func Record(col string, id primitive.ObjectID, action string, old, new bson.M, u interfaces.User, source constants.AuditSource) (err error) {
+	diff := Diff(old, new)
+	if len(diff) == 0 && (action == constants.AuditActionSave || action == constants.AuditActionUpdate) {
+		return nil
+	}
+	l := &models.AuditLog{
+		Id:         primitive.NewObjectID(),
+		Col:        col,
+		ResourceId: id,
+		Action:     action,
+		Source:     string(source),
+		Ts:         time.Now(),
+		Diff:       diff,
+	}
+	if u != nil && !reflect.ValueOf(u).IsZero() {
+		l.UserId = u.GetId()
+	}
+	if _, err := mongo.GetMongoCol(interfaces.ModelColNameAuditLog).Insert(l); err != nil {
+		return trace.TraceError(err)
+	}
+	return nil
+}
+
+// Diff top-level fields changed from old to new document sorted by key
+func Diff(old, new bson.M) (diff []models.AuditLogDiff) {
+	keys := map[string]bool{}
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+	delete(keys, "_id")
+
+	var sortedKeys []string
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, k := range sortedKeys {
+		o, n := old[k], new[k]
+		if reflect.DeepEqual(o, n) {
+			continue
+		}
+		if isRedactedKey(k) {
+			if o != nil {
+				o = redactedValue
+			}
+			if n != nil {
+				n = redactedValue
+			}
+		}
+		diff = append(diff, models.AuditLogDiff{
+			Key: k,
+			Old: o,
+			New: n,
+		})
+	}
+	return diff
+}
+
+// isRedactedKey whether value of the field with given key is redacted
+func isRedactedKey(key string) (ok bool) {
+	key = strings.ToLower(key)
+	for _, p := range redactedPatterns {
+		if strings.Contains(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// ToBsonM convert a document to bson.M as it is stored in database
+func ToBsonM(doc interface{}) (res bson.M, err error) {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := bson.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
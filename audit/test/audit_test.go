@@ -0,0 +1,79 @@
+package test
+
+import (
+	"github.com/doubletrey/crawlab-core/audit"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old, err := audit.ToBsonM(&models.Schedule{Name: "test_schedule", Cron: "* * * * *"})
+	require.Nil(t, err)
+	new, err := audit.ToBsonM(&models.Schedule{Name: "test_schedule", Cron: "0 * * * *"})
+	require.Nil(t, err)
+
+	diff := audit.Diff(old, new)
+	require.Equal(t, 1, len(diff))
+	require.Equal(t, "cron", diff[0].Key)
+	require.Equal(t, "* * * * *", diff[0].Old)
+	require.Equal(t, "0 * * * *", diff[0].New)
+}
+
+func TestDiff_AddDelete(t *testing.T) {
+	doc := bson.M{"_id": 1, "name": "test", "password": "secret"}
+
+	diff := audit.Diff(nil, doc)
+	require.Equal(t, 2, len(diff))
+	require.Equal(t, "name", diff[0].Key)
+	require.Nil(t, diff[0].Old)
+	require.Equal(t, "test", diff[0].New)
+	require.Equal(t, "password", diff[1].Key)
+	require.NotEqual(t, "secret", diff[1].New)
+
+	diff = audit.Diff(doc, nil)
+	require.Equal(t, 2, len(diff))
+	require.Nil(t, diff[0].New)
+}
+
+func TestDiff_Redacted(t *testing.T) {
+	// spider
+	old, err := audit.ToBsonM(&models.Spider{Name: "test_spider", GitPassword: "old_password"})
+	require.Nil(t, err)
+	new, err := audit.ToBsonM(&models.Spider{Name: "test_spider", GitPassword: "new_password"})
+	require.Nil(t, err)
+	diff := audit.Diff(old, new)
+	require.Equal(t, 1, len(diff))
+	require.Equal(t, "git_password", diff[0].Key)
+	require.NotEqual(t, "old_password", diff[0].Old)
+	require.NotEqual(t, "new_password", diff[0].New)
+
+	// git
+	old, err = audit.ToBsonM(&models.Git{WebhookSecret: "old_secret"})
+	require.Nil(t, err)
+	new, err = audit.ToBsonM(&models.Git{WebhookSecret: "new_secret"})
+	require.Nil(t, err)
+	diff = audit.Diff(old, new)
+	require.Equal(t, 1, len(diff))
+	require.Equal(t, "webhook_secret", diff[0].Key)
+	require.NotEqual(t, "old_secret", diff[0].Old)
+	require.NotEqual(t, "new_secret", diff[0].New)
+
+	// ssh key, of which public key is kept
+	old, err = audit.ToBsonM(&models.SshKey{PublicKey: "old_public", PrivateKey: "old_private", Passphrase: "old_passphrase"})
+	require.Nil(t, err)
+	new, err = audit.ToBsonM(&models.SshKey{PublicKey: "new_public", PrivateKey: "new_private", Passphrase: "new_passphrase"})
+	require.Nil(t, err)
+	diff = audit.Diff(old, new)
+	require.Equal(t, 3, len(diff))
+	require.Equal(t, "passphrase", diff[0].Key)
+	require.NotEqual(t, "old_passphrase", diff[0].Old)
+	require.NotEqual(t, "new_passphrase", diff[0].New)
+	require.Equal(t, "private_key", diff[1].Key)
+	require.NotEqual(t, "old_private", diff[1].Old)
+	require.NotEqual(t, "new_private", diff[1].New)
+	require.Equal(t, "public_key", diff[2].Key)
+	require.Equal(t, "old_public", diff[2].Old)
+	require.Equal(t, "new_public", diff[2].New)
+}
@@ -0,0 +1,17 @@
+package constants
+
+type AuditSource string
+
+const (
+	AuditSourceHttp   AuditSource = "http"
+	AuditSourceGrpc   AuditSource = "grpc"
+	AuditSourceSystem AuditSource = "system"
+)
+
+const (
+	AuditActionAdd     = "add"
+	AuditActionSave    = "save"
+	AuditActionDelete  = "delete"
+	AuditActionUpdate  = "update"
+	AuditActionRestore = "restore"
+)
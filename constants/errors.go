@@ -20,6 +20,7 @@ var (
 	ErrTaskError        = errors.New("task error")
 	ErrTaskLost         = errors.New("task lost")
 	ErrTaskCancelled    = errors.New("task cancelled")
+	ErrTaskOomKilled    = errors.New("task killed due to out of memory")
 	ErrUnableToCancel   = errors.New("unable to cancel")
 	ErrUnableToDispose  = errors.New("unable to dispose")
 	ErrAlreadyDisposed  = errors.New("already disposed")
@@ -3,3 +3,13 @@ package constants
 const EmptyFileData = " "
 
 const FsKeepFileName = ".gitkeep"
+
+const (
+	ArchiveFormatZip   = "zip"
+	ArchiveFormatTarGz = "tar.gz"
+)
+
+const (
+	FsUploadModeMerge   = "merge"
+	FsUploadModeReplace = "replace"
+)
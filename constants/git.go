@@ -5,7 +5,28 @@ const (
 	GitAuthTypeSsh  = "ssh"
 )
 
+const (
+	GitSshKeyTypeEd25519 = "ed25519"
+	GitSshKeyTypeRsa     = "rsa"
+)
+
 const (
 	GitRemoteNameUpstream = "upstream"
 	GitRemoteNameOrigin   = "origin"
 )
+
+// GitCommitDirtySuffix suffix of commit of a workspace with uncommitted changes
+const GitCommitDirtySuffix = "-dirty"
+
+const (
+	GitWebhookProviderGithub = "github"
+	GitWebhookProviderGitlab = "gitlab"
+	GitWebhookProviderGitea  = "gitea"
+)
+
+const (
+	GitWebhookStatusProcessing = "processing"
+	GitWebhookStatusSuccess    = "success"
+	GitWebhookStatusError      = "error"
+	GitWebhookStatusIgnored    = "ignored"
+)
@@ -8,3 +8,33 @@ const (
 	ScheduleStatusErrorNotFoundNode   = "Not Found Node"
 	ScheduleStatusErrorNotFoundSpider = "Not Found Spider"
 )
+
+const (
+	ScheduleOverlapPolicyAllow         = "allow"
+	ScheduleOverlapPolicySkipIfRunning = "skip-if-running"
+	ScheduleOverlapPolicyQueueOne      = "queue-one"
+	ScheduleOverlapPolicyReplace       = "replace"
+)
+
+const (
+	ScheduleMisfirePolicyIgnore   = "ignore"
+	ScheduleMisfirePolicyFireOnce = "fire-once"
+	ScheduleMisfirePolicyFireAll  = "fire-all"
+)
+
+const (
+	ScheduleTypeCron = "cron"
+	ScheduleTypeOnce = "once"
+)
+
+const (
+	ScheduleBlackoutPolicySkip  = "skip"
+	ScheduleBlackoutPolicyDefer = "defer"
+)
+
+const (
+	ScheduleSkipReasonOverlap  = "overlap"
+	ScheduleSkipReasonCalendar = "calendar"
+	ScheduleSkipReasonBlackout = "blackout"
+	ScheduleSkipReasonTemplate = "template"
+)
@@ -5,3 +5,20 @@ const (
 	Configurable = "configurable"
 	Plugin       = "plugin"
 )
+
+const (
+	DependencyEnvTypePython = "python"
+	DependencyEnvTypeNode   = "node"
+)
+
+const (
+	DependencyEnvStatusInstalling = "installing"
+	DependencyEnvStatusReady      = "ready"
+	DependencyEnvStatusError      = "error"
+)
+
+const (
+	SpiderImportConflictRename    = "rename"
+	SpiderImportConflictSkip      = "skip"
+	SpiderImportConflictOverwrite = "overwrite"
+)
@@ -33,3 +33,9 @@ const (
 	TaskListQueuePrefixPublic = "tasks:public"
 	TaskListQueuePrefixNodes  = "tasks:nodes"
 )
+
+const (
+	TaskFairShareModeNone    = ""
+	TaskFairShareModeProject = "project"
+	TaskFairShareModeUser    = "user"
+)
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+)
+
+var AuditController ActionController
+
+func getAuditActions() []Action {
+	ctx := newAuditContext()
+	return []Action{
+		{
+			Method:      http.MethodGet,
+			Path:        "",
+			HandlerFunc: ctx.getList,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/:id",
+			HandlerFunc: ctx.get,
+		},
+	}
+}
+
+type auditContext struct {
+	col *mongo.Col
+}
+
+// getList audit logs queried by resource (col and resource_id), user (user_id),
+// action and source, sorted by time in descending order
+func (ctx *auditContext) getList(c *gin.Context) {
+	// query
+	query := bson.M{}
+	for _, key := range []string{"col", "action", "source"} {
+		if value := c.Query(key); value != "" {
+			query[key] = value
+		}
+	}
+	for _, key := range []string{"resource_id", "user_id"} {
+		if value := c.Query(key); value != "" {
+			id, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				HandleErrorBadRequest(c, err)
+				return
+			}
+			query[key] = id
+		}
+	}
+
+	// pagination
+	pagination := MustGetPagination(c)
+
+	// list, decoded as bson.M so that nested values in diff are kept as maps
+	var list []bson.M
+	if err := ctx.col.Find(query, &mongo.FindOptions{
+		Skip:  pagination.Size * (pagination.Page - 1),
+		Limit: pagination.Size,
+		Sort:  bson.D{{Key: "ts", Value: -1}},
+	}).All(&list); err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// total
+	total, err := ctx.col.Count(query)
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithListData(c, list, total)
+}
+
+func (ctx *auditContext) get(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	var l bson.M
+	if err := ctx.col.FindId(id).One(&l); err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithData(c, l)
+}
+
+func newAuditContext() *auditContext {
+	return &auditContext{
+		col: mongo.GetMongoCol(interfaces.ModelColNameAuditLog),
+	}
+}
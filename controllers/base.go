@@ -27,6 +27,12 @@ const (
 	ControllerIdVersion
 	ControllerIdI18n
 	ControllerIdSystemInfo
+	ControllerIdRecycleBin
+	ControllerIdAudit
+	ControllerIdGitWebhook
+	ControllerIdGitWebhookDelivery
+	ControllerIdSshKey
+	ControllerIdCalendar
 )
 
 type ControllerId int
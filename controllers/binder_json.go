@@ -66,6 +66,9 @@ func (b *JsonBinder) Bind(c *gin.Context) (res interfaces.Model, err error) {
 	case ControllerIdGit:
 		err = c.ShouldBindJSON(&m.Git)
 		return &m.Git, nil
+	case ControllerIdCalendar:
+		err = c.ShouldBindJSON(&m.Calendar)
+		return &m.Calendar, err
 	default:
 		return nil, errors.ErrorControllerInvalidControllerId
 	}
@@ -116,6 +119,9 @@ func (b *JsonBinder) BindList(c *gin.Context) (res interface{}, err error) {
 	case ControllerIdPlugin:
 		err = c.ShouldBindJSON(&m.Plugins)
 		return m.Plugins, nil
+	case ControllerIdCalendar:
+		err = c.ShouldBindJSON(&m.Calendars)
+		return m.Calendars, err
 	default:
 		return nil, errors.ErrorControllerInvalidControllerId
 	}
@@ -181,6 +187,9 @@ func (b *JsonBinder) BindBatchRequestPayloadWithStringData(c *gin.Context) (payl
 	case ControllerIdPlugin:
 		err = json.Unmarshal([]byte(payload.Data), &m.Plugin)
 		return payload, &m.Plugin, err
+	case ControllerIdCalendar:
+		err = json.Unmarshal([]byte(payload.Data), &m.Calendar)
+		return payload, &m.Calendar, err
 	default:
 		return payload, nil, errors.ErrorControllerInvalidControllerId
 	}
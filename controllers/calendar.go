@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/delegate"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/doubletrey/crawlab-core/schedule"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var CalendarController *calendarController
+
+// calendarController calendars of dates excluded from fires of schedules,
+// which can be shared by schedules
+type calendarController struct {
+	ListControllerDelegate
+	modelSvc service.ModelService
+}
+
+func (ctr *calendarController) Put(c *gin.Context) {
+	var cal models.Calendar
+	if err := c.ShouldBindJSON(&cal); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	if err := schedule.ValidateCalendarDates(cal.Dates); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	if err := delegate.NewModelDelegate(&cal, GetUserFromContext(c)).Add(); err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+	HandleSuccessWithData(c, cal)
+}
+
+func (ctr *calendarController) Post(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	var cal models.Calendar
+	if err := c.ShouldBindJSON(&cal); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	if cal.Id != id {
+		HandleErrorBadRequest(c, errors.ErrorHttpBadRequest)
+		return
+	}
+	if err := schedule.ValidateCalendarDates(cal.Dates); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	if _, err := ctr.modelSvc.GetCalendarById(id); err != nil {
+		HandleErrorNotFound(c, err)
+		return
+	}
+	if err := delegate.NewModelDelegate(&cal, GetUserFromContext(c)).Save(); err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+	HandleSuccessWithData(c, cal)
+}
+
+func newCalendarController() *calendarController {
+	modelSvc, err := service.GetService()
+	if err != nil {
+		panic(err)
+	}
+
+	ctr := NewListControllerDelegate(ControllerIdCalendar, modelSvc.GetBaseService(interfaces.ModelIdCalendar))
+
+	return &calendarController{
+		ListControllerDelegate: *ctr,
+		modelSvc:               modelSvc,
+	}
+}
@@ -0,0 +1,333 @@
+package controllers
+
+import (
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	delegate2 "github.com/doubletrey/crawlab-core/models/delegate"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/doubletrey/crawlab-core/spider/admin"
+	"github.com/doubletrey/crawlab-core/spider/git"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/dig"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+var GitWebhookController ActionController
+
+var GitWebhookDeliveryController ActionController
+
+func getGitWebhookActions() []Action {
+	ctx := newGitWebhookContext()
+	return []Action{
+		{
+			Method:      http.MethodPost,
+			Path:        "",
+			HandlerFunc: ctx.receive,
+		},
+	}
+}
+
+func getGitWebhookDeliveryActions() []Action {
+	ctx := newGitWebhookContext()
+	return []Action{
+		{
+			Method:      http.MethodGet,
+			Path:        "",
+			HandlerFunc: ctx.getDeliveryList,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/:id",
+			HandlerFunc: ctx.getDelivery,
+		},
+	}
+}
+
+type gitWebhookContext struct {
+	modelSvc service.ModelService
+	gitSvc   interfaces.SpiderGitService
+	adminSvc interfaces.SpiderAdminService
+}
+
+// gitWebhookMaxBodySize max size of webhook payload
+const gitWebhookMaxBodySize = 5 << 20
+
+// receive push webhook of github, gitlab or gitea, which pulls spiders whose
+// git url and branch match the pushed repo and branch, and runs them if
+// webhook run is enabled in their git settings. Spiders are only matched if
+// the webhook is verified with the webhook secret in their git settings, or
+// the global secret "git.webhook.secret" in config if not set. Only verified
+// deliveries are recorded, which are processed in background so that the
+// provider gets a response in time
+func (ctx *gitWebhookContext) receive(c *gin.Context) {
+	// body
+	body, err := ioutil.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, gitWebhookMaxBodySize))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	// webhook
+	wh, err := git.ParseWebhook(c.Request.Header, body)
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	// skip if not branch push
+	if !wh.IsPush || wh.Branch == "" {
+		HandleSuccess(c)
+		return
+	}
+
+	// git settings of the pushed repo
+	gits, err := ctx._getGits(wh)
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+	if len(gits) == 0 {
+		HandleSuccess(c)
+		return
+	}
+
+	// verified git settings
+	var verifiedGits []models.Git
+	for _, g := range gits {
+		secret := g.WebhookSecret
+		if secret == "" {
+			secret = viper.GetString("git.webhook.secret")
+		}
+		if wh.Verify(secret) {
+			verifiedGits = append(verifiedGits, g)
+		}
+	}
+	if len(verifiedGits) == 0 {
+		HandleErrorUnauthorized(c, errors.ErrorGitInvalidSignature)
+		return
+	}
+
+	// delivery
+	d := &models.GitWebhookDelivery{
+		Provider:   wh.Provider,
+		Event:      wh.Event,
+		DeliveryId: wh.DeliveryId,
+		Branch:     wh.Branch,
+		Commit:     wh.Commit,
+		Status:     constants.GitWebhookStatusProcessing,
+		Ts:         time.Now(),
+	}
+	if len(wh.RepoUrls) > 0 {
+		d.RepoUrl = wh.RepoUrls[0]
+	}
+	if err := delegate2.NewModelDelegate(d).Add(); err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithData(c, d)
+
+	// pull and run spiders
+	go ctx._process(d, verifiedGits, wh)
+}
+
+func (ctx *gitWebhookContext) getDeliveryList(c *gin.Context) {
+	// query
+	query := bson.M{}
+	for _, key := range []string{"provider", "status", "branch"} {
+		if value := c.Query(key); value != "" {
+			query[key] = value
+		}
+	}
+	if value := c.Query("spider_id"); value != "" {
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			HandleErrorBadRequest(c, err)
+			return
+		}
+		query["results.spider_id"] = id
+	}
+
+	// pagination
+	pagination := MustGetPagination(c)
+
+	// list
+	list, err := ctx.modelSvc.GetGitWebhookDeliveryList(query, &mongo.FindOptions{
+		Skip:  pagination.Size * (pagination.Page - 1),
+		Limit: pagination.Size,
+		Sort:  bson.D{{Key: "ts", Value: -1}},
+	})
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// total
+	total, err := ctx.modelSvc.GetBaseService(interfaces.ModelIdGitWebhookDelivery).Count(query)
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithListData(c, list, total)
+}
+
+func (ctx *gitWebhookContext) getDelivery(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	d, err := ctx.modelSvc.GetGitWebhookDeliveryById(id)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithData(c, d)
+}
+
+// _getGits git settings with url matching the pushed repo
+func (ctx *gitWebhookContext) _getGits(wh *git.Webhook) (res []models.Git, err error) {
+	gits, err := ctx.modelSvc.GetGitList(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range gits {
+		if wh.MatchUrl(g.Url) {
+			res = append(res, g)
+		}
+	}
+	return res, nil
+}
+
+// _processSpider pull the spider of the git settings if its branch matches the
+// pushed branch and run it if enabled, which returns nil if not matched
+func (ctx *gitWebhookContext) _processSpider(g *models.Git, wh *git.Webhook) (res *models.GitWebhookDeliverySpider) {
+	res = &models.GitWebhookDeliverySpider{
+		SpiderId: g.Id,
+	}
+
+	// spider
+	s, err := ctx.modelSvc.GetSpiderById(g.Id)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return nil
+		}
+		res.Error = err.Error()
+		return res
+	}
+
+	// branch of spider, which is current branch of workspace if not set
+	branch := s.GitBranch
+	if branch == "" {
+		gitClient, err := ctx.gitSvc.GetGitClient(s.Id)
+		if err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		branch, err = gitClient.GetCurrentBranch()
+		if err != nil {
+			res.Error = err.Error()
+			return res
+		}
+	}
+	if branch != wh.Branch {
+		return nil
+	}
+
+	// pull
+	if err := ctx.gitSvc.Sync(s.Id); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if synced, err := ctx.modelSvc.GetSpiderById(s.Id); err == nil {
+		res.Commit = synced.GitSyncCommit
+	}
+
+	// run
+	if g.WebhookRun {
+		if err := ctx.adminSvc.Schedule(s.Id, &interfaces.SpiderRunOptions{
+			Mode:     s.Mode,
+			NodeIds:  s.NodeIds,
+			NodeTags: s.NodeTags,
+			Cmd:      s.Cmd,
+			Param:    s.Param,
+			Priority: s.Priority,
+		}); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		res.Run = true
+	}
+
+	return res
+}
+
+// _process pull and run spiders of verified git settings, and save the
+// delivery with results
+func (ctx *gitWebhookContext) _process(d *models.GitWebhookDelivery, gits []models.Git, wh *git.Webhook) {
+	status := constants.GitWebhookStatusSuccess
+	for _, g := range gits {
+		res := ctx._processSpider(&g, wh)
+		if res == nil {
+			continue
+		}
+		if res.Error != "" {
+			status = constants.GitWebhookStatusError
+		}
+		d.Results = append(d.Results, *res)
+	}
+	if len(d.Results) == 0 {
+		status = constants.GitWebhookStatusIgnored
+	}
+	d.Status = status
+	if err := delegate2.NewModelDelegate(d).Save(); err != nil {
+		trace.PrintError(err)
+	}
+}
+
+func newGitWebhookContext() *gitWebhookContext {
+	// context
+	ctx := &gitWebhookContext{}
+
+	// dependency injection
+	c := dig.New()
+	if err := c.Provide(service.NewService); err != nil {
+		panic(err)
+	}
+	if err := c.Provide(git.ProvideGetSpiderGitService("")); err != nil {
+		panic(err)
+	}
+	if err := c.Provide(admin.NewSpiderAdminService); err != nil {
+		panic(err)
+	}
+	if err := c.Invoke(func(
+		modelSvc service.ModelService,
+		gitSvc interfaces.SpiderGitService,
+		adminSvc interfaces.SpiderAdminService,
+	) {
+		ctx.modelSvc = modelSvc
+		ctx.gitSvc = gitSvc
+		ctx.adminSvc = adminSvc
+	}); err != nil {
+		panic(err)
+	}
+
+	return ctx
+}
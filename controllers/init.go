@@ -32,6 +32,12 @@ func InitControllers() (err error) {
 	VersionController = NewActionControllerDelegate(ControllerIdVersion, getVersionActions())
 	I18nController = NewActionControllerDelegate(ControllerIdI18n, getI18nActions())
 	SystemInfoController = NewActionControllerDelegate(ControllerIdSystemInfo, getSystemInfoActions())
+	RecycleBinController = NewActionControllerDelegate(ControllerIdRecycleBin, getRecycleBinActions())
+	AuditController = NewActionControllerDelegate(ControllerIdAudit, getAuditActions())
+	GitWebhookController = NewActionControllerDelegate(ControllerIdGitWebhook, getGitWebhookActions())
+	GitWebhookDeliveryController = NewActionControllerDelegate(ControllerIdGitWebhookDelivery, getGitWebhookDeliveryActions())
+	SshKeyController = NewActionControllerDelegate(ControllerIdSshKey, getSshKeyActions())
+	CalendarController = newCalendarController()
 
 	return nil
 }
@@ -1,19 +1,36 @@
 package controllers
 
 import (
+	"fmt"
 	"github.com/doubletrey/crawlab-core/errors"
 	"github.com/doubletrey/crawlab-core/interfaces"
 	"github.com/doubletrey/crawlab-core/models/models"
 	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/doubletrey/crawlab-core/spider/admin"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/dig"
+	"net/http"
 )
 
 var ProjectController *projectController
 
+func getProjectActions() []Action {
+	ctx := newProjectContext()
+	return []Action{
+		{
+			Method:      http.MethodGet,
+			Path:        "/:id/export",
+			HandlerFunc: ctx.export,
+		},
+	}
+}
+
 type projectController struct {
 	ListControllerDelegate
+	ActionControllerDelegate
 }
 
 func (ctr *projectController) GetList(c *gin.Context) {
@@ -96,8 +113,73 @@ func newProjectController() *projectController {
 	}
 
 	ctr := NewListControllerDelegate(ControllerIdProject, modelSvc.GetBaseService(interfaces.ModelIdProject))
+	actionCtr := NewActionControllerDelegate(ControllerIdProject, getProjectActions())
 
 	return &projectController{
-		ListControllerDelegate: *ctr,
+		ListControllerDelegate:   *ctr,
+		ActionControllerDelegate: *actionCtr,
 	}
 }
+
+type projectContext struct {
+	modelSvc service.ModelService
+	adminSvc interfaces.SpiderAdminService
+}
+
+// export all spiders of the project as a zip or tar.gz archive given by query
+// field "format", which can be imported on another cluster
+func (ctx *projectContext) export(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	// validate
+	if _, err := ctx.modelSvc.GetProjectById(id); err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// spiders
+	spiders, err := ctx.modelSvc.GetSpiderList(bson.M{"project_id": id}, nil)
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+	var ids []primitive.ObjectID
+	for _, s := range spiders {
+		ids = append(ids, s.Id)
+	}
+
+	exportSpiderArchive(c, ctx.adminSvc, ids, fmt.Sprintf("project-%s", id.Hex()))
+}
+
+func newProjectContext() *projectContext {
+	// context
+	ctx := &projectContext{}
+
+	// dependency injection
+	c := dig.New()
+	if err := c.Provide(service.NewService); err != nil {
+		panic(err)
+	}
+	if err := c.Provide(admin.NewSpiderAdminService); err != nil {
+		panic(err)
+	}
+	if err := c.Invoke(func(
+		modelSvc service.ModelService,
+		adminSvc interfaces.SpiderAdminService,
+	) {
+		ctx.modelSvc = modelSvc
+		ctx.adminSvc = adminSvc
+	}); err != nil {
+		panic(err)
+	}
+
+	return ctx
+}
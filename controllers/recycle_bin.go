@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/recycle"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/dig"
+	"net/http"
+)
+
+var RecycleBinController ActionController
+
+func getRecycleBinActions() []Action {
+	ctx := newRecycleBinContext()
+	return []Action{
+		{
+			Method:      http.MethodGet,
+			Path:        "",
+			HandlerFunc: ctx.getList,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/:id/restore",
+			HandlerFunc: ctx.restore,
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/:id",
+			HandlerFunc: ctx.purge,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/purge",
+			HandlerFunc: ctx.purgeExpired,
+		},
+	}
+}
+
+type recycleBinContext struct {
+	recycleSvc interfaces.RecycleService
+}
+
+func (ctx *recycleBinContext) getList(c *gin.Context) {
+	// query by model type, i.e. collection name
+	query := bson.M{}
+	if col := c.Query("col"); col != "" {
+		query["_col"] = col
+	}
+
+	// pagination
+	pagination := MustGetPagination(c)
+
+	list, total, err := ctx.recycleSvc.GetList(query, &mongo.FindOptions{
+		Skip:  pagination.Size * (pagination.Page - 1),
+		Limit: pagination.Size,
+	})
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithListData(c, list, total)
+}
+
+func (ctx *recycleBinContext) restore(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	if err := ctx.recycleSvc.Restore(id, GetUserFromContext(c)); err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return
+		}
+		if err == errors.ErrorModelAlreadyExists {
+			HandleErrorBadRequest(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccess(c)
+}
+
+func (ctx *recycleBinContext) purge(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	if err := ctx.recycleSvc.Purge(id); err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccess(c)
+}
+
+func (ctx *recycleBinContext) purgeExpired(c *gin.Context) {
+	if err := ctx.recycleSvc.PurgeExpired(); err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+	HandleSuccess(c)
+}
+
+func newRecycleBinContext() *recycleBinContext {
+	// context
+	ctx := &recycleBinContext{}
+
+	// dependency injection
+	c := dig.New()
+	if err := c.Provide(recycle.ProvideGetRecycleService("")); err != nil {
+		panic(err)
+	}
+	if err := c.Invoke(func(
+		recycleSvc interfaces.RecycleService,
+	) {
+		ctx.recycleSvc = recycleSvc
+	}); err != nil {
+		panic(err)
+	}
+
+	return ctx
+}
@@ -2,16 +2,22 @@ package controllers
 
 import (
 	"github.com/doubletrey/crawlab-core/config"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
 	"github.com/doubletrey/crawlab-core/interfaces"
 	"github.com/doubletrey/crawlab-core/models/delegate"
 	"github.com/doubletrey/crawlab-core/models/models"
 	"github.com/doubletrey/crawlab-core/models/service"
 	"github.com/doubletrey/crawlab-core/schedule"
+	"github.com/doubletrey/crawlab-db/mongo"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/dig"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 var ScheduleController *scheduleController
@@ -29,6 +35,26 @@ func getScheduleActions() []Action {
 			Path:        "/:id/disable",
 			HandlerFunc: scheduleCtx.disable,
 		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/:id/skips",
+			HandlerFunc: scheduleCtx.getSkipList,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/:id/preview",
+			HandlerFunc: scheduleCtx.preview,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/preview",
+			HandlerFunc: scheduleCtx.previewSpec,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/render",
+			HandlerFunc: scheduleCtx.render,
+		},
 	}
 }
 
@@ -44,6 +70,11 @@ func (ctr *scheduleController) Put(c *gin.Context) {
 		HandleErrorBadRequest(c, err)
 		return
 	}
+	if err := ctr.ctx._validate(&s); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	s.LastFireTs = time.Time{}
 	if err := delegate.NewModelDelegate(&s, GetUserFromContext(c)).Add(); err != nil {
 		HandleErrorInternalServerError(c, err)
 		return
@@ -57,6 +88,62 @@ func (ctr *scheduleController) Put(c *gin.Context) {
 	HandleSuccessWithData(c, s)
 }
 
+func (ctr *scheduleController) Post(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	var s models.Schedule
+	if err := c.ShouldBindJSON(&s); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	if s.Id != id {
+		HandleErrorBadRequest(c, errors.ErrorHttpBadRequest)
+		return
+	}
+	if err := ctr.ctx._validate(&s); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	existing, err := ctr.ctx.modelSvc.GetScheduleById(id)
+	if err != nil {
+		HandleErrorNotFound(c, err)
+		return
+	}
+	// cron entry and last fire time are maintained by schedule service
+	s.EntryId = existing.EntryId
+	s.LastFireTs = existing.LastFireTs
+
+	// the schedule is removed from cron if its scheduling is changed, and
+	// added back with the changed scheduling if enabled, where fires before
+	// the change are not regarded as missed
+	changed := ctr.ctx._isSchedulingChanged(existing, &s)
+	if changed {
+		if err := ctr.ctx.scheduleSvc.Disable(existing, GetUserFromContext(c)); err != nil {
+			HandleErrorInternalServerError(c, err)
+			return
+		}
+		s.EntryId = -1
+	}
+	enable := changed && s.Enabled
+	if enable {
+		s.Enabled = false
+	}
+	if err := delegate.NewModelDelegate(&s, GetUserFromContext(c)).Save(); err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+	if enable {
+		if err := ctr.ctx.scheduleSvc.Enable(&s, GetUserFromContext(c)); err != nil {
+			HandleErrorInternalServerError(c, err)
+			return
+		}
+	}
+	HandleSuccessWithData(c, s)
+}
+
 func (ctr *scheduleController) Delete(c *gin.Context) {
 	id := c.Param("id")
 	oid, err := primitive.ObjectIDFromHex(id)
@@ -131,6 +218,190 @@ func (ctx *scheduleContext) disable(c *gin.Context) {
 	HandleSuccess(c)
 }
 
+// getSkipList fires of the schedule skipped due to its overlap policy
+func (ctx *scheduleContext) getSkipList(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	query := bson.M{"schedule_id": id}
+
+	// pagination
+	pagination := MustGetPagination(c)
+
+	// list
+	list, err := ctx.modelSvc.GetScheduleSkipList(query, &mongo.FindOptions{
+		Skip:  pagination.Size * (pagination.Page - 1),
+		Limit: pagination.Size,
+		Sort:  bson.D{{Key: "ts", Value: -1}},
+	})
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// total
+	total, err := ctx.modelSvc.GetBaseService(interfaces.ModelIdScheduleSkip).Count(query)
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithListData(c, list, total)
+}
+
+// preview next fire times of the schedule, whose number is given by query
+// field "n" (default: 10)
+func (ctx *scheduleContext) preview(c *gin.Context) {
+	s, err := ctx._getSchedule(c)
+	if err != nil {
+		return
+	}
+	if s.Type == constants.ScheduleTypeOnce {
+		// the only fire of one-off schedule
+		var times []time.Time
+		if s.RunAt.After(time.Now()) {
+			times = append(times, s.RunAt)
+		}
+		HandleSuccessWithData(c, times)
+		return
+	}
+	n, err := strconv.Atoi(c.DefaultQuery("n", strconv.Itoa(schedulePreviewDefaultCount)))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	ctx._preview(c, s.Cron, s.Timezone, n)
+}
+
+// previewSpec preview next fire times of the unsaved cron spec and time zone
+func (ctx *scheduleContext) previewSpec(c *gin.Context) {
+	var payload schedulePreviewPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	if payload.N == 0 {
+		payload.N = schedulePreviewDefaultCount
+	}
+	ctx._preview(c, payload.Cron, payload.Timezone, payload.N)
+}
+
+func (ctx *scheduleContext) _preview(c *gin.Context, spec, timezone string, n int) {
+	if n <= 0 || n > schedulePreviewMaxCount {
+		HandleErrorBadRequest(c, errors.ErrorHttpBadRequest)
+		return
+	}
+	times, err := ctx.scheduleSvc.Preview(spec, timezone, n)
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	HandleSuccessWithData(c, times)
+}
+
+// render param and environment variables of the unsaved schedule as they would
+// be at its next fire, where fire time and previous fire time can be given in
+// payload instead
+func (ctx *scheduleContext) render(c *gin.Context) {
+	var payload scheduleRenderPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	s := &payload.Schedule
+	if err := schedule.ValidateTemplates(s); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	// location
+	loc := ctx.scheduleSvc.GetLocation()
+	if s.Timezone != "" {
+		l, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			HandleErrorBadRequest(c, errors.ErrorScheduleInvalidTimezone)
+			return
+		}
+		loc = l
+	}
+
+	// fire times, which are the next fire and the last fire by default
+	fireTs := payload.FireTime
+	if fireTs.IsZero() {
+		fireTs = time.Now().Truncate(time.Second)
+		if sch, err := schedule.GetSchedule(s); err == nil {
+			if next := sch.Next(time.Now()); !next.IsZero() {
+				fireTs = next
+			}
+		}
+	}
+	prevFireTs := payload.PrevFireTime
+	if prevFireTs.IsZero() {
+		prevFireTs = s.LastFireTs
+	}
+
+	// global variables
+	variables, err := ctx.modelSvc.GetVariableList(nil, nil)
+	if err != nil && err != mongo2.ErrNoDocuments {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// render
+	data := schedule.NewTemplateData(s, fireTs, prevFireTs, loc, variables)
+	param, envs, err := schedule.Render(s.Param, s.Envs, data)
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	HandleSuccessWithData(c, scheduleRenderResult{
+		Param:        param,
+		Envs:         envs,
+		FireTime:     data.FireTime.Time,
+		PrevFireTime: data.PrevFireTime.Time,
+	})
+}
+
+// _validate settings of the schedule
+func (ctx *scheduleContext) _validate(s *models.Schedule) (err error) {
+	if !schedule.IsValidType(s.Type) {
+		return errors.ErrorScheduleInvalidType
+	}
+	if !schedule.IsValidOverlapPolicy(s.OverlapPolicy) {
+		return errors.ErrorScheduleInvalidOverlapPolicy
+	}
+	if !schedule.IsValidMisfirePolicy(s.MisfirePolicy) {
+		return errors.ErrorScheduleInvalidMisfirePolicy
+	}
+	if !schedule.IsValidBlackoutPolicy(s.BlackoutPolicy) {
+		return errors.ErrorScheduleInvalidBlackoutPolicy
+	}
+	if _, err := schedule.GetSchedule(s); err != nil {
+		return err
+	}
+	if s.Type == constants.ScheduleTypeOnce && s.Enabled && !s.RunAt.After(time.Now()) {
+		return errors.ErrorScheduleInvalidRunAt
+	}
+	if err := schedule.ValidateBlackouts(s.Blackouts); err != nil {
+		return err
+	}
+	if err := schedule.ValidateTemplates(s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// _isSchedulingChanged whether fields deciding fires of the schedule are changed
+func (ctx *scheduleContext) _isSchedulingChanged(existing, s *models.Schedule) (ok bool) {
+	return s.Cron != existing.Cron ||
+		s.Timezone != existing.Timezone ||
+		s.Type != existing.Type ||
+		!s.RunAt.Equal(existing.RunAt) ||
+		s.Enabled != existing.Enabled
+}
+
 func (ctx *scheduleContext) _getSchedule(c *gin.Context) (s *models.Schedule, err error) {
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
@@ -147,6 +418,30 @@ func (ctx *scheduleContext) _getSchedule(c *gin.Context) (s *models.Schedule, er
 	return s, nil
 }
 
+const (
+	schedulePreviewDefaultCount = 10
+	schedulePreviewMaxCount     = 100
+)
+
+type schedulePreviewPayload struct {
+	Cron     string `json:"cron"`
+	Timezone string `json:"timezone"`
+	N        int    `json:"n"` // number of fire times, default: 10
+}
+
+type scheduleRenderPayload struct {
+	models.Schedule
+	FireTime     time.Time `json:"fire_time"`      // next fire time of the schedule if empty
+	PrevFireTime time.Time `json:"prev_fire_time"` // last fire time of the schedule if empty
+}
+
+type scheduleRenderResult struct {
+	Param        string            `json:"param"`
+	Envs         map[string]string `json:"envs"`
+	FireTime     time.Time         `json:"fire_time"`
+	PrevFireTime time.Time         `json:"prev_fire_time"`
+}
+
 type scheduleContext struct {
 	modelSvc    service.ModelService
 	scheduleSvc interfaces.ScheduleService
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	vcs "github.com/crawlab-team/crawlab-vcs"
 	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/config"
 	"github.com/doubletrey/crawlab-core/constants"
 	"github.com/doubletrey/crawlab-core/entity"
 	"github.com/doubletrey/crawlab-core/errors"
@@ -12,13 +13,13 @@ import (
 	delegate2 "github.com/doubletrey/crawlab-core/models/delegate"
 	"github.com/doubletrey/crawlab-core/models/models"
 	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/doubletrey/crawlab-core/schedule"
 	"github.com/doubletrey/crawlab-core/spider/admin"
+	"github.com/doubletrey/crawlab-core/spider/git"
 	"github.com/doubletrey/crawlab-core/spider/sync"
 	"github.com/doubletrey/crawlab-core/utils"
 	"github.com/doubletrey/crawlab-db/mongo"
 	"github.com/gin-gonic/gin"
-	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -29,6 +30,7 @@ import (
 	"math"
 	"net/http"
 	"path"
+	"sort"
 	"strings"
 )
 
@@ -62,6 +64,11 @@ func getSpiderActions() []Action {
 			Path:        "/:id/files/save/dir",
 			HandlerFunc: ctx.saveDir,
 		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/:id/files/upload",
+			HandlerFunc: ctx.uploadArchive,
+		},
 		{
 			Method:      http.MethodPost,
 			Path:        "/:id/files/rename",
@@ -102,11 +109,21 @@ func getSpiderActions() []Action {
 			Path:        "/:id/git/commit",
 			HandlerFunc: ctx.gitCommit,
 		},
-		//{
-		//	Method:      http.MethodPost,
-		//	Path:        "/:id/clone",
-		//	HandlerFunc: ctx.clone,
-		//},
+		{
+			Method:      http.MethodPost,
+			Path:        "/:id/clone",
+			HandlerFunc: ctx.clone,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/:id/export",
+			HandlerFunc: ctx.export,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/import",
+			HandlerFunc: ctx.importSpiders,
+		},
 		{
 			Path:        "/:id/data-source",
 			Method:      http.MethodGet,
@@ -117,6 +134,16 @@ func getSpiderActions() []Action {
 			Method:      http.MethodPost,
 			HandlerFunc: ctx.postDataSource,
 		},
+		{
+			Path:        "/:id/dependencies",
+			Method:      http.MethodGet,
+			HandlerFunc: ctx.getDependencies,
+		},
+		{
+			Path:        "/:id/dependencies/logs",
+			Method:      http.MethodGet,
+			HandlerFunc: ctx.getDependencyLogs,
+		},
 	}
 }
 
@@ -146,6 +173,36 @@ func (ctr *spiderController) Post(c *gin.Context) {
 	HandleSuccessWithData(c, s)
 }
 
+func (ctr *spiderController) Delete(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	res, err := ctr.ctx._delete(c, id)
+	if err != nil {
+		return
+	}
+	HandleSuccessWithData(c, res)
+}
+
+func (ctr *spiderController) DeleteList(c *gin.Context) {
+	payload, err := NewJsonBinder(ControllerIdSpider).BindBatchRequestPayload(c)
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	var results []*interfaces.SpiderDeleteResult
+	for _, id := range payload.Ids {
+		res, err := ctr.ctx._delete(c, id)
+		if err != nil {
+			return
+		}
+		results = append(results, res)
+	}
+	HandleSuccessWithData(c, results)
+}
+
 func (ctr *spiderController) GetList(c *gin.Context) {
 	withStats := c.Query("stats")
 	if withStats == "" {
@@ -160,6 +217,7 @@ type spiderContext struct {
 	modelSpiderSvc interfaces.ModelBaseService
 	syncSvc        interfaces.SpiderSyncService
 	adminSvc       interfaces.SpiderAdminService
+	gitSvc         interfaces.SpiderGitService
 }
 
 func (ctx *spiderContext) listDir(c *gin.Context) {
@@ -243,6 +301,52 @@ func (ctx *spiderContext) saveDir(c *gin.Context) {
 	HandleSuccess(c)
 }
 
+// uploadArchive extract files in the uploaded zip or tar.gz archive to the
+// spider, which are merged into existing files or replace them all according
+// to form field "mode". The top-level directory shared by all files in the
+// archive is stripped if form field "strip" is true
+func (ctx *spiderContext) uploadArchive(c *gin.Context) {
+	_, payload, fsSvc, err := ctx._processFileRequest(c, http.MethodPost)
+	if err != nil {
+		return
+	}
+
+	// mode
+	mode := c.DefaultPostForm("mode", constants.FsUploadModeMerge)
+	if mode != constants.FsUploadModeMerge && mode != constants.FsUploadModeReplace {
+		HandleErrorBadRequest(c, errors.ErrorFsInvalidUploadMode)
+		return
+	}
+
+	// files in archive, which is rejected if any path is invalid
+	files, err := utils.ReadArchive([]byte(payload.Data), "")
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	if len(files) == 0 {
+		HandleErrorBadRequest(c, errors.ErrorFsInvalidArchive)
+		return
+	}
+	if c.PostForm("strip") == "true" {
+		files, _ = utils.StripArchiveTopDir(files)
+	}
+	var paths []string
+	for p, data := range files {
+		files[p] = utils.FillEmptyFileData(data)
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	// save
+	if err := fsSvc.SaveFiles(files, mode == constants.FsUploadModeReplace); err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithData(c, paths)
+}
+
 func (ctx *spiderContext) renameFile(c *gin.Context) {
 	_, payload, fsSvc, err := ctx._processFileRequest(c, http.MethodPost)
 	if err != nil {
@@ -313,6 +417,113 @@ func (ctx *spiderContext) run(c *gin.Context) {
 	HandleSuccess(c)
 }
 
+func (ctx *spiderContext) clone(c *gin.Context) {
+	// spider id
+	id, err := ctx._processActionRequest(c)
+	if err != nil {
+		return
+	}
+
+	// options, which are optional
+	var opts interfaces.SpiderCloneOptions
+	if err := c.ShouldBindJSON(&opts); err != nil && err != io.EOF {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	// clone
+	newId, err := ctx.adminSvc.Clone(id, &opts, GetUserFromContext(c))
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// new spider
+	s, err := ctx.modelSvc.GetSpiderById(newId)
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithData(c, s)
+}
+
+// export the spider with its files, schedules, data collection and tags as a
+// zip or tar.gz archive given by query field "format"
+func (ctx *spiderContext) export(c *gin.Context) {
+	// spider id
+	id, err := ctx._processActionRequest(c)
+	if err != nil {
+		return
+	}
+
+	// validate
+	if _, err := ctx.modelSvc.GetSpiderById(id); err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	exportSpiderArchive(c, ctx.adminSvc, []primitive.ObjectID{id}, fmt.Sprintf("spider-%s", id.Hex()))
+}
+
+// importSpiders import spiders from an uploaded archive created by export, where spiders
+// with duplicate names are handled according to form field "conflict"
+func (ctx *spiderContext) importSpiders(c *gin.Context) {
+	// options
+	var opts interfaces.SpiderImportOptions
+	if err := c.ShouldBind(&opts); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	switch opts.Conflict {
+	case "", constants.SpiderImportConflictRename, constants.SpiderImportConflictSkip, constants.SpiderImportConflictOverwrite:
+	default:
+		HandleErrorBadRequest(c, errors.ErrorSpiderInvalidImportConflict)
+		return
+	}
+	if projectId := c.PostForm("project_id"); projectId != "" {
+		id, err := primitive.ObjectIDFromHex(projectId)
+		if err != nil {
+			HandleErrorBadRequest(c, err)
+			return
+		}
+		opts.ProjectId = id
+	}
+
+	// archive
+	payload, err := ctx._getFileRequestMultipartPayload(c)
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	data := []byte(payload.Data)
+	if utils.DetectArchiveFormat(data) == "" {
+		HandleErrorBadRequest(c, errors.ErrorFsInvalidArchive)
+		return
+	}
+
+	// import
+	res, err := ctx.adminSvc.Import(data, &opts, GetUserFromContext(c))
+	if err != nil {
+		if isInvalidArchiveError(err) {
+			HandleErrorBadRequest(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithData(c, res)
+}
+
 func (ctx *spiderContext) getGit(c *gin.Context) {
 	// spider id
 	id, err := ctx._processActionRequest(c)
@@ -334,14 +545,14 @@ func (ctx *spiderContext) getGit(c *gin.Context) {
 	}
 
 	// git client
-	gitClient, err := ctx._getGitClient(id, fsSvc)
+	gitClient, err := ctx.gitSvc.GetGitClient(id)
 	if err != nil {
 		HandleErrorInternalServerError(c, err)
 		return
 	}
 
 	// current branch
-	currentBranch, err := ctx._getCurrentBranch(gitClient)
+	currentBranch, err := ctx._getCurrentBranch(id, gitClient)
 	if err != nil {
 		HandleErrorInternalServerError(c, err)
 		return
@@ -421,28 +632,8 @@ func (ctx *spiderContext) getGitRemoteRefs(c *gin.Context) {
 		remoteName = vcs.GitRemoteNameUpstream
 	}
 
-	// spider fs service
-	fsSvc, err := ctx.syncSvc.GetFsService(id)
-	if err != nil {
-		HandleErrorInternalServerError(c, err)
-		return
-	}
-
-	// sync from remote to workspace
-	//if err := fsSvc.GetFsService().SyncToWorkspace(); err != nil {
-	//	HandleErrorInternalServerError(c, err)
-	//	return
-	//}
-
-	// git client
-	gitClient, err := ctx._getGitClient(id, fsSvc)
-	if err != nil {
-		HandleErrorInternalServerError(c, err)
-		return
-	}
-
 	// refs
-	refs, err := gitClient.GetRemoteRefs(remoteName)
+	refs, err := ctx.gitSvc.GetRemoteRefs(id, remoteName)
 	if err != nil {
 		HandleErrorInternalServerError(c, err)
 		return
@@ -465,82 +656,12 @@ func (ctx *spiderContext) gitPull(c *gin.Context) {
 		return
 	}
 
-	// spider fs service
-	fsSvc, err := ctx.syncSvc.GetFsService(id)
-	if err != nil {
-		HandleErrorInternalServerError(c, err)
-		return
-	}
-
-	// git
-	g, err := ctx.modelSvc.GetGitById(id)
-	if err != nil {
-		HandleErrorInternalServerError(c, err)
-		return
-	}
-
-	// git client
-	gitClient, err := ctx._getGitClient(id, fsSvc)
-	if err != nil {
-		HandleErrorInternalServerError(c, err)
-		return
-	}
-
-	// set remote
-	r, err := gitClient.GetRemote(constants.GitRemoteNameUpstream)
-	if err != nil {
-		if err == git.ErrRemoteNotFound {
-			// create upstream remote if not exists
-			r, err = ctx._createGitRemote(gitClient, g)
-			if err != nil {
-				HandleErrorInternalServerError(c, err)
-				return
-			}
-		} else {
-			// error
-			HandleErrorInternalServerError(c, err)
+	// pull target branch (current branch by default) and sync to fs
+	if _, err := ctx.gitSvc.Pull(id, payload.Branch); err != nil {
+		if err == errors.ErrorGitDirtyWorktree {
+			HandleError(http.StatusConflict, c, err)
 			return
 		}
-	} else {
-		// re-create upstream remote if remote urls not matched
-		if len(r.Config().URLs) == 0 || r.Config().URLs[0] != g.Url {
-			// delete existing upstream remote
-			if err := gitClient.DeleteRemote(constants.GitRemoteNameUpstream); err != nil {
-				HandleErrorInternalServerError(c, err)
-				return
-			}
-
-			// create upstream remote if not exists
-			r, err = ctx._createGitRemote(gitClient, g)
-			if err != nil {
-				HandleErrorInternalServerError(c, err)
-				return
-			}
-		}
-	}
-
-	// branch to pull
-	var branch string
-	if payload.Branch == "" {
-		// by default current branch
-		branch, err = gitClient.GetCurrentBranch()
-		if err != nil {
-			HandleErrorInternalServerError(c, err)
-			return
-		}
-	} else {
-		// payload branch
-		branch = payload.Branch
-	}
-
-	// attempt to pull with target branch
-	if err := ctx._gitPull(gitClient, constants.GitRemoteNameUpstream, branch); err != nil {
-		HandleErrorInternalServerError(c, err)
-		return
-	}
-
-	// sync to fs
-	if err := fsSvc.GetFsService().SyncToFs(interfaces.WithOnlyFromWorkspace()); err != nil {
 		HandleErrorInternalServerError(c, err)
 		return
 	}
@@ -576,7 +697,7 @@ func (ctx *spiderContext) gitCommit(c *gin.Context) {
 	}
 
 	// git client
-	gitClient, err := ctx._getGitClient(id, fsSvc)
+	gitClient, err := ctx.gitSvc.GetGitClient(id)
 	if err != nil {
 		HandleErrorInternalServerError(c, err)
 		return
@@ -596,9 +717,17 @@ func (ctx *spiderContext) gitCommit(c *gin.Context) {
 		return
 	}
 
+	// auth
+	auth, err := ctx.gitSvc.GetGitAuth(id)
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
 	// push
 	if err := gitClient.Push(
 		vcs.WithRemoteNamePush(vcs.GitRemoteNameUpstream),
+		vcs.WithAuthPush(auth),
 	); err != nil {
 		HandleErrorInternalServerError(c, err)
 		return
@@ -675,6 +804,75 @@ func (ctx *spiderContext) postDataSource(c *gin.Context) {
 	HandleSuccess(c)
 }
 
+func (ctx *spiderContext) getDependencies(c *gin.Context) {
+	// spider id
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	// query
+	query := bson.M{"spider_id": id}
+	if c.Query("node_id") != "" {
+		nodeId, err := primitive.ObjectIDFromHex(c.Query("node_id"))
+		if err != nil {
+			HandleErrorBadRequest(c, err)
+			return
+		}
+		query["node_id"] = nodeId
+	}
+
+	// dependency envs
+	envs, err := ctx.modelSvc.GetDependencyEnvList(query, &mongo.FindOptions{
+		Sort: bson.D{{Key: "node_id", Value: 1}, {Key: "type", Value: 1}},
+	})
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// exclude logs
+	for i := range envs {
+		envs[i].Logs = ""
+	}
+
+	HandleSuccessWithData(c, envs)
+}
+
+func (ctx *spiderContext) getDependencyLogs(c *gin.Context) {
+	// spider id
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	// node id
+	nodeId, err := primitive.ObjectIDFromHex(c.Query("node_id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	// dependency env
+	e, err := ctx.modelSvc.GetDependencyEnv(bson.M{
+		"spider_id": id,
+		"node_id":   nodeId,
+		"type":      c.Query("type"),
+	}, nil)
+	if err == mongo2.ErrNoDocuments {
+		HandleErrorNotFound(c, err)
+		return
+	}
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithData(c, e)
+}
+
 func (ctx *spiderContext) _get(c *gin.Context) {
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
@@ -769,6 +967,30 @@ func (ctx *spiderContext) _put(c *gin.Context) (s *models.Spider, err error) {
 	return s, nil
 }
 
+// _delete delete spider together with its files, schedules and queued tasks,
+// with options of deleting historical tasks and dry run given in query parameters
+func (ctx *spiderContext) _delete(c *gin.Context, id primitive.ObjectID) (res *interfaces.SpiderDeleteResult, err error) {
+	// options
+	var opts interfaces.SpiderDeleteOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		HandleErrorBadRequest(c, err)
+		return nil, err
+	}
+
+	// delete
+	res, err = ctx.adminSvc.Delete(id, &opts, GetUserFromContext(c))
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return nil, err
+		}
+		HandleErrorInternalServerError(c, err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
 func (ctx *spiderContext) _getListWithStats(c *gin.Context) {
 	// params
 	pagination := MustGetPagination(c)
@@ -962,6 +1184,46 @@ func (ctx *spiderContext) _getFileRequestMultipartPayload(c *gin.Context) (paylo
 	return
 }
 
+// exportSpiderArchive respond with the archive of the spiders named after
+// fileName, whose format is given by query field "format"
+func exportSpiderArchive(c *gin.Context, adminSvc interfaces.SpiderAdminService, ids []primitive.ObjectID, fileName string) {
+	// format
+	format := c.DefaultQuery("format", constants.ArchiveFormatZip)
+	if format != constants.ArchiveFormatZip && format != constants.ArchiveFormatTarGz {
+		HandleErrorBadRequest(c, errors.ErrorFsInvalidArchive)
+		return
+	}
+
+	// archive streamed to response, where the error can only be responded if
+	// nothing has been written yet
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", fileName, format))
+	c.Header("Content-Type", utils.GetArchiveContentType(format))
+	if err := adminSvc.Export(ids, format, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			HandleErrorInternalServerError(c, err)
+			return
+		}
+		trace.PrintError(err)
+	}
+	c.Abort()
+}
+
+// isInvalidArchiveError whether the error of importing spiders is caused by
+// the archive, e.g. invalid format, file paths or manifest
+func isInvalidArchiveError(err error) (ok bool) {
+	for _, e := range []error{
+		errors.ErrorFsInvalidArchive,
+		errors.ErrorFsInvalidArchivePath,
+		errors.ErrorSpiderInvalidManifest,
+	} {
+		if strings.HasPrefix(err.Error(), e.Error()) {
+			return true
+		}
+	}
+	return false
+}
+
 func (ctx *spiderContext) _processActionRequest(c *gin.Context) (id primitive.ObjectID, err error) {
 	// id
 	id, err = primitive.ObjectIDFromHex(c.Param("id"))
@@ -1021,87 +1283,7 @@ func (ctx *spiderContext) _getGitIgnore(fsSvc interfaces.SpiderFsService) (ignor
 	return ignore, nil
 }
 
-func (ctx *spiderContext) _createGitRemote(gitClient *vcs.GitClient, g *models.Git) (r *git.Remote, err error) {
-	r, err = gitClient.CreateRemote(&config.RemoteConfig{
-		Name: constants.GitRemoteNameUpstream,
-		URLs: []string{g.Url},
-	})
-	if err != nil {
-		return nil, trace.TraceError(err)
-	}
-	return r, nil
-}
-
-func (ctx *spiderContext) _gitPull(gitClient *vcs.GitClient, remote, branch string) (err error) {
-	// remote refs
-	//remoteRefs, err := gitClient.GetRemoteRefs(remote)
-	//if err != nil {
-	//	return err
-	//}
-
-	// ref
-	//var ref *plumbing.Reference
-	//for _, remoteRef := range remoteRefs {
-	//	if remoteRef.Type == vcs.GitRefTypeBranch && remoteRef.Name == branch {
-	//		ref = plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), plumbing.NewHash(remoteRef.Hash))
-	//		break
-	//	}
-	//}
-
-	// reset
-	_ = gitClient.Reset()
-
-	// checkout to target branch
-	//_ = gitClient.CheckoutBranchWithRemoteFromRef(branch, remote, ref)
-
-	// pull
-	if err := gitClient.Pull(
-		vcs.WithRemoteNamePull(remote),
-		vcs.WithBranchNamePull(branch),
-	); err != nil {
-		return trace.TraceError(err)
-	}
-
-	// reset
-	_ = gitClient.Reset()
-
-	return nil
-}
-
-func (ctx *spiderContext) _getGitClient(id primitive.ObjectID, fsSvc interfaces.SpiderFsService) (gitClient *vcs.GitClient, err error) {
-	// auth type
-	authType := ""
-
-	// git
-	g, err := ctx.modelSvc.GetGitById(id)
-	if err != nil {
-		if err != mongo2.ErrNoDocuments {
-			return nil, trace.TraceError(err)
-		}
-	} else {
-		authType = g.AuthType
-	}
-
-	// git client
-	gitClient = fsSvc.GetFsService().GetGitClient()
-
-	switch authType {
-	case constants.GitAuthTypeHttp:
-		gitClient.SetAuthType(vcs.GitAuthTypeHTTP)
-		gitClient.SetUsername(g.Username)
-		gitClient.SetPassword(g.Password)
-	case constants.GitAuthTypeSsh:
-		gitClient.SetAuthType(vcs.GitAuthTypeSSH)
-		gitClient.SetUsername(g.Username)
-		gitClient.SetPrivateKey(g.Password)
-	default:
-		return gitClient, nil
-	}
-
-	return gitClient, nil
-}
-
-func (ctx *spiderContext) _getCurrentBranch(gitClient *vcs.GitClient) (currentBranch string, err error) {
+func (ctx *spiderContext) _getCurrentBranch(id primitive.ObjectID, gitClient *vcs.GitClient) (currentBranch string, err error) {
 	// current branch from repo
 	currentBranch, err = gitClient.GetCurrentBranch()
 	if err != nil {
@@ -1109,7 +1291,7 @@ func (ctx *spiderContext) _getCurrentBranch(gitClient *vcs.GitClient) (currentBr
 	}
 
 	// remote refs
-	remoteRefs, err := gitClient.GetRemoteRefs(constants.GitRemoteNameUpstream)
+	remoteRefs, err := ctx.gitSvc.GetRemoteRefs(id, constants.GitRemoteNameUpstream)
 	if err != nil {
 		return currentBranch, err
 	}
@@ -1179,14 +1361,24 @@ func newSpiderContext() *spiderContext {
 	if err := c.Provide(admin.NewSpiderAdminService); err != nil {
 		panic(err)
 	}
+	if err := c.Provide(git.ProvideGetSpiderGitService("")); err != nil {
+		panic(err)
+	}
+	if err := c.Provide(schedule.ProvideGetScheduleService(config.DefaultConfigPath)); err != nil {
+		panic(err)
+	}
 	if err := c.Invoke(func(
 		modelSvc service.ModelService,
 		syncSvc interfaces.SpiderSyncService,
 		adminSvc interfaces.SpiderAdminService,
+		gitSvc interfaces.SpiderGitService,
+		scheduleSvc interfaces.ScheduleService,
 	) {
 		ctx.modelSvc = modelSvc
 		ctx.syncSvc = syncSvc
 		ctx.adminSvc = adminSvc
+		ctx.gitSvc = gitSvc
+		ctx.adminSvc.SetScheduleService(scheduleSvc)
 	}); err != nil {
 		panic(err)
 	}
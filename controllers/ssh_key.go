@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/delegate"
+	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/doubletrey/crawlab-core/spider/git"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/dig"
+	"net/http"
+)
+
+var SshKeyController ActionController
+
+func getSshKeyActions() []Action {
+	ctx := newSshKeyContext()
+	return []Action{
+		{
+			Method:      http.MethodGet,
+			Path:        "",
+			HandlerFunc: ctx.getList,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/:id",
+			HandlerFunc: ctx.get,
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/:id/public-key",
+			HandlerFunc: ctx.getPublicKey,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "",
+			HandlerFunc: ctx.upload,
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/generate",
+			HandlerFunc: ctx.generate,
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/:id",
+			HandlerFunc: ctx.put,
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/:id",
+			HandlerFunc: ctx.delete,
+		},
+	}
+}
+
+type sshKeyContext struct {
+	modelSvc service.ModelService
+}
+
+type sshKeyPayload struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`        // key type to generate, ed25519 (default) or rsa
+	PrivateKey  string `json:"private_key"` // private key in PEM format to upload
+	Passphrase  string `json:"passphrase"`  // passphrase of uploaded private key if encrypted
+}
+
+func (ctx *sshKeyContext) getList(c *gin.Context) {
+	// pagination
+	pagination := MustGetPagination(c)
+
+	// list
+	list, err := ctx.modelSvc.GetSshKeyList(nil, &mongo.FindOptions{
+		Skip:  pagination.Size * (pagination.Page - 1),
+		Limit: pagination.Size,
+		Sort:  bson.D{{Key: "ts", Value: -1}},
+	})
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// total
+	total, err := ctx.modelSvc.GetBaseService(interfaces.ModelIdSshKey).Count(nil)
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithListData(c, list, total)
+}
+
+func (ctx *sshKeyContext) get(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	k, err := ctx.modelSvc.GetSshKeyById(id)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithData(c, k)
+}
+
+// getPublicKey public key in authorized_keys format to be added as deploy key
+// of git remotes
+func (ctx *sshKeyContext) getPublicKey(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	k, err := ctx.modelSvc.GetSshKeyById(id)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithData(c, k.PublicKey)
+}
+
+// upload existing private key, which is stored encrypted
+func (ctx *sshKeyContext) upload(c *gin.Context) {
+	var payload sshKeyPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	k, err := git.NewSshKey(payload.Name, payload.PrivateKey, payload.Passphrase)
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	k.Description = payload.Description
+
+	if err := delegate.NewModelDelegate(k, GetUserFromContext(c)).Add(); err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithData(c, k)
+}
+
+// generate new key pair, whose private key is stored encrypted
+func (ctx *sshKeyContext) generate(c *gin.Context) {
+	var payload sshKeyPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	k, err := git.GenerateSshKey(payload.Name, payload.Type)
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+	k.Description = payload.Description
+
+	if err := delegate.NewModelDelegate(k, GetUserFromContext(c)).Add(); err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccessWithData(c, k)
+}
+
+// put update name and description of ssh key, whose key pair is immutable
+func (ctx *sshKeyContext) put(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	var payload sshKeyPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	if _, err := ctx.modelSvc.GetSshKeyById(id); err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	if err := ctx.modelSvc.GetBaseService(interfaces.ModelIdSshKey).UpdateById(id, bson.M{
+		"$set": bson.M{
+			"name":        payload.Name,
+			"description": payload.Description,
+		},
+	}); err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccess(c)
+}
+
+// delete ssh key, which is not allowed if attached to any git settings
+func (ctx *sshKeyContext) delete(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	k, err := ctx.modelSvc.GetSshKeyById(id)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			HandleErrorNotFound(c, err)
+			return
+		}
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// in use
+	count, err := ctx.modelSvc.GetBaseService(interfaces.ModelIdGit).Count(bson.M{"ssh_key_id": id})
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+	if count > 0 {
+		HandleErrorBadRequest(c, errors.ErrorGitSshKeyInUse)
+		return
+	}
+
+	if err := delegate.NewModelDelegate(k, GetUserFromContext(c)).Delete(); err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	HandleSuccess(c)
+}
+
+func newSshKeyContext() *sshKeyContext {
+	// context
+	ctx := &sshKeyContext{}
+
+	// dependency injection
+	c := dig.New()
+	if err := c.Provide(service.NewService); err != nil {
+		panic(err)
+	}
+	if err := c.Invoke(func(
+		modelSvc service.ModelService,
+	) {
+		ctx.modelSvc = modelSvc
+	}); err != nil {
+		panic(err)
+	}
+
+	return ctx
+}
@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"github.com/crawlab-team/go-trace"
 	"github.com/doubletrey/crawlab-core/entity"
+	"github.com/doubletrey/crawlab-core/node/leader"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
 	"net/http"
 )
 
@@ -12,6 +15,18 @@ func getSystemInfo(c *gin.Context) {
 		Edition: viper.GetString("info.edition"),
 		Version: viper.GetString("info.version"),
 	}
+
+	// leader among masters
+	leaderSvc, err := leader.GetNodeLeaderService(viper.GetString("config.path"))
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+	info.Leader, err = leaderSvc.GetLeader()
+	if err != nil && err != mongo2.ErrNoDocuments {
+		trace.PrintError(err)
+	}
+
 	HandleSuccessWithData(c, info)
 }
 
@@ -3,6 +3,7 @@ package controllers
 import (
 	clog "github.com/crawlab-team/crawlab-log"
 	"github.com/doubletrey/crawlab-core/config"
+	"github.com/doubletrey/crawlab-core/constants"
 	"github.com/doubletrey/crawlab-core/errors"
 	"github.com/doubletrey/crawlab-core/interfaces"
 	"github.com/doubletrey/crawlab-core/models/models"
@@ -62,6 +63,10 @@ type taskController struct {
 	ctx *taskContext
 }
 
+func (ctr *taskController) Get(c *gin.Context) {
+	ctr.ctx.getWithStatAndMetric(c)
+}
+
 func (ctr *taskController) GetList(c *gin.Context) {
 	withStats := c.Query("stats")
 	if withStats == "" {
@@ -109,6 +114,7 @@ func (ctx *taskContext) run(c *gin.Context) {
 		NodeIds:  t.NodeIds,
 		Param:    t.Param,
 		Priority: t.Priority,
+		Ref:      t.Ref,
 	}
 
 	// user
@@ -148,6 +154,11 @@ func (ctx *taskContext) restart(c *gin.Context) {
 		Priority: t.Priority,
 	}
 
+	// re-run at the same commit if pinned
+	if t.Ref != "" {
+		opts.Ref = t.Commit
+	}
+
 	// user
 	if u := GetUserFromContext(c); u != nil {
 		opts.UserId = u.GetId()
@@ -292,6 +303,13 @@ func (ctx *taskContext) getListWithStats(c *gin.Context) {
 		dict[s.GetId()] = s
 	}
 
+	// queue positions of pending tasks
+	queue, err := ctx._getQueueInfo(list.Values())
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
 	// iterate list again
 	var data []interface{}
 	for _, d := range list.Values() {
@@ -300,6 +318,9 @@ func (ctx *taskContext) getListWithStats(c *gin.Context) {
 		if ok {
 			t.Stat = &s
 		}
+		if qi, ok := queue[t.GetId()]; ok {
+			t.Queue = &qi
+		}
 		data = append(data, *t)
 	}
 
@@ -307,6 +328,56 @@ func (ctx *taskContext) getListWithStats(c *gin.Context) {
 	HandleSuccessWithListData(c, data, total)
 }
 
+func (ctx *taskContext) getWithStatAndMetric(c *gin.Context) {
+	// id
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		HandleErrorBadRequest(c, err)
+		return
+	}
+
+	// task
+	t, err := ctx.modelSvc.GetTaskById(id)
+	if err == mongo2.ErrNoDocuments {
+		HandleErrorNotFound(c, err)
+		return
+	}
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// stat
+	ts, err := ctx.modelSvc.GetTaskStatById(id)
+	if err == nil {
+		t.Stat = ts
+	} else if err != mongo2.ErrNoDocuments {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// metric
+	tm, err := ctx.modelSvc.GetTaskMetricById(id)
+	if err == nil {
+		t.Metric = tm
+	} else if err != mongo2.ErrNoDocuments {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+
+	// queue position
+	queue, err := ctx._getQueueInfo([]interface{}{t})
+	if err != nil {
+		HandleErrorInternalServerError(c, err)
+		return
+	}
+	if qi, ok := queue[t.GetId()]; ok {
+		t.Queue = &qi
+	}
+
+	HandleSuccessWithData(c, t)
+}
+
 func (ctx *taskContext) getData(c *gin.Context) {
 	// id
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
@@ -373,6 +444,18 @@ func (ctx *taskContext) getData(c *gin.Context) {
 	HandleSuccessWithListData(c, data, total)
 }
 
+// _getQueueInfo queue positions and estimated waiting time of tasks, which
+// are only fetched if any of the tasks is pending
+func (ctx *taskContext) _getQueueInfo(tasks []interface{}) (res map[primitive.ObjectID]interfaces.TaskQueueInfo, err error) {
+	for _, d := range tasks {
+		t, ok := d.(*models.Task)
+		if ok && t.Status == constants.TaskStatusPending {
+			return ctx.schedulerSvc.GetQueueInfo()
+		}
+	}
+	return nil, nil
+}
+
 func (ctx *taskContext) _getLogDriver(id primitive.ObjectID) (l clog.Driver, err error) {
 	// attempt to get from cache
 	res, ok := ctx.drivers.Load(id)
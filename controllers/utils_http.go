@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"fmt"
 	"github.com/crawlab-team/go-trace"
 	"github.com/doubletrey/crawlab-core/constants"
 	"github.com/doubletrey/crawlab-core/entity"
@@ -70,3 +71,9 @@ func HandleSuccessWithListData(c *gin.Context, data interface{}, total int) {
 		Total:   total,
 	})
 }
+
+func HandleSuccessWithFile(c *gin.Context, fileName string, contentType string, data []byte) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	c.Data(http.StatusOK, contentType, data)
+	c.Abort()
+}
@@ -1,6 +1,9 @@
 package entity
 
+import "github.com/doubletrey/crawlab-core/interfaces"
+
 type SystemInfo struct {
-	Edition string `json:"edition"` // edition. e.g. community / pro
-	Version string `json:"version"` // version. e.g. v0.6.0
+	Edition string                      `json:"edition"`          // edition. e.g. community / pro
+	Version string                      `json:"version"`          // version. e.g. v0.6.0
+	Leader  *interfaces.NodeLeaderLease `json:"leader,omitempty"` // lease of the leader among masters
 }
@@ -8,3 +8,6 @@ var ErrorFsForbidden = NewFsError("forbidden")
 var ErrorFsEmptyWorkspacePath = NewFsError("empty workspace path")
 var ErrorFsInvalidType = NewFsError("invalid type")
 var ErrorFsAlreadyExists = NewFsError("already exists")
+var ErrorFsInvalidArchive = NewFsError("invalid archive")
+var ErrorFsInvalidArchivePath = NewFsError("invalid archive path")
+var ErrorFsInvalidUploadMode = NewFsError("invalid upload mode")
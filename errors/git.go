@@ -5,6 +5,12 @@ func NewGitError(msg string) (err error) {
 }
 
 var (
-	ErrorGitInvalidAuthType = NewGitError("invalid auth type")
-	ErrorGitNoMainBranch    = NewGitError("no main branch")
+	ErrorGitInvalidAuthType  = NewGitError("invalid auth type")
+	ErrorGitNoMainBranch     = NewGitError("no main branch")
+	ErrorGitUnknownWebhook   = NewGitError("unknown webhook provider")
+	ErrorGitInvalidSignature = NewGitError("invalid webhook signature")
+	ErrorGitInvalidSshKey    = NewGitError("invalid ssh key")
+	ErrorGitSshKeyInUse      = NewGitError("ssh key in use")
+	ErrorGitUnknownHostKey   = NewGitError("unknown host key")
+	ErrorGitDirtyWorktree    = NewGitError("uncommitted changes in worktree")
 )
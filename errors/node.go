@@ -12,3 +12,5 @@ var ErrorNodeInvalidCode = NewNodeError("invalid code")
 var ErrorNodeInvalidNodeKey = NewNodeError("invalid node key")
 var ErrorNodeMonitorError = NewNodeError("monitor error")
 var ErrorNodeNotExists = NewNodeError("not exists")
+var ErrorNodeNotLeader = NewNodeError("not leader")
+var ErrorNodeStaleLeaderToken = NewNodeError("stale leader token")
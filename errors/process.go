@@ -7,4 +7,5 @@ func NewProcessError(msg string) (err error) {
 var (
 	ErrorProcessReachedMaxErrors    = NewProcessError("reached max errors")
 	ErrorProcessDaemonProcessExited = NewProcessError("daemon process exited")
+	ErrorProcessCgroupNotSupported  = NewProcessError("cgroup v2 not supported")
 )
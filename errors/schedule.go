@@ -5,3 +5,16 @@ func NewScheduleError(msg string) (err error) {
 }
 
 //var ErrorSchedule = NewScheduleError("unregistered")
+
+var (
+	ErrorScheduleInvalidOverlapPolicy  = NewScheduleError("invalid overlap policy")
+	ErrorScheduleInvalidCron           = NewScheduleError("invalid cron")
+	ErrorScheduleInvalidTimezone       = NewScheduleError("invalid timezone")
+	ErrorScheduleInvalidMisfirePolicy  = NewScheduleError("invalid misfire policy")
+	ErrorScheduleInvalidType           = NewScheduleError("invalid type")
+	ErrorScheduleInvalidRunAt          = NewScheduleError("invalid run at")
+	ErrorScheduleInvalidBlackout       = NewScheduleError("invalid blackout window")
+	ErrorScheduleInvalidBlackoutPolicy = NewScheduleError("invalid blackout policy")
+	ErrorScheduleInvalidCalendarDate   = NewScheduleError("invalid calendar date")
+	ErrorScheduleInvalidTemplate       = NewScheduleError("invalid template")
+)
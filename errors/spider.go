@@ -7,4 +7,9 @@ func NewSpiderError(msg string) (err error) {
 var (
 	ErrorSpiderMissingRequiredOption = NewSpiderError("missing required option")
 	ErrorSpiderForbidden             = NewSpiderError("forbidden")
+	ErrorSpiderMissingGit            = NewSpiderError("missing git")
+	ErrorSpiderInvalidGitSyncFreq    = NewSpiderError("invalid git sync frequency")
+	ErrorSpiderInvalidGitRef         = NewSpiderError("invalid git ref")
+	ErrorSpiderInvalidManifest       = NewSpiderError("invalid manifest")
+	ErrorSpiderInvalidImportConflict = NewSpiderError("invalid import conflict")
 )
@@ -15,4 +15,5 @@ var (
 	ErrorTaskEmptySpiderId      = NewTaskError("empty spider id")
 	ErrorTaskNoNodeId           = NewTaskError("no node id")
 	ErrorTaskNodeNotFound       = NewTaskError("node not found")
+	ErrorTaskMissingCommit      = NewTaskError("missing commit")
 )
@@ -0,0 +1,129 @@
+package fs
+
+import (
+	"encoding/json"
+	"github.com/crawlab-team/go-trace"
+	"github.com/crawlab-team/goseaweedfs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestDirName directory under parent of workspace to keep manifests, which
+// are shared by all tasks running in the same workspace on a node
+const manifestDirName = ".manifests"
+
+// workspaceLocks locks of workspaces to avoid concurrent syncs
+var workspaceLocks = sync.Map{}
+
+// ManifestFile info of a file synced from fs to workspace
+type ManifestFile struct {
+	Md5   string    `json:"md5"`
+	Size  int64     `json:"size"`
+	Mtime time.Time `json:"mtime"`
+}
+
+// Manifest files synced from fs to workspace keyed by path relative to the
+// fs path, which are compared with files in fs to sync incrementally
+type Manifest map[string]ManifestFile
+
+// Diff relative paths of files to download, i.e. new files in fs or files
+// with different md5 (size and mtime if md5 is missing), and of files to
+// delete which have been synced but are removed from fs
+func (m Manifest) Diff(files Manifest) (downloads, deletes []string) {
+	for p, f := range files {
+		cached, ok := m[p]
+		if !ok || cached.changed(f) {
+			downloads = append(downloads, p)
+		}
+	}
+	for p := range m {
+		if _, ok := files[p]; !ok {
+			deletes = append(deletes, p)
+		}
+	}
+	return downloads, deletes
+}
+
+func (f ManifestFile) changed(other ManifestFile) (ok bool) {
+	if f.Md5 != "" && other.Md5 != "" {
+		return f.Md5 != other.Md5
+	}
+	return f.Size != other.Size || !f.Mtime.Equal(other.Mtime)
+}
+
+// newManifestFromFsFiles manifest of files in fs listed recursively under
+// the fs path, returning relative paths of directories as well
+func newManifestFromFsFiles(fsPath string, files []goseaweedfs.FilerFileInfo) (m Manifest, dirs []string) {
+	m = Manifest{}
+	var walk func(files []goseaweedfs.FilerFileInfo)
+	walk = func(files []goseaweedfs.FilerFileInfo) {
+		for _, f := range files {
+			p := strings.TrimPrefix(strings.TrimPrefix(f.FullPath, fsPath), "/")
+			if p == "" {
+				continue
+			}
+			if f.IsDir {
+				dirs = append(dirs, p)
+				walk(f.Children)
+				continue
+			}
+			m[p] = ManifestFile{
+				Md5:   f.Md5,
+				Size:  f.FileSize,
+				Mtime: f.Mtime,
+			}
+		}
+	}
+	walk(files)
+	return m, dirs
+}
+
+// getManifestPath path of the manifest of the workspace
+func getManifestPath(workspacePath string) (manifestPath string) {
+	workspacePath = filepath.Clean(workspacePath)
+	return filepath.Join(filepath.Dir(workspacePath), manifestDirName, filepath.Base(workspacePath)+".json")
+}
+
+// loadManifest manifest of the workspace, which is empty if not exists or
+// corrupted so that all files are to be synced
+func loadManifest(workspacePath string) (m Manifest) {
+	m = Manifest{}
+	data, err := ioutil.ReadFile(getManifestPath(workspacePath))
+	if err != nil {
+		return m
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}
+	}
+	return m
+}
+
+// saveManifest save manifest of the workspace by replacing it with a temporary
+// file so that it is never partially written
+func saveManifest(workspacePath string, m Manifest) (err error) {
+	manifestPath := getManifestPath(workspacePath)
+	if err := os.MkdirAll(filepath.Dir(manifestPath), os.ModePerm); err != nil {
+		return trace.TraceError(err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return trace.TraceError(err)
+	}
+	tmpPath := manifestPath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, os.FileMode(0644)); err != nil {
+		return trace.TraceError(err)
+	}
+	if err := os.Rename(tmpPath, manifestPath); err != nil {
+		return trace.TraceError(err)
+	}
+	return nil
+}
+
+func getWorkspaceLock(workspacePath string) (mu *sync.Mutex) {
+	res, _ := workspaceLocks.LoadOrStore(filepath.Clean(workspacePath), &sync.Mutex{})
+	return res.(*sync.Mutex)
+}
@@ -0,0 +1,81 @@
+package fs
+
+import (
+	"github.com/crawlab-team/goseaweedfs"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestManifest_Diff(t *testing.T) {
+	ts := time.Now()
+	m := Manifest{
+		"main.py":      {Md5: "a", Size: 1},
+		"data/a.csv":   {Md5: "b", Size: 2},
+		"data/old.csv": {Md5: "c", Size: 3},
+		"no_md5.txt":   {Size: 4, Mtime: ts},
+		"no_md5_2.txt": {Size: 5, Mtime: ts},
+	}
+	files := Manifest{
+		"main.py":      {Md5: "a", Size: 1},
+		"data/a.csv":   {Md5: "b2", Size: 2},
+		"data/new.csv": {Md5: "d", Size: 6},
+		"no_md5.txt":   {Size: 4, Mtime: ts},
+		"no_md5_2.txt": {Size: 5, Mtime: ts.Add(time.Second)},
+	}
+	downloads, deletes := m.Diff(files)
+	sort.Strings(downloads)
+	require.Equal(t, []string{"data/a.csv", "data/new.csv", "no_md5_2.txt"}, downloads)
+	require.Equal(t, []string{"data/old.csv"}, deletes)
+
+	// all files are to be downloaded if manifest is empty
+	downloads, deletes = Manifest{}.Diff(files)
+	require.Len(t, downloads, len(files))
+	require.Empty(t, deletes)
+}
+
+func TestNewManifestFromFsFiles(t *testing.T) {
+	files := []goseaweedfs.FilerFileInfo{
+		{FullPath: "/fs/spider/main.py", Md5: "a", FileSize: 1},
+		{
+			FullPath: "/fs/spider/data",
+			IsDir:    true,
+			Children: []goseaweedfs.FilerFileInfo{
+				{FullPath: "/fs/spider/data/a.csv", Md5: "b", FileSize: 2},
+				{FullPath: "/fs/spider/data/empty", IsDir: true},
+			},
+		},
+	}
+	m, dirs := newManifestFromFsFiles("/fs/spider", files)
+	require.Equal(t, Manifest{
+		"main.py":    {Md5: "a", Size: 1},
+		"data/a.csv": {Md5: "b", Size: 2},
+	}, m)
+	require.Equal(t, []string{"data", "data/empty"}, dirs)
+}
+
+func TestSaveManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crawlab-manifest-")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	workspacePath := filepath.Join(dir, "spider")
+
+	// empty if not exists
+	require.Empty(t, loadManifest(workspacePath))
+
+	// saved outside of workspace
+	m := Manifest{"main.py": {Md5: "a", Size: 1}}
+	err = saveManifest(workspacePath, m)
+	require.Nil(t, err)
+	require.Equal(t, filepath.Join(dir, manifestDirName, "spider.json"), getManifestPath(workspacePath))
+	require.Equal(t, m, loadManifest(workspacePath))
+
+	// empty if corrupted
+	err = ioutil.WriteFile(getManifestPath(workspacePath), []byte("{"), os.ModePerm)
+	require.Nil(t, err)
+	require.Empty(t, loadManifest(workspacePath))
+}
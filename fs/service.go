@@ -15,6 +15,7 @@ import (
 	"github.com/ztrue/tracerr"
 	"go.uber.org/dig"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 )
@@ -281,10 +282,10 @@ func (svc *Service) Commit(msg string) (err error) {
 		return trace.TraceError(errors.ErrorFsForbidden)
 	}
 
-	// TODO: check whether need to sync remote fs to local (based on md5?)
-	//if err := svc.fs.SyncRemoteToLocal(svc.fsPath, c.GetPath()); err != nil {
-	//	return err
-	//}
+	// sync changed files from fs to workspace
+	if err := svc.SyncToWorkspace(); err != nil {
+		return err
+	}
 
 	// commit
 	if err := svc.gitClient.CommitAll(msg); err != nil {
@@ -327,13 +328,21 @@ func (svc *Service) SyncToFs(opts ...interfaces.ServiceCrudOption) (err error) {
 	return nil
 }
 
-// SyncToWorkspace sync from fs to workspace
+// SyncToWorkspace sync from fs to workspace incrementally, where only files
+// changed since last sync according to the workspace manifest are downloaded,
+// and files removed from fs are deleted. Files created in workspace but never
+// synced from fs are left as they are
 func (svc *Service) SyncToWorkspace() (err error) {
 	// validate workspace path
 	if svc.workspacePath == "" {
 		return trace.TraceError(errors.ErrorFsEmptyWorkspacePath)
 	}
 
+	// lock to avoid concurrent syncs of the same workspace
+	mu := getWorkspaceLock(svc.workspacePath)
+	mu.Lock()
+	defer mu.Unlock()
+
 	// create workspace directory if not exists
 	if _, err := os.Stat(svc.workspacePath); err != nil {
 		if err := os.MkdirAll(svc.workspacePath, os.ModePerm); err != nil {
@@ -341,12 +350,60 @@ func (svc *Service) SyncToWorkspace() (err error) {
 		}
 	}
 
-	// sync to local workspace from remote fs
-	if err := svc.fs.SyncRemoteToLocal(svc.fsPath, svc.workspacePath); err != nil {
+	// files in fs
+	remoteFiles, err := svc.fs.ListDir(svc.fsPath, true)
+	if err != nil {
+		if !strings.Contains(err.Error(), cfs.FilerResponseNotFoundErrorMessage) {
+			return err
+		}
+		remoteFiles = nil
+	}
+	files, dirs := newManifestFromFsFiles(svc.fsPath, remoteFiles)
+
+	// files to download or delete
+	m := loadManifest(svc.workspacePath)
+	downloads, deletes := m.Diff(files)
+
+	// files missing or modified in workspace are downloaded again
+	for p, f := range files {
+		if cached, ok := m[p]; !ok || cached.changed(f) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(svc.workspacePath, filepath.FromSlash(p)))
+		if err != nil || info.Size() != f.Size {
+			downloads = append(downloads, p)
+		}
+	}
+
+	// directories
+	for _, p := range dirs {
+		if err := os.MkdirAll(filepath.Join(svc.workspacePath, filepath.FromSlash(p)), os.ModePerm); err != nil {
+			return trace.TraceError(err)
+		}
+	}
+
+	// delete files removed from fs
+	for _, p := range deletes {
+		if err := os.Remove(filepath.Join(svc.workspacePath, filepath.FromSlash(p))); err != nil && !os.IsNotExist(err) {
+			return trace.TraceError(err)
+		}
+		delete(m, p)
+	}
+
+	// download changed files, where manifest is saved even if failed so that
+	// downloaded files are not to be downloaded again
+	for _, p := range downloads {
+		if err = svc.fs.DownloadFile(fmt.Sprintf("%s/%s", svc.fsPath, p), filepath.Join(svc.workspacePath, filepath.FromSlash(p))); err != nil {
+			err = trace.TraceError(err)
+			break
+		}
+		m[p] = files[p]
+	}
+	if err := saveManifest(svc.workspacePath, m); err != nil {
 		return err
 	}
 
-	return nil
+	return err
 }
 
 func (svc *Service) GetFsPath() (path string) {
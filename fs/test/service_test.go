@@ -8,6 +8,7 @@ import (
 	"github.com/doubletrey/crawlab-core/interfaces"
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
+	"os"
 	"path"
 	"testing"
 )
@@ -124,6 +125,46 @@ func TestService_SyncToWorkspace(t *testing.T) {
 	require.Equal(t, content, string(data))
 }
 
+func TestService_SyncToWorkspaceIncremental(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// save files to remote and sync to workspace
+	err = T.masterFsSvc.Save("test_file.txt", []byte("v1"))
+	require.Nil(t, err)
+	err = T.masterFsSvc.Save("data/test_data.csv", []byte("a,b"))
+	require.Nil(t, err)
+	err = T.workerFsSvc.SyncToWorkspace()
+	require.Nil(t, err)
+	require.FileExists(t, "./tmp/test_worker_workspace/data/test_data.csv")
+
+	// file created in workspace
+	err = ioutil.WriteFile("./tmp/test_worker_workspace/output.txt", []byte("output"), os.ModePerm)
+	require.Nil(t, err)
+
+	// update and delete files in remote
+	err = T.masterFsSvc.Save("test_file.txt", []byte("v2"))
+	require.Nil(t, err)
+	err = T.masterFsSvc.Delete("data/test_data.csv")
+	require.Nil(t, err)
+	err = T.workerFsSvc.SyncToWorkspace()
+	require.Nil(t, err)
+
+	// validate
+	data, err := ioutil.ReadFile("./tmp/test_worker_workspace/test_file.txt")
+	require.Nil(t, err)
+	require.Equal(t, "v2", string(data))
+	require.NoFileExists(t, "./tmp/test_worker_workspace/data/test_data.csv")
+	require.FileExists(t, "./tmp/test_worker_workspace/output.txt")
+
+	// file removed from workspace is synced again
+	err = os.Remove("./tmp/test_worker_workspace/test_file.txt")
+	require.Nil(t, err)
+	err = T.workerFsSvc.SyncToWorkspace()
+	require.Nil(t, err)
+	require.FileExists(t, "./tmp/test_worker_workspace/test_file.txt")
+}
+
 func TestService_WorkerFsService(t *testing.T) {
 	var err error
 	T.Setup(t)
@@ -4,6 +4,7 @@ import (
 	"context"
 	grpc "github.com/crawlab-team/crawlab-grpc"
 	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
 	"github.com/doubletrey/crawlab-core/entity"
 	"github.com/doubletrey/crawlab-core/interfaces"
 	"github.com/doubletrey/crawlab-core/models/service"
@@ -46,21 +47,21 @@ func (svr ModelBaseServiceServer) GetList(ctx context.Context, req *grpc.Request
 
 func (svr ModelBaseServiceServer) DeleteById(ctx context.Context, req *grpc.Request) (res *grpc.Response, err error) {
 	return svr.handleRequest(req, func(params *entity.GrpcBaseServiceParams, svc interfaces.ModelBaseService) (interface{}, error) {
-		err := svc.DeleteById(params.Id, params.User)
+		err := svc.DeleteById(params.Id, params.User, constants.AuditSourceGrpc)
 		return nil, err
 	})
 }
 
 func (svr ModelBaseServiceServer) Delete(ctx context.Context, req *grpc.Request) (res *grpc.Response, err error) {
 	return svr.handleRequest(req, func(params *entity.GrpcBaseServiceParams, svc interfaces.ModelBaseService) (interface{}, error) {
-		err := svc.Delete(utils.NormalizeBsonMObjectId(params.Query), params.User)
+		err := svc.Delete(utils.NormalizeBsonMObjectId(params.Query), params.User, constants.AuditSourceGrpc)
 		return nil, err
 	})
 }
 
 func (svr ModelBaseServiceServer) DeleteList(ctx context.Context, req *grpc.Request) (res *grpc.Response, err error) {
 	return svr.handleRequest(req, func(params *entity.GrpcBaseServiceParams, svc interfaces.ModelBaseService) (interface{}, error) {
-		err := svc.DeleteList(utils.NormalizeBsonMObjectId(params.Query), params.User)
+		err := svc.DeleteList(utils.NormalizeBsonMObjectId(params.Query), params.User, constants.AuditSourceGrpc)
 		return nil, err
 	})
 }
@@ -74,21 +75,21 @@ func (svr ModelBaseServiceServer) ForceDeleteList(ctx context.Context, req *grpc
 
 func (svr ModelBaseServiceServer) UpdateById(ctx context.Context, req *grpc.Request) (res *grpc.Response, err error) {
 	return svr.handleRequest(req, func(params *entity.GrpcBaseServiceParams, svc interfaces.ModelBaseService) (interface{}, error) {
-		err := svc.UpdateById(params.Id, params.Update)
+		err := svc.UpdateById(params.Id, params.Update, params.User, constants.AuditSourceGrpc)
 		return nil, err
 	})
 }
 
 func (svr ModelBaseServiceServer) Update(ctx context.Context, req *grpc.Request) (res *grpc.Response, err error) {
 	return svr.handleRequest(req, func(params *entity.GrpcBaseServiceParams, svc interfaces.ModelBaseService) (interface{}, error) {
-		err := svc.Update(utils.NormalizeBsonMObjectId(params.Query), params.Update, params.Fields, params.User)
+		err := svc.Update(utils.NormalizeBsonMObjectId(params.Query), params.Update, params.Fields, params.User, constants.AuditSourceGrpc)
 		return nil, err
 	})
 }
 
 func (svr ModelBaseServiceServer) UpdateDoc(ctx context.Context, req *grpc.Request) (res *grpc.Response, err error) {
 	return svr.handleRequest(req, func(params *entity.GrpcBaseServiceParams, svc interfaces.ModelBaseService) (interface{}, error) {
-		err := svc.UpdateDoc(utils.NormalizeBsonMObjectId(params.Query), params.Doc, params.Fields, params.User)
+		err := svc.UpdateDoc(utils.NormalizeBsonMObjectId(params.Query), params.Doc, params.Fields, params.User, constants.AuditSourceGrpc)
 		return nil, err
 	})
 }
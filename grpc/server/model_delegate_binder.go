@@ -75,6 +75,20 @@ func (b *ModelDelegateBinder) Bind() (res interface{}, err error) {
 		return b.process(&m.PluginStatus)
 	case interfaces.ModelIdGit:
 		return b.process(&m.Git)
+	case interfaces.ModelIdTaskMetric:
+		return b.process(&m.TaskMetric)
+	case interfaces.ModelIdDependencyEnv:
+		return b.process(&m.DependencyEnv)
+	case interfaces.ModelIdAuditLog:
+		return b.process(&m.AuditLog)
+	case interfaces.ModelIdGitWebhookDelivery:
+		return b.process(&m.GitWebhookDelivery)
+	case interfaces.ModelIdSshKey:
+		return b.process(&m.SshKey)
+	case interfaces.ModelIdScheduleSkip:
+		return b.process(&m.ScheduleSkip)
+	case interfaces.ModelIdCalendar:
+		return b.process(&m.Calendar)
 	default:
 		return nil, errors.ErrorModelInvalidModelId
 	}
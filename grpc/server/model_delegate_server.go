@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	grpc "github.com/crawlab-team/crawlab-grpc"
+	"github.com/doubletrey/crawlab-core/constants"
 	"github.com/doubletrey/crawlab-core/errors"
 	"github.com/doubletrey/crawlab-core/interfaces"
 	"github.com/doubletrey/crawlab-core/models/delegate"
@@ -27,7 +28,7 @@ func (svr ModelDelegateServer) Do(ctx context.Context, req *grpc.Request) (res *
 	}
 
 	// model delegate
-	d := delegate.NewModelDelegate(doc)
+	d := delegate.NewModelDelegate(doc, constants.AuditSourceGrpc)
 
 	// apply method
 	switch msg.GetMethod() {
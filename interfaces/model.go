@@ -35,31 +35,45 @@ const (
 	ModelIdExtraValue
 	ModelIdPluginStatus
 	ModelIdGit
+	ModelIdTaskMetric
+	ModelIdDependencyEnv
+	ModelIdAuditLog
+	ModelIdGitWebhookDelivery
+	ModelIdSshKey
+	ModelIdScheduleSkip
+	ModelIdCalendar
 )
 
 const (
-	ModelColNameArtifact       = "artifacts"
-	ModelColNameTag            = "tags"
-	ModelColNameNode           = "nodes"
-	ModelColNameProject        = "projects"
-	ModelColNameSpider         = "spiders"
-	ModelColNameTask           = "tasks"
-	ModelColNameJob            = "jobs"
-	ModelColNameSchedule       = "schedules"
-	ModelColNameUser           = "users"
-	ModelColNameSetting        = "settings"
-	ModelColNameToken          = "tokens"
-	ModelColNameVariable       = "variables"
-	ModelColNameTaskQueue      = "task_queue"
-	ModelColNameTaskStat       = "task_stats"
-	ModelColNamePlugin         = "plugins"
-	ModelColNameSpiderStat     = "spider_stats"
-	ModelColNameDataSource     = "data_sources"
-	ModelColNameDataCollection = "data_collections"
-	ModelColNamePasswords      = "passwords"
-	ModelColNameExtraValues    = "extra_values"
-	ModelColNamePluginStatus   = "plugin_status"
-	ModelColNameGit            = "gits"
+	ModelColNameArtifact           = "artifacts"
+	ModelColNameTag                = "tags"
+	ModelColNameNode               = "nodes"
+	ModelColNameProject            = "projects"
+	ModelColNameSpider             = "spiders"
+	ModelColNameTask               = "tasks"
+	ModelColNameJob                = "jobs"
+	ModelColNameSchedule           = "schedules"
+	ModelColNameUser               = "users"
+	ModelColNameSetting            = "settings"
+	ModelColNameToken              = "tokens"
+	ModelColNameVariable           = "variables"
+	ModelColNameTaskQueue          = "task_queue"
+	ModelColNameTaskStat           = "task_stats"
+	ModelColNamePlugin             = "plugins"
+	ModelColNameSpiderStat         = "spider_stats"
+	ModelColNameDataSource         = "data_sources"
+	ModelColNameDataCollection     = "data_collections"
+	ModelColNamePasswords          = "passwords"
+	ModelColNameExtraValues        = "extra_values"
+	ModelColNamePluginStatus       = "plugin_status"
+	ModelColNameGit                = "gits"
+	ModelColNameTaskMetric         = "task_metrics"
+	ModelColNameDependencyEnv      = "dependency_envs"
+	ModelColNameAuditLog           = "audit_logs"
+	ModelColNameGitWebhookDelivery = "git_webhook_deliveries"
+	ModelColNameSshKey             = "ssh_keys"
+	ModelColNameScheduleSkip       = "schedule_skips"
+	ModelColNameCalendar           = "calendars"
 )
 
 type ModelWithTags interface {
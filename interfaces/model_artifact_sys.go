@@ -18,4 +18,6 @@ type ModelArtifactSys interface {
 	SetUpdateUid(id primitive.ObjectID)
 	GetDeleteUid() primitive.ObjectID
 	SetDeleteUid(id primitive.ObjectID)
+	GetRecycleId() primitive.ObjectID
+	SetRecycleId(id primitive.ObjectID)
 }
@@ -18,6 +18,8 @@ type Node interface {
 	SetAvailableRunners(runners int)
 	GetMaxRunners() (runners int)
 	SetMaxRunners(runners int)
+	GetRunners() (runners int)
+	SetRunners(runners int)
 	IncrementAvailableRunners()
 	DecrementAvailableRunners()
 }
@@ -3,6 +3,7 @@ package interfaces
 import (
 	"github.com/robfig/cron/v3"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
 )
 
 type Schedule interface {
@@ -11,8 +12,13 @@ type Schedule interface {
 	SetEnabled(enabled bool)
 	GetEntryId() (id cron.EntryID)
 	SetEntryId(id cron.EntryID)
+	GetType() (t string)
+	GetRunAt() (ts time.Time)
 	GetCron() (c string)
 	SetCron(c string)
+	GetTimezone() (tz string)
+	GetLastFireTs() (ts time.Time)
+	SetLastFireTs(ts time.Time)
 	GetSpiderId() (id primitive.ObjectID)
 	SetSpiderId(id primitive.ObjectID)
 	GetMode() (mode string)
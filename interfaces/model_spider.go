@@ -20,4 +20,8 @@ type Spider interface {
 	SetPriority(p int)
 	GetColId() (id primitive.ObjectID)
 	SetColId(id primitive.ObjectID)
+	GetImage() (image string)
+	GetCpuLimit() (n float64)
+	GetMemoryLimit() (n int64)
+	GetPidsLimit() (n int64)
 }
@@ -14,11 +14,15 @@ type Task interface {
 	SetError(error string)
 	GetPid() (pid int)
 	SetPid(pid int)
+	GetLeakedPids() (pids []int)
+	SetLeakedPids(pids []int)
 	GetSpiderId() (id primitive.ObjectID)
 	GetType() (ty string)
 	GetCmd() (cmd string)
 	GetParam() (param string)
 	GetPriority() (p int)
+	GetRef() (ref string)
+	GetCommit() (commit string)
 	GetUserId() (id primitive.ObjectID)
 	SetUserId(id primitive.ObjectID)
 }
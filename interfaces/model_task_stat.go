@@ -12,6 +12,8 @@ type TaskStat interface {
 	SetEndTs(ts time.Time)
 	GetWaitDuration() (d int64)
 	SetWaitDuration(d int64)
+	GetSyncDuration() (d int64)
+	SetSyncDuration(d int64)
 	GetRuntimeDuration() (d int64)
 	SetRuntimeDuration(d int64)
 	GetTotalDuration() (d int64)
@@ -20,4 +22,14 @@ type TaskStat interface {
 	SetResultCount(c int64)
 	GetErrorLogCount() (c int64)
 	SetErrorLogCount(c int64)
+	GetCpuTime() (t int64)
+	SetCpuTime(t int64)
+	GetPeakRss() (rss int64)
+	SetPeakRss(rss int64)
+	GetReadBytes() (b int64)
+	SetReadBytes(b int64)
+	GetWriteBytes() (b int64)
+	SetWriteBytes(b int64)
+	GetPeakThreads() (n int)
+	SetPeakThreads(n int)
 }
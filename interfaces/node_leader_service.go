@@ -0,0 +1,35 @@
+package interfaces
+
+import "time"
+
+// NodeLeaderLease lease of leadership among masters, which is held by the
+// master of the node key until expiry unless renewed
+type NodeLeaderLease struct {
+	Id       string    `json:"-" bson:"_id"`
+	NodeKey  string    `json:"node_key" bson:"node_key"`
+	Token    int64     `json:"token" bson:"token"`
+	ExpireTs time.Time `json:"expire_ts" bson:"expire_ts"`
+	RenewTs  time.Time `json:"renew_ts" bson:"renew_ts"`
+}
+
+type NodeLeaderService interface {
+	WithConfigPath
+	Module
+	GetNodeKey() (key string)
+	SetNodeKey(key string)
+	GetLeaseDuration() (duration time.Duration)
+	SetLeaseDuration(duration time.Duration)
+	GetRenewInterval() (interval time.Duration)
+	SetRenewInterval(interval time.Duration)
+	// IsLeader whether the master holds an unexpired lease
+	IsLeader() (ok bool)
+	// GetToken fencing token of the lease held by the master, which increases
+	// on every change of leadership, or 0 if it is not the leader
+	GetToken() (token int64)
+	// CheckToken check if the token is still the fencing token of the unexpired
+	// lease in db at the moment, which does not guard later writes by itself.
+	// Writes only the leader can make are to be conditional on the token
+	CheckToken(token int64) (err error)
+	// GetLeader current lease in db, which may have expired
+	GetLeader() (l *NodeLeaderLease, err error)
+}
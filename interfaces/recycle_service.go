@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"github.com/doubletrey/crawlab-db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type RecycleService interface {
+	WithConfigPath
+	Module
+	GetRetention() (retention time.Duration)
+	SetRetention(retention time.Duration)
+	GetPurgeInterval() (interval time.Duration)
+	SetPurgeInterval(interval time.Duration)
+	GetList(query bson.M, opts *mongo.FindOptions) (res []bson.M, total int, err error)
+	Restore(id primitive.ObjectID, args ...interface{}) (err error)
+	Purge(id primitive.ObjectID) (err error)
+	PurgeExpired() (err error)
+}
@@ -14,10 +14,18 @@ type ScheduleService interface {
 	SetDelay(delay bool)
 	GetSkip() (skip bool)
 	SetSkip(skip bool)
+	GetMisfirePolicy() (policy string)
+	SetMisfirePolicy(policy string)
+	// GetCatchUpWindow max time before now within which missed fires are caught up
+	GetCatchUpWindow() (window time.Duration)
+	SetCatchUpWindow(window time.Duration)
 	GetUpdateInterval() (interval time.Duration)
 	SetUpdateInterval(interval time.Duration)
 	Enable(s Schedule, args ...interface{}) (err error)
 	Disable(s Schedule, args ...interface{}) (err error)
 	Update()
 	GetCron() (c *cron.Cron)
+	// Preview next n fire times of the cron spec with the time zone, which is
+	// location of the service if empty
+	Preview(spec, timezone string, n int) (times []time.Time, err error)
 }
@@ -2,14 +2,21 @@ package interfaces
 
 import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
 )
 
 type SpiderAdminService interface {
 	WithConfigPath
+	// SetScheduleService set the schedule service by which schedules of deleted spiders are removed from cron
+	SetScheduleService(svc ScheduleService)
 	// Schedule a new task of the spider
 	Schedule(id primitive.ObjectID, opts *SpiderRunOptions) (err error)
-	// Clone the spider
-	Clone(id primitive.ObjectID, opts *SpiderCloneOptions) (err error)
-	// Delete the spider
-	Delete(id primitive.ObjectID) (err error)
+	// Clone the spider together with its files and return id of the new spider
+	Clone(id primitive.ObjectID, opts *SpiderCloneOptions, args ...interface{}) (newId primitive.ObjectID, err error)
+	// Delete the spider together with its schedules and tasks, whose files are removed once purged from recycle bin
+	Delete(id primitive.ObjectID, opts *SpiderDeleteOptions, args ...interface{}) (res *SpiderDeleteResult, err error)
+	// Export the spiders together with their files, schedules, data collections and tags as an archive
+	Export(ids []primitive.ObjectID, format string, w io.Writer) (err error)
+	// Import spiders from an archive created by Export
+	Import(data []byte, opts *SpiderImportOptions, args ...interface{}) (res *SpiderImportResult, err error)
 }
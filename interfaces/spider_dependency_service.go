@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SpiderDependencyService interface {
+	WithConfigPath
+	// SetDependencyPathBase set base path of cached dependency environments
+	SetDependencyPathBase(path string)
+	// Prepare build dependency environments of the spider from dependency files in the
+	// workspace if not cached, and return environment variables to activate them, which
+	// are held by the task until released
+	Prepare(id primitive.ObjectID, s Spider, nodeId primitive.ObjectID, workspacePath string) (envs []string, err error)
+	// Release release dependency environments held by the task, where outdated ones
+	// no longer held by any task are removed
+	Release(id primitive.ObjectID)
+}
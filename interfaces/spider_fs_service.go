@@ -12,6 +12,9 @@ type SpiderFsService interface {
 	GetFile(path string) (data []byte, err error)
 	GetFileInfo(path string) (file FsFileInfo, err error)
 	Save(path string, data []byte) (err error)
+	// SaveFiles save files keyed by path in one batch and sync them to
+	// workspace once, where files not in the batch are deleted if replace is true
+	SaveFiles(files map[string][]byte, replace bool) (err error)
 	Rename(path, newPath string) (err error)
 	Delete(path string) (err error)
 	Copy(path, newPath string) (err error)
@@ -19,6 +22,12 @@ type SpiderFsService interface {
 	GetFsPath() (res string)
 	GetWorkspacePath() (res string)
 	GetRepoPath() (res string)
+	// GetRefFsPath fs path of spider files at the given commit, or the
+	// directory of all commits if hash is empty
+	GetRefFsPath(hash string) (res string)
+	// GetRefWorkspacePath isolated workspace path of the given name, which is
+	// usually the id of the task running spider files at a commit
+	GetRefWorkspacePath(name string) (res string)
 	SetFsPathBase(path string)
 	SetWorkspacePathBase(path string)
 	SetRepoPathBase(path string)
@@ -0,0 +1,37 @@
+package interfaces
+
+import (
+	vcs "github.com/crawlab-team/crawlab-vcs"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type SpiderGitService interface {
+	WithConfigPath
+	Module
+	GetInterval() (interval time.Duration)
+	SetInterval(interval time.Duration)
+	// GetGitClient git client of the spider workspace, which is without auth
+	// and hence remote operations are to be given auth of GetGitAuth
+	GetGitClient(id primitive.ObjectID) (gitClient *vcs.GitClient, err error)
+	// GetGitAuth auth of remote operations with git settings of the spider,
+	// which verifies host keys of ssh remotes if host key checking is enabled
+	GetGitAuth(id primitive.ObjectID) (auth transport.AuthMethod, err error)
+	// GetRemoteRefs branches and tags of the git remote of the spider
+	GetRemoteRefs(id primitive.ObjectID, remoteName string) (refs []vcs.GitRef, err error)
+	// Pull the branch from the git remote of the spider, fast-forward its
+	// workspace and sync files to fs, returning hash of the pulled commit
+	Pull(id primitive.ObjectID, branch string) (hash string, err error)
+	// Sync pull the git branch of the spider and record the result
+	Sync(id primitive.ObjectID) (err error)
+	// GetHeadCommit hash of the commit at HEAD of the spider workspace
+	GetHeadCommit(id primitive.ObjectID) (hash string, err error)
+	// GetWorkspaceCommit hash of the commit at HEAD of the spider workspace,
+	// suffixed with constants.GitCommitDirtySuffix if there are uncommitted
+	// changes, or empty if the spider is not a git repo or has no commits
+	GetWorkspaceCommit(id primitive.ObjectID) (commit string, err error)
+	// Snapshot resolve the git ref (tag, branch or commit) of the spider and
+	// save its files at the resolved commit to fs, returning the commit hash
+	Snapshot(id primitive.ObjectID, ref string) (hash string, err error)
+}
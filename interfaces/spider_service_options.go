@@ -8,11 +8,40 @@ type SpiderRunOptions struct {
 	NodeTags   []string             `json:"node_tags"`
 	Cmd        string               `json:"cmd"`
 	Param      string               `json:"param"`
+	Envs       map[string]string    `json:"envs"` // environment variables of tasks
 	ScheduleId primitive.ObjectID   `json:"schedule_id"`
 	Priority   int                  `json:"priority"`
+	Ref        string               `json:"ref"` // git ref (tag, branch or commit) to run, which is latest files if empty
 	UserId     primitive.ObjectID   `json:"-"`
 }
 
 type SpiderCloneOptions struct {
-	Name string
+	Name string `json:"name"` // name of the new spider, default: "<name> (copy)"
+}
+
+type SpiderDeleteOptions struct {
+	DeleteTasks bool `json:"delete_tasks" form:"delete_tasks"` // whether to delete historical tasks with their results and logs, which are removed permanently
+	DryRun      bool `json:"dry_run" form:"dry_run"`           // whether only to report what would be removed
+}
+
+type SpiderDeleteResult struct {
+	DryRun        bool                 `json:"dry_run"`
+	Files         []string             `json:"files"` // files removed once the spider is purged from recycle bin
+	ScheduleIds   []primitive.ObjectID `json:"schedule_ids"`
+	QueuedTaskIds []primitive.ObjectID `json:"queued_task_ids"`
+	TaskIds       []primitive.ObjectID `json:"task_ids"`
+	Results       int                  `json:"results"`
+	Logs          int                  `json:"logs"`
+}
+
+type SpiderImportOptions struct {
+	Conflict         string             `json:"conflict" form:"conflict"`                   // how to handle spiders with duplicate names: rename (default), skip or overwrite
+	ProjectId        primitive.ObjectID `json:"project_id" form:"-"`                        // project to import spiders into, which is unassigned if empty
+	DisableSchedules bool               `json:"disable_schedules" form:"disable_schedules"` // whether to disable imported schedules
+}
+
+type SpiderImportResult struct {
+	Ids     []primitive.ObjectID `json:"ids"`     // ids of imported spiders
+	Renamed map[string]string    `json:"renamed"` // new names of renamed spiders keyed by original names
+	Skipped []string             `json:"skipped"` // names of skipped spiders
 }
@@ -0,0 +1,41 @@
+package interfaces
+
+import (
+	"io"
+	"time"
+)
+
+// TaskExecutor executes the command of a task in a backend (e.g. local process or container)
+type TaskExecutor interface {
+	// Start start execution, stdout and stderr are available before start
+	Start() (err error)
+	// Wait wait for execution to finish, returns *exec.ExitError if exited with non-zero code
+	Wait() (err error)
+	// Stop stop execution gracefully and force kill after timeout
+	Stop(timeout time.Duration) (err error)
+	// Dispose release resources after execution
+	Dispose() (err error)
+	// GetPid get id of the local process running the workload, which is 0 if
+	// the workload is not a local process (e.g. in a container), where resource
+	// accounting and cleanup by process are not applicable
+	GetPid() (pid int)
+	// GetStdout get stdout reader
+	GetStdout() (r io.Reader)
+	// GetStderr get stderr reader
+	GetStderr() (r io.Reader)
+	// IsOomKilled whether execution has been killed due to out of memory
+	IsOomKilled() (ok bool)
+}
+
+type TaskExecutorOptions struct {
+	Id          string   // unique id of execution, e.g. task id
+	Cmd         string   // shell command
+	Cwd         string   // working directory on local
+	Env         []string // environment variables in the form of "key=value"
+	Image       string   // container image, empty if executed as local process
+	CpuLimit    float64  // number of cpu cores
+	MemoryLimit int64    // in byte
+	PidsLimit   int64    // max number of processes
+}
+
+type TaskExecutorFactory func(opts *TaskExecutorOptions) (exec TaskExecutor, err error)
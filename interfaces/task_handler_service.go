@@ -15,12 +15,6 @@ type TaskHandlerService interface {
 	ReportStatus()
 	// Reset reset internals to default
 	Reset()
-	// IsSyncLocked whether the given spider is locked for files sync
-	IsSyncLocked(spiderId primitive.ObjectID) (ok bool)
-	// LockSync lock files sync for given spider
-	LockSync(spiderId primitive.ObjectID)
-	// UnlockSync unlock files sync for given spider
-	UnlockSync(spiderId primitive.ObjectID)
 	// GetExitWatchDuration get max runners
 	GetExitWatchDuration() (duration time.Duration)
 	// SetExitWatchDuration set max runners
@@ -12,5 +12,6 @@ type TaskRunner interface {
 	Dispose() (err error)
 	SetLogDriverType(driverType string)
 	SetSubscribeTimeout(timeout time.Duration)
+	SetExecutorFactory(f TaskExecutorFactory)
 	GetTaskId() (id primitive.ObjectID)
 }
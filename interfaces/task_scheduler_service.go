@@ -9,14 +9,39 @@ type TaskSchedulerService interface {
 	TaskBaseService
 	// Enqueue task into the task queue
 	Enqueue(t Task) (err error)
-	// DequeueAndSchedule continuously dequeue task and schedule to corresponding node
+	// Trigger dequeue and schedule immediately, e.g. when tasks are enqueued or
+	// runners are freed up
+	Trigger()
+	// DequeueAndSchedule continuously dequeue task and schedule to corresponding
+	// node whenever triggered, or at intervals as a fallback
 	DequeueAndSchedule()
-	// Dequeue task with node info from the task queue
+	// Dequeue task with node info from the task queue, which fails if the master
+	// is not the leader among masters
 	Dequeue() (tasks []Task, err error)
 	// Schedule task to corresponding node
 	Schedule(tasks []Task) (err error)
 	// Cancel task to corresponding node
 	Cancel(id primitive.ObjectID, args ...interface{}) (err error)
-	// SetInterval set the interval or duration between two adjacent fetches
+	// SetInterval set the interval of fallback polling between two adjacent
+	// fetches without triggers
 	SetInterval(interval time.Duration)
+	// SetBatchSize set the max number of tasks dequeued per round
+	SetBatchSize(size int)
+	// SetReconcileInterval set the interval of reconciling runner slots with
+	// tasks and nodes, which releases slots of ended tasks or lost nodes
+	SetReconcileInterval(interval time.Duration)
+	// SetAgingInterval set the waiting time by which effective priority of
+	// pending tasks is raised by one, which disables aging if zero
+	SetAgingInterval(interval time.Duration)
+	// SetFairShare set the mode of fair-share dequeue across projects or users
+	// with weights by project or user id, where weights default to 1
+	SetFairShare(mode string, weights map[string]float64)
+	// GetQueueInfo queue positions and estimated waiting time of pending tasks
+	GetQueueInfo() (res map[primitive.ObjectID]TaskQueueInfo, err error)
+}
+
+// TaskQueueInfo position of a pending task in the task queue
+type TaskQueueInfo struct {
+	Position      int   `json:"position"`       // 1-based position in dequeue order
+	EstimatedWait int64 `json:"estimated_wait"` // in millisecond, which is -1 if unknown
 }
@@ -62,6 +62,20 @@ func NewModelDelegate(doc interfaces.Model, opts ...ModelDelegateOption) interfa
 		return newModelDelegate(interfaces.ModelIdPluginStatus, doc, opts...)
 	case *models.Git:
 		return newModelDelegate(interfaces.ModelIdGit, doc, opts...)
+	case *models.TaskMetric:
+		return newModelDelegate(interfaces.ModelIdTaskMetric, doc, opts...)
+	case *models.DependencyEnv:
+		return newModelDelegate(interfaces.ModelIdDependencyEnv, doc, opts...)
+	case *models.AuditLog:
+		return newModelDelegate(interfaces.ModelIdAuditLog, doc, opts...)
+	case *models.GitWebhookDelivery:
+		return newModelDelegate(interfaces.ModelIdGitWebhookDelivery, doc, opts...)
+	case *models.SshKey:
+		return newModelDelegate(interfaces.ModelIdSshKey, doc, opts...)
+	case *models.ScheduleSkip:
+		return newModelDelegate(interfaces.ModelIdScheduleSkip, doc, opts...)
+	case *models.Calendar:
+		return newModelDelegate(interfaces.ModelIdCalendar, doc, opts...)
 	default:
 		_ = trace.TraceError(errors.ErrorModelInvalidType)
 		return nil
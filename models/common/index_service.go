@@ -15,6 +15,7 @@ func CreateIndexes() {
 		{Keys: bson.M{"_col": 1}},
 		{Keys: bson.M{"_del": 1}},
 		{Keys: bson.M{"_tid": 1}},
+		{Keys: bson.M{"_sys.delete_ts": 1}},
 	})
 
 	// tags
@@ -120,6 +121,39 @@ func CreateIndexes() {
 		{Keys: bson.D{{"plugin_id", 1}, {"node_id", 1}}, Options: options.Index().SetUnique(true)},
 	})
 
+	// dependency envs
+	mongo.GetMongoCol(interfaces.ModelColNameDependencyEnv).MustCreateIndexes([]mongo2.IndexModel{
+		{Keys: bson.M{"spider_id": 1}},
+		{Keys: bson.M{"node_id": 1}},
+		{
+			Keys:    bson.D{{Key: "spider_id", Value: 1}, {Key: "node_id", Value: 1}, {Key: "type", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+
+	// audit logs
+	mongo.GetMongoCol(interfaces.ModelColNameAuditLog).MustCreateIndexes([]mongo2.IndexModel{
+		{Keys: bson.D{{Key: "col", Value: 1}, {Key: "resource_id", Value: 1}, {Key: "ts", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "ts", Value: -1}}},
+		{Keys: bson.M{"ts": -1}},
+	})
+
+	// git webhook deliveries
+	mongo.GetMongoCol(interfaces.ModelColNameGitWebhookDelivery).MustCreateIndexes([]mongo2.IndexModel{
+		{Keys: bson.D{{Key: "results.spider_id", Value: 1}, {Key: "ts", Value: -1}}},
+		{Keys: bson.M{"ts": -1}},
+	})
+
+	// schedule skips
+	mongo.GetMongoCol(interfaces.ModelColNameScheduleSkip).MustCreateIndexes([]mongo2.IndexModel{
+		{Keys: bson.D{{Key: "schedule_id", Value: 1}, {Key: "ts", Value: -1}}},
+	})
+
+	// calendars
+	mongo.GetMongoCol(interfaces.ModelColNameCalendar).MustCreateIndexes([]mongo2.IndexModel{
+		{Keys: bson.M{"name": 1}},
+	})
+
 	// cache
 	mongo.GetMongoCol(constants.CacheColName).MustCreateIndexes([]mongo2.IndexModel{
 		{
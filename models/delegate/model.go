@@ -3,6 +3,8 @@ package delegate
 import (
 	"encoding/json"
 	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/audit"
+	"github.com/doubletrey/crawlab-core/constants"
 	errors2 "github.com/doubletrey/crawlab-core/errors"
 	"github.com/doubletrey/crawlab-core/event"
 	"github.com/doubletrey/crawlab-core/interfaces"
@@ -65,6 +67,20 @@ func NewModelDelegate(doc interfaces.Model, args ...interface{}) interfaces.Mode
 		return newModelDelegate(interfaces.ModelIdPluginStatus, doc, args...)
 	case *models.Git:
 		return newModelDelegate(interfaces.ModelIdGit, doc, args...)
+	case *models.TaskMetric:
+		return newModelDelegate(interfaces.ModelIdTaskMetric, doc, args...)
+	case *models.DependencyEnv:
+		return newModelDelegate(interfaces.ModelIdDependencyEnv, doc, args...)
+	case *models.AuditLog:
+		return newModelDelegate(interfaces.ModelIdAuditLog, doc, args...)
+	case *models.GitWebhookDelivery:
+		return newModelDelegate(interfaces.ModelIdGitWebhookDelivery, doc, args...)
+	case *models.SshKey:
+		return newModelDelegate(interfaces.ModelIdSshKey, doc, args...)
+	case *models.ScheduleSkip:
+		return newModelDelegate(interfaces.ModelIdScheduleSkip, doc, args...)
+	case *models.Calendar:
+		return newModelDelegate(interfaces.ModelIdCalendar, doc, args...)
 	default:
 		_ = trace.TraceError(errors2.ErrorModelInvalidType)
 		return nil
@@ -75,6 +91,9 @@ func newModelDelegate(id interfaces.ModelId, doc interfaces.Model, args ...inter
 	// user
 	u := utils.GetUserFromArgs(args...)
 
+	// source of changes
+	source := utils.GetAuditSourceFromArgs(args...)
+
 	// collection name
 	colName := models.GetModelColName(id)
 
@@ -86,7 +105,8 @@ func newModelDelegate(id interfaces.ModelId, doc interfaces.Model, args ...inter
 		a: &models.Artifact{
 			Col: colName,
 		},
-		u: u,
+		u:      u,
+		source: source,
 	}
 
 	return d
@@ -100,6 +120,7 @@ type ModelDelegate struct {
 	od      bson.M                   // original doc
 	a       interfaces.ModelArtifact // artifact
 	u       interfaces.User          // user
+	source  constants.AuditSource    // source of changes
 }
 
 // Add model
@@ -180,6 +201,9 @@ func (d *ModelDelegate) add() (err error) {
 	if _, err = col.Insert(d.doc); err != nil {
 		return trace.TraceError(err)
 	}
+	if doc, err := audit.ToBsonM(d.doc); err == nil {
+		d.audit(constants.AuditActionAdd, nil, doc)
+	}
 	if err := d.upsertArtifact(); err != nil {
 		return trace.TraceError(err)
 	}
@@ -219,6 +243,7 @@ func (d *ModelDelegate) save() (err error) {
 	if err := col.ReplaceId(d.doc.GetId(), d.doc); err != nil {
 		return trace.TraceError(err)
 	}
+	d.audit(constants.AuditActionSave, d.od, d.cd)
 
 	// upsert artifact
 	if err := d.upsertArtifact(); err != nil {
@@ -245,6 +270,9 @@ func (d *ModelDelegate) delete() (err error) {
 	if err := col.DeleteId(d.doc.GetId()); err != nil {
 		return trace.TraceError(err)
 	}
+	if doc, err := audit.ToBsonM(d.doc); err == nil {
+		d.audit(constants.AuditActionDelete, doc, nil)
+	}
 	return d.deleteArtifact()
 }
 
@@ -263,6 +291,11 @@ func (d *ModelDelegate) refresh() (err error) {
 
 // refresh artifact
 func (d *ModelDelegate) refreshArtifact() (err error) {
+	// skip
+	if d._skip() {
+		return nil
+	}
+
 	if d.doc.GetId().IsZero() {
 		return trace.TraceError(errors2.ErrorModelMissingId)
 	}
@@ -312,7 +345,9 @@ func (d *ModelDelegate) upsertArtifact() (err error) {
 		}
 	}
 
-	// existing artifact
+	// existing artifact, which is no longer deleted if it was
+	d.a.SetDel(false)
+	d.a.SetObj(nil)
 	d.a.GetSys().SetUpdateTs(time.Now())
 	if d.u != nil {
 		d.a.GetSys().SetUpdateUid(d.u.GetId())
@@ -333,6 +368,16 @@ func (d *ModelDelegate) deleteArtifact() (err error) {
 		return trace.TraceError(errors.ErrMissingValue)
 	}
 	col := mongo.GetMongoCol(interfaces.ModelColNameArtifact)
+
+	// keep tags and sys info of existing artifact so that it can be restored
+	exists := true
+	if err := col.FindId(d.doc.GetId()).One(d.a); err != nil {
+		if err != mongo2.ErrNoDocuments {
+			return trace.TraceError(err)
+		}
+		exists = false
+	}
+
 	d.a.SetId(d.doc.GetId())
 	d.a.SetObj(d.doc)
 	d.a.SetDel(true)
@@ -340,6 +385,10 @@ func (d *ModelDelegate) deleteArtifact() (err error) {
 	if d.u != nil {
 		d.a.GetSys().SetDeleteUid(d.u.GetId())
 	}
+	if !exists {
+		_, err = col.Insert(d.a)
+		return err
+	}
 	return col.ReplaceId(d.doc.GetId(), d.a)
 }
 
@@ -387,19 +436,41 @@ func (d *ModelDelegate) updateTags() (err error) {
 	return col.ReplaceId(d.a.GetId(), d.a)
 }
 
+// audit record changes of the doc in audit logs
+func (d *ModelDelegate) audit(action string, old, new bson.M) {
+	// skip
+	if d._skip() {
+		return
+	}
+
+	if err := audit.Record(d.colName, d.doc.GetId(), action, old, new, d.u, d.source); err != nil {
+		trace.PrintError(err)
+	}
+}
+
 func (d *ModelDelegate) hasChange() (ok bool) {
 	return !utils.BsonMEqual(d.cd, d.od)
 }
 
 func (d *ModelDelegate) _skip() (ok bool) {
-	switch d.id {
+	return IsSkipModel(d.id)
+}
+
+// IsSkipModel whether artifacts and audit logs are skipped for the model of given id
+func IsSkipModel(id interfaces.ModelId) (ok bool) {
+	switch id {
 	case
 		interfaces.ModelIdArtifact,
 		interfaces.ModelIdTaskQueue,
 		interfaces.ModelIdTaskStat,
 		interfaces.ModelIdSpiderStat,
 		interfaces.ModelIdResult,
-		interfaces.ModelIdPassword:
+		interfaces.ModelIdPassword,
+		interfaces.ModelIdTaskMetric,
+		interfaces.ModelIdDependencyEnv,
+		interfaces.ModelIdAuditLog,
+		interfaces.ModelIdGitWebhookDelivery,
+		interfaces.ModelIdScheduleSkip:
 		return true
 	default:
 		return false
@@ -1,11 +1,13 @@
 package delegate_test
 
 import (
+	"github.com/doubletrey/crawlab-core/constants"
 	"github.com/doubletrey/crawlab-core/interfaces"
 	"github.com/doubletrey/crawlab-core/models/delegate"
 	models2 "github.com/doubletrey/crawlab-core/models/models"
 	"github.com/doubletrey/crawlab-db/mongo"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"testing"
 )
 
@@ -66,3 +68,59 @@ func TestProject_Delete(t *testing.T) {
 	require.NotNil(t, a.Obj)
 	require.True(t, a.Del)
 }
+
+func TestProject_AuditLogs(t *testing.T) {
+	SetupTest(t)
+
+	p := &models2.Project{
+		Name: "test_project",
+	}
+
+	err := delegate.NewModelDelegate(p).Add()
+	require.Nil(t, err)
+
+	p.Name = "test_project_2"
+	err = delegate.NewModelDelegate(p).Save()
+	require.Nil(t, err)
+
+	// no change
+	err = delegate.NewModelDelegate(p).Save()
+	require.Nil(t, err)
+
+	err = delegate.NewModelDelegate(p).Delete()
+	require.Nil(t, err)
+
+	var logs []models2.AuditLog
+	col := mongo.GetMongoCol(interfaces.ModelColNameAuditLog)
+	err = col.Find(bson.M{"resource_id": p.Id}, &mongo.FindOptions{Sort: bson.D{{Key: "ts", Value: 1}}}).All(&logs)
+	require.Nil(t, err)
+	require.Equal(t, 3, len(logs))
+	require.Equal(t, constants.AuditActionAdd, logs[0].Action)
+	require.Equal(t, constants.AuditActionSave, logs[1].Action)
+	require.Equal(t, constants.AuditActionDelete, logs[2].Action)
+	require.Equal(t, string(constants.AuditSourceSystem), logs[1].Source)
+	require.Equal(t, 1, len(logs[1].Diff))
+	require.Equal(t, "name", logs[1].Diff[0].Key)
+	require.Equal(t, "test_project", logs[1].Diff[0].Old)
+	require.Equal(t, "test_project_2", logs[1].Diff[0].New)
+}
+
+func TestSshKey_AuditLogs(t *testing.T) {
+	SetupTest(t)
+
+	k := &models2.SshKey{
+		Name:       "test_ssh_key",
+		PrivateKey: "test_private_key",
+	}
+	err := delegate.NewModelDelegate(k).Add()
+	require.Nil(t, err)
+
+	var logs []models2.AuditLog
+	col := mongo.GetMongoCol(interfaces.ModelColNameAuditLog)
+	err = col.Find(bson.M{"resource_id": k.Id}, nil).All(&logs)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(logs))
+	for _, d := range logs[0].Diff {
+		require.NotEqual(t, "test_private_key", d.New)
+	}
+}
@@ -12,6 +12,7 @@ type ArtifactSys struct {
 	UpdateUid primitive.ObjectID `json:"update_uid" bson:"update_uid"`
 	DeleteTs  time.Time          `json:"delete_ts" bson:"delete_ts"`
 	DeleteUid primitive.ObjectID `json:"delete_uid" bson:"delete_uid"`
+	RecycleId primitive.ObjectID `json:"recycle_id" bson:"recycle_id,omitempty"`
 }
 
 func (sys *ArtifactSys) GetCreateTs() time.Time {
@@ -61,3 +62,11 @@ func (sys *ArtifactSys) GetDeleteUid() primitive.ObjectID {
 func (sys *ArtifactSys) SetDeleteUid(id primitive.ObjectID) {
 	sys.DeleteUid = id
 }
+
+func (sys *ArtifactSys) GetRecycleId() primitive.ObjectID {
+	return sys.RecycleId
+}
+
+func (sys *ArtifactSys) SetRecycleId(id primitive.ObjectID) {
+	sys.RecycleId = id
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// AuditLog is an append-only record of a change on a document
+type AuditLog struct {
+	Id         primitive.ObjectID `json:"_id" bson:"_id"`
+	Col        string             `json:"col" bson:"col"`                 // collection of changed document
+	ResourceId primitive.ObjectID `json:"resource_id" bson:"resource_id"` // id of changed document
+	Action     string             `json:"action" bson:"action"`
+	Source     string             `json:"source" bson:"source"` // http, grpc or system
+	UserId     primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Ts         time.Time          `json:"ts" bson:"ts"`
+	Diff       []AuditLogDiff     `json:"diff" bson:"diff"` // changed fields
+}
+
+// AuditLogDiff is a change of a top-level field of a document
+type AuditLogDiff struct {
+	Key string      `json:"key" bson:"key"`
+	Old interface{} `json:"old" bson:"old"`
+	New interface{} `json:"new" bson:"new"`
+}
+
+func (l *AuditLog) GetId() (id primitive.ObjectID) {
+	return l.Id
+}
+
+func (l *AuditLog) SetId(id primitive.ObjectID) {
+	l.Id = id
+}
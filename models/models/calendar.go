@@ -0,0 +1,30 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Calendar is a set of dates such as holidays or maintenance days, on which
+// schedules using the calendar do not fire
+type Calendar struct {
+	Id          primitive.ObjectID `json:"_id" bson:"_id"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description" bson:"description"`
+	Dates       []CalendarDate     `json:"dates" bson:"dates"`
+}
+
+// CalendarDate excluded date, or range of dates if end date is set, where
+// dates are in format of "2006-01-02" in time zone of the schedule
+type CalendarDate struct {
+	Date string `json:"date" bson:"date"`
+	End  string `json:"end" bson:"end"`   // inclusive end date of the range
+	Name string `json:"name" bson:"name"` // e.g. name of the holiday
+}
+
+func (c *Calendar) GetId() (id primitive.ObjectID) {
+	return c.Id
+}
+
+func (c *Calendar) SetId(id primitive.ObjectID) {
+	c.Id = id
+}
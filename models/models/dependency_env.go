@@ -0,0 +1,28 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// DependencyEnv is the install status of a dependency environment
+// (e.g. python venv, node_modules) of a spider on a node
+type DependencyEnv struct {
+	Id       primitive.ObjectID `json:"_id" bson:"_id"`
+	SpiderId primitive.ObjectID `json:"spider_id" bson:"spider_id"`
+	NodeId   primitive.ObjectID `json:"node_id" bson:"node_id"`
+	Type     string             `json:"type" bson:"type"`
+	Hash     string             `json:"hash" bson:"hash"` // hash of dependency files
+	Status   string             `json:"status" bson:"status"`
+	Error    string             `json:"error" bson:"error"`
+	Logs     string             `json:"logs" bson:"logs"` // install logs
+	UpdateTs time.Time          `json:"update_ts" bson:"update_ts"`
+}
+
+func (e *DependencyEnv) GetId() (id primitive.ObjectID) {
+	return e.Id
+}
+
+func (e *DependencyEnv) SetId(id primitive.ObjectID) {
+	e.Id = id
+}
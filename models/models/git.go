@@ -5,11 +5,16 @@ import (
 )
 
 type Git struct {
-	Id       primitive.ObjectID `json:"_id" bson:"_id"`
-	Url      string             `json:"url" bson:"url"`
-	AuthType string             `json:"auth_type" bson:"auth_type"`
-	Username string             `json:"username" bson:"username"`
-	Password string             `json:"password" bson:"password"`
+	Id            primitive.ObjectID `json:"_id" bson:"_id"`
+	Url           string             `json:"url" bson:"url"`
+	AuthType      string             `json:"auth_type" bson:"auth_type"`
+	Username      string             `json:"username" bson:"username"`
+	Password      string             `json:"password" bson:"password"`
+	WebhookSecret string             `json:"webhook_secret" bson:"webhook_secret"`   // secret to verify push webhooks
+	WebhookRun    bool               `json:"webhook_run" bson:"webhook_run"`         // whether to run spider after pulled by webhook
+	SshKeyId      primitive.ObjectID `json:"ssh_key_id" bson:"ssh_key_id"`           // SshKey.Id used if auth type is ssh
+	StrictHostKey bool               `json:"strict_host_key" bson:"strict_host_key"` // whether to verify host key of ssh remote
+	KnownHosts    string             `json:"known_hosts" bson:"known_hosts"`         // known_hosts lines of ssh remote in addition to known hosts file
 }
 
 func (t *Git) GetId() (id primitive.ObjectID) {
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// GitWebhookDelivery is a record of a received git webhook for troubleshooting
+type GitWebhookDelivery struct {
+	Id         primitive.ObjectID         `json:"_id" bson:"_id"`
+	Provider   string                     `json:"provider" bson:"provider"`       // github, gitlab or gitea
+	Event      string                     `json:"event" bson:"event"`             // event type in request header
+	DeliveryId string                     `json:"delivery_id" bson:"delivery_id"` // delivery id assigned by provider
+	RepoUrl    string                     `json:"repo_url" bson:"repo_url"`
+	Branch     string                     `json:"branch" bson:"branch"`
+	Commit     string                     `json:"commit" bson:"commit"`
+	Status     string                     `json:"status" bson:"status"`
+	Error      string                     `json:"error" bson:"error"`
+	Results    []GitWebhookDeliverySpider `json:"results" bson:"results"` // results of matched spiders
+	Ts         time.Time                  `json:"ts" bson:"ts"`
+}
+
+// GitWebhookDeliverySpider is the result of a git webhook delivery on a matched spider
+type GitWebhookDeliverySpider struct {
+	SpiderId primitive.ObjectID `json:"spider_id" bson:"spider_id"`
+	Commit   string             `json:"commit" bson:"commit"` // hash of pulled commit
+	Run      bool               `json:"run" bson:"run"`       // whether a run is started
+	Error    string             `json:"error" bson:"error"`
+}
+
+func (d *GitWebhookDelivery) GetId() (id primitive.ObjectID) {
+	return d.Id
+}
+
+func (d *GitWebhookDelivery) SetId(id primitive.ObjectID) {
+	d.Id = id
+}
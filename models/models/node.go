@@ -22,6 +22,7 @@ type Node struct {
 	ActiveTs         time.Time          `json:"active_ts" bson:"active_ts"`
 	AvailableRunners int                `json:"available_runners" bson:"available_runners"`
 	MaxRunners       int                `json:"max_runners" bson:"max_runners"`
+	Runners          int                `json:"runners" bson:"runners"`
 	Tags             []Tag              `json:"tags" bson:"-"`
 }
 
@@ -97,6 +98,14 @@ func (n *Node) SetMaxRunners(runners int) {
 	n.MaxRunners = runners
 }
 
+func (n *Node) GetRunners() (runners int) {
+	return n.Runners
+}
+
+func (n *Node) SetRunners(runners int) {
+	n.Runners = runners
+}
+
 func (n *Node) IncrementAvailableRunners() {
 	n.AvailableRunners++
 }
@@ -3,6 +3,7 @@ package models
 import (
 	"github.com/robfig/cron/v3"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
 )
 
 type Schedule struct {
@@ -10,14 +11,25 @@ type Schedule struct {
 	Name           string               `json:"name" bson:"name"`
 	Description    string               `json:"description" bson:"description"`
 	SpiderId       primitive.ObjectID   `json:"spider_id" bson:"spider_id"`
+	Type           string               `json:"type" bson:"type"` // cron (default) or once
 	Cron           string               `json:"cron" bson:"cron"`
+	RunAt          time.Time            `json:"run_at" bson:"run_at"`     // time of the only fire of one-off schedule, which is disabled after firing
+	Timezone       string               `json:"timezone" bson:"timezone"` // IANA time zone of cron, e.g. Asia/Shanghai, which is location of schedule service if empty
 	EntryId        cron.EntryID         `json:"entry_id" bson:"entry_id"`
 	Cmd            string               `json:"cmd" bson:"cmd"`
-	Param          string               `json:"param" bson:"param"`
+	Param          string               `json:"param" bson:"param"` // Go template rendered at fire time, see schedule.TemplateData
+	Envs           []Env                `json:"envs" bson:"envs"`   // environment variables of tasks, whose values are rendered like param
 	Mode           string               `json:"mode" bson:"mode"`
 	NodeIds        []primitive.ObjectID `json:"node_ids" bson:"node_ids"`
 	NodeTags       []string             `json:"node_tags" bson:"node_tags"`
 	Priority       int                  `json:"priority" bson:"priority"`
+	Ref            string               `json:"ref" bson:"ref"`                         // git ref (tag, branch or commit) to run
+	OverlapPolicy  string               `json:"overlap_policy" bson:"overlap_policy"`   // policy when previous runs are still active, which is service default if empty
+	MisfirePolicy  string               `json:"misfire_policy" bson:"misfire_policy"`   // policy of fires missed during master downtime, which is service default if empty
+	LastFireTs     time.Time            `json:"last_fire_ts" bson:"last_fire_ts"`       // time of the last fire, including those skipped due to overlap policy
+	CalendarIds    []primitive.ObjectID `json:"calendar_ids" bson:"calendar_ids"`       // calendars of dates without fires
+	Blackouts      []ScheduleBlackout   `json:"blackouts" bson:"blackouts"`             // daily windows without fires
+	BlackoutPolicy string               `json:"blackout_policy" bson:"blackout_policy"` // skip (default) or defer fires in blackout windows
 	Enabled        bool                 `json:"enabled" bson:"enabled"`
 	UserId         primitive.ObjectID   `json:"user_id" bson:"user_id"`
 	ScrapySpider   string               `json:"scrapy_spider" bson:"scrapy_spider"`
@@ -25,6 +37,15 @@ type Schedule struct {
 	Tags           []string             `json:"tags" bson:"-"`
 }
 
+// ScheduleBlackout daily window in time zone of the schedule, during which fires
+// are skipped or deferred to its end. The window spans midnight if end is not
+// after start
+type ScheduleBlackout struct {
+	Start    string `json:"start" bson:"start"`       // start time in format of "15:04"
+	End      string `json:"end" bson:"end"`           // end time in format of "15:04"
+	Weekdays []int  `json:"weekdays" bson:"weekdays"` // weekdays of start, 0 for Sunday, which are all days if empty
+}
+
 func (s *Schedule) GetId() (id primitive.ObjectID) {
 	return s.Id
 }
@@ -49,6 +70,14 @@ func (s *Schedule) SetEntryId(id cron.EntryID) {
 	s.EntryId = id
 }
 
+func (s *Schedule) GetType() (t string) {
+	return s.Type
+}
+
+func (s *Schedule) GetRunAt() (ts time.Time) {
+	return s.RunAt
+}
+
 func (s *Schedule) GetCron() (c string) {
 	return s.Cron
 }
@@ -57,6 +86,18 @@ func (s *Schedule) SetCron(c string) {
 	s.Cron = c
 }
 
+func (s *Schedule) GetTimezone() (tz string) {
+	return s.Timezone
+}
+
+func (s *Schedule) GetLastFireTs() (ts time.Time) {
+	return s.LastFireTs
+}
+
+func (s *Schedule) SetLastFireTs(ts time.Time) {
+	s.LastFireTs = ts
+}
+
 func (s *Schedule) GetSpiderId() (id primitive.ObjectID) {
 	return s.SpiderId
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// ScheduleSkip is a record of a fire of the schedule skipped due to its
+// overlap policy as previous runs of the schedule are still active, or due to
+// its calendars or blackout windows
+type ScheduleSkip struct {
+	Id         primitive.ObjectID   `json:"_id" bson:"_id"`
+	ScheduleId primitive.ObjectID   `json:"schedule_id" bson:"schedule_id"`
+	SpiderId   primitive.ObjectID   `json:"spider_id" bson:"spider_id"`
+	Reason     string               `json:"reason" bson:"reason"`     // overlap, calendar or blackout
+	Policy     string               `json:"policy" bson:"policy"`     // overlap or blackout policy of the schedule at the fire
+	TaskIds    []primitive.ObjectID `json:"task_ids" bson:"task_ids"` // active tasks of previous runs
+	Ts         time.Time            `json:"ts" bson:"ts"`
+}
+
+func (s *ScheduleSkip) GetId() (id primitive.ObjectID) {
+	return s.Id
+}
+
+func (s *ScheduleSkip) SetId(id primitive.ObjectID) {
+	s.Id = id
+}
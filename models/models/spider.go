@@ -3,6 +3,7 @@ package models
 import (
 	"github.com/doubletrey/crawlab-core/interfaces"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
 )
 
 type Env struct {
@@ -43,15 +44,25 @@ type Spider struct {
 	Template string `json:"template" bson:"template"` // Spiderfile模版
 
 	// Git 设置
-	IsGit            bool   `json:"is_git" bson:"is_git"`                         // 是否为 Git
-	GitUrl           string `json:"git_url" bson:"git_url"`                       // Git URL
-	GitBranch        string `json:"git_branch" bson:"git_branch"`                 // Git 分支
-	GitHasCredential bool   `json:"git_has_credential" bson:"git_has_credential"` // Git 是否加密
-	GitUsername      string `json:"git_username" bson:"git_username"`             // Git 用户名
-	GitPassword      string `json:"git_password" bson:"git_password"`             // Git 密码
-	GitAutoSync      bool   `json:"git_auto_sync" bson:"git_auto_sync"`           // Git 是否自动同步
-	GitSyncFrequency string `json:"git_sync_frequency" bson:"git_sync_frequency"` // Git 同步频率
-	GitSyncError     string `json:"git_sync_error" bson:"git_sync_error"`         // Git 同步错误
+	IsGit            bool      `json:"is_git" bson:"is_git"`                         // 是否为 Git
+	GitUrl           string    `json:"git_url" bson:"git_url"`                       // Git URL
+	GitBranch        string    `json:"git_branch" bson:"git_branch"`                 // Git 分支
+	GitHasCredential bool      `json:"git_has_credential" bson:"git_has_credential"` // Git 是否加密
+	GitUsername      string    `json:"git_username" bson:"git_username"`             // Git 用户名
+	GitPassword      string    `json:"git_password" bson:"git_password"`             // Git 密码
+	GitAutoSync      bool      `json:"git_auto_sync" bson:"git_auto_sync"`           // Git 是否自动同步
+	GitSyncFrequency string    `json:"git_sync_frequency" bson:"git_sync_frequency"` // Git 同步频率
+	GitSyncError     string    `json:"git_sync_error" bson:"git_sync_error"`         // Git 同步错误
+	GitSyncCommit    string    `json:"git_sync_commit" bson:"git_sync_commit"`       // hash of last synced commit
+	GitSyncTs        time.Time `json:"git_sync_ts" bson:"git_sync_ts"`               // time of last sync
+
+	// 容器
+	Image string `json:"image" bson:"image"` // container image, executed as local process if empty
+
+	// 资源限制
+	CpuLimit    float64 `json:"cpu_limit" bson:"cpu_limit"`       // number of cpu cores
+	MemoryLimit int64   `json:"memory_limit" bson:"memory_limit"` // in megabyte
+	PidsLimit   int64   `json:"pids_limit" bson:"pids_limit"`     // max number of processes
 
 	// 长任务
 	IsLongTask bool `json:"is_long_task" bson:"is_long_task"` // 是否为长任务
@@ -145,3 +156,19 @@ func (s *Spider) GetColId() (id primitive.ObjectID) {
 func (s *Spider) SetColId(id primitive.ObjectID) {
 	s.ColId = id
 }
+
+func (s *Spider) GetCpuLimit() (n float64) {
+	return s.CpuLimit
+}
+
+func (s *Spider) GetMemoryLimit() (n int64) {
+	return s.MemoryLimit
+}
+
+func (s *Spider) GetPidsLimit() (n int64) {
+	return s.PidsLimit
+}
+
+func (s *Spider) GetImage() (image string) {
+	return s.Image
+}
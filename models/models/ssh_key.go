@@ -0,0 +1,28 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// SshKey is an ssh deploy key for git remotes of spiders, whose private key
+// and passphrase are encrypted and never exposed
+type SshKey struct {
+	Id          primitive.ObjectID `json:"_id" bson:"_id"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description" bson:"description"`
+	Type        string             `json:"type" bson:"type"`               // key type, e.g. ssh-ed25519 or ssh-rsa
+	PublicKey   string             `json:"public_key" bson:"public_key"`   // public key in authorized_keys format
+	Fingerprint string             `json:"fingerprint" bson:"fingerprint"` // SHA256 fingerprint of public key
+	PrivateKey  string             `json:"-" bson:"private_key"`           // encrypted private key in PEM format
+	Passphrase  string             `json:"-" bson:"passphrase"`            // encrypted passphrase of private key
+	Ts          time.Time          `json:"ts" bson:"ts"`
+}
+
+func (k *SshKey) GetId() (id primitive.ObjectID) {
+	return k.Id
+}
+
+func (k *SshKey) SetId(id primitive.ObjectID) {
+	k.Id = id
+}
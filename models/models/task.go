@@ -1,29 +1,36 @@
 package models
 
 import (
+	"github.com/doubletrey/crawlab-core/interfaces"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Task struct {
-	Id         primitive.ObjectID   `json:"_id" bson:"_id"`
-	SpiderId   primitive.ObjectID   `json:"spider_id" bson:"spider_id"`
-	Status     string               `json:"status" bson:"status"`
-	NodeId     primitive.ObjectID   `json:"node_id" bson:"node_id"`
-	Cmd        string               `json:"cmd" bson:"cmd"`
-	Param      string               `json:"param" bson:"param"`
-	Error      string               `json:"error" bson:"error"`
-	Pid        int                  `json:"pid" bson:"pid"`
-	ScheduleId primitive.ObjectID   `json:"schedule_id" bson:"schedule_id"` // Schedule.Id
-	Type       string               `json:"type" bson:"type"`
-	Mode       string               `json:"mode" bson:"mode"`           // running mode of Task
-	NodeIds    []primitive.ObjectID `json:"node_ids" bson:"node_ids"`   // list of Node.Id
-	NodeTags   []string             `json:"node_tags" bson:"node_tags"` // list of Node.Tag
-	ParentId   primitive.ObjectID   `json:"parent_id" bson:"parent_id"` // parent Task.Id if it'Spider a sub-task
-	Priority   int                  `json:"priority" bson:"priority"`
-	Stat       *TaskStat            `json:"stat,omitempty" bson:"-"`
-	HasSub     bool                 `json:"has_sub" json:"has_sub"` // whether to have sub-tasks
-	SubTasks   []Task               `json:"sub_tasks,omitempty" bson:"-"`
-	UserId     primitive.ObjectID   `json:"-" bson:"-"`
+	Id         primitive.ObjectID        `json:"_id" bson:"_id"`
+	SpiderId   primitive.ObjectID        `json:"spider_id" bson:"spider_id"`
+	Status     string                    `json:"status" bson:"status"`
+	NodeId     primitive.ObjectID        `json:"node_id" bson:"node_id"`
+	Cmd        string                    `json:"cmd" bson:"cmd"`
+	Param      string                    `json:"param" bson:"param"`
+	Envs       []Env                     `json:"envs" bson:"envs"` // environment variables of the task process
+	Error      string                    `json:"error" bson:"error"`
+	Pid        int                       `json:"pid" bson:"pid"`
+	LeakedPids []int                     `json:"leaked_pids,omitempty" bson:"leaked_pids,omitempty"` // descendant processes alive after exit
+	ScheduleId primitive.ObjectID        `json:"schedule_id" bson:"schedule_id"`                     // Schedule.Id
+	Type       string                    `json:"type" bson:"type"`
+	Mode       string                    `json:"mode" bson:"mode"`           // running mode of Task
+	NodeIds    []primitive.ObjectID      `json:"node_ids" bson:"node_ids"`   // list of Node.Id
+	NodeTags   []string                  `json:"node_tags" bson:"node_tags"` // list of Node.Tag
+	ParentId   primitive.ObjectID        `json:"parent_id" bson:"parent_id"` // parent Task.Id if it'Spider a sub-task
+	Priority   int                       `json:"priority" bson:"priority"`
+	Ref        string                    `json:"ref" bson:"ref"`       // git ref (tag, branch or commit) the task is pinned to
+	Commit     string                    `json:"commit" bson:"commit"` // commit hash of the spider code
+	Stat       *TaskStat                 `json:"stat,omitempty" bson:"-"`
+	Metric     *TaskMetric               `json:"metric,omitempty" bson:"-"`
+	Queue      *interfaces.TaskQueueInfo `json:"queue,omitempty" bson:"-"` // queue position if pending
+	HasSub     bool                      `json:"has_sub" json:"has_sub"`   // whether to have sub-tasks
+	SubTasks   []Task                    `json:"sub_tasks,omitempty" bson:"-"`
+	UserId     primitive.ObjectID        `json:"-" bson:"-"`
 }
 
 func (t *Task) GetId() (id primitive.ObjectID) {
@@ -74,6 +81,14 @@ func (t *Task) SetPid(pid int) {
 	t.Pid = pid
 }
 
+func (t *Task) GetLeakedPids() (pids []int) {
+	return t.LeakedPids
+}
+
+func (t *Task) SetLeakedPids(pids []int) {
+	t.LeakedPids = pids
+}
+
 func (t *Task) GetSpiderId() (id primitive.ObjectID) {
 	return t.SpiderId
 }
@@ -94,6 +109,14 @@ func (t *Task) GetPriority() (p int) {
 	return t.Priority
 }
 
+func (t *Task) GetRef() (ref string) {
+	return t.Ref
+}
+
+func (t *Task) GetCommit() (commit string) {
+	return t.Commit
+}
+
 func (t *Task) GetUserId() (id primitive.ObjectID) {
 	return t.UserId
 }
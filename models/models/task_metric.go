@@ -0,0 +1,31 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// TaskMetric is the downsampled resource usage time series of a task,
+// sharing the same id with the task
+type TaskMetric struct {
+	Id       primitive.ObjectID `json:"_id" bson:"_id"`
+	Interval int64              `json:"interval" bson:"interval"` // in millisecond
+	Points   []TaskMetricPoint  `json:"points" bson:"points"`
+}
+
+type TaskMetricPoint struct {
+	Ts         time.Time `json:"ts" bson:"ts"`
+	CpuPercent float64   `json:"cpu_percent" bson:"cpu_percent"`
+	Rss        int64     `json:"rss" bson:"rss"` // in byte
+	ReadBytes  int64     `json:"read_bytes" bson:"read_bytes"`
+	WriteBytes int64     `json:"write_bytes" bson:"write_bytes"`
+	Threads    int       `json:"threads" bson:"threads"`
+}
+
+func (m *TaskMetric) GetId() (id primitive.ObjectID) {
+	return m.Id
+}
+
+func (m *TaskMetric) SetId(id primitive.ObjectID) {
+	m.Id = id
+}
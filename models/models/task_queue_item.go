@@ -1,10 +1,16 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
 
 type TaskQueueItem struct {
-	Id       primitive.ObjectID `json:"_id" bson:"_id"`
-	Priority int                `json:"p" bson:"p"`
+	Id        primitive.ObjectID `json:"_id" bson:"_id"`
+	Priority  int                `json:"p" bson:"p"`
+	Ts        time.Time          `json:"ts" bson:"ts"`                 // enqueue time, from which priority is aged
+	ProjectId primitive.ObjectID `json:"project_id" bson:"project_id"` // project of the spider, for fair-share dequeue
+	UserId    primitive.ObjectID `json:"user_id" bson:"user_id"`       // user who runs the task, for fair-share dequeue
 }
 
 func (t *TaskQueueItem) GetId() (id primitive.ObjectID) {
@@ -11,10 +11,16 @@ type TaskStat struct {
 	StartTs         time.Time          `json:"start_ts" bson:"start_ts,omitempty"`
 	EndTs           time.Time          `json:"end_ts" bson:"end_ts,omitempty"`
 	WaitDuration    int64              `json:"wait_duration" bson:"wait_duration,omitempty"`       // in millisecond
+	SyncDuration    int64              `json:"sync_duration" bson:"sync_duration,omitempty"`       // in millisecond, of syncing files to workspace before running
 	RuntimeDuration int64              `json:"runtime_duration" bson:"runtime_duration,omitempty"` // in millisecond
 	TotalDuration   int64              `json:"total_duration" bson:"total_duration,omitempty"`     // in millisecond
 	ResultCount     int64              `json:"result_count" bson:"result_count"`
 	ErrorLogCount   int64              `json:"error_log_count" bson:"error_log_count"`
+	CpuTime         int64              `json:"cpu_time" bson:"cpu_time,omitempty"` // in millisecond
+	PeakRss         int64              `json:"peak_rss" bson:"peak_rss,omitempty"` // in byte
+	ReadBytes       int64              `json:"read_bytes" bson:"read_bytes,omitempty"`
+	WriteBytes      int64              `json:"write_bytes" bson:"write_bytes,omitempty"`
+	PeakThreads     int                `json:"peak_threads" bson:"peak_threads,omitempty"`
 }
 
 func (s *TaskStat) GetId() (id primitive.ObjectID) {
@@ -57,6 +63,14 @@ func (s *TaskStat) SetWaitDuration(d int64) {
 	s.WaitDuration = d
 }
 
+func (s *TaskStat) GetSyncDuration() (d int64) {
+	return s.SyncDuration
+}
+
+func (s *TaskStat) SetSyncDuration(d int64) {
+	s.SyncDuration = d
+}
+
 func (s *TaskStat) GetRuntimeDuration() (d int64) {
 	return s.RuntimeDuration
 }
@@ -88,3 +102,43 @@ func (s *TaskStat) GetErrorLogCount() (c int64) {
 func (s *TaskStat) SetErrorLogCount(c int64) {
 	s.ErrorLogCount = c
 }
+
+func (s *TaskStat) GetCpuTime() (t int64) {
+	return s.CpuTime
+}
+
+func (s *TaskStat) SetCpuTime(t int64) {
+	s.CpuTime = t
+}
+
+func (s *TaskStat) GetPeakRss() (rss int64) {
+	return s.PeakRss
+}
+
+func (s *TaskStat) SetPeakRss(rss int64) {
+	s.PeakRss = rss
+}
+
+func (s *TaskStat) GetReadBytes() (b int64) {
+	return s.ReadBytes
+}
+
+func (s *TaskStat) SetReadBytes(b int64) {
+	s.ReadBytes = b
+}
+
+func (s *TaskStat) GetWriteBytes() (b int64) {
+	return s.WriteBytes
+}
+
+func (s *TaskStat) SetWriteBytes(b int64) {
+	s.WriteBytes = b
+}
+
+func (s *TaskStat) GetPeakThreads() (n int) {
+	return s.PeakThreads
+}
+
+func (s *TaskStat) SetPeakThreads(n int) {
+	s.PeakThreads = n
+}
@@ -1,55 +1,69 @@
 package models
 
 type ModelMap struct {
-	Artifact       Artifact
-	Tag            Tag
-	Node           Node
-	Project        Project
-	Spider         Spider
-	Task           Task
-	Job            Job
-	Schedule       Schedule
-	User           User
-	Setting        Setting
-	Token          Token
-	Variable       Variable
-	TaskQueueItem  TaskQueueItem
-	TaskStat       TaskStat
-	Plugin         Plugin
-	SpiderStat     SpiderStat
-	DataSource     DataSource
-	DataCollection DataCollection
-	Result         Result
-	Password       Password
-	ExtraValue     ExtraValue
-	PluginStatus   PluginStatus
-	Git            Git
+	Artifact           Artifact
+	Tag                Tag
+	Node               Node
+	Project            Project
+	Spider             Spider
+	Task               Task
+	Job                Job
+	Schedule           Schedule
+	User               User
+	Setting            Setting
+	Token              Token
+	Variable           Variable
+	TaskQueueItem      TaskQueueItem
+	TaskStat           TaskStat
+	Plugin             Plugin
+	SpiderStat         SpiderStat
+	DataSource         DataSource
+	DataCollection     DataCollection
+	Result             Result
+	Password           Password
+	ExtraValue         ExtraValue
+	PluginStatus       PluginStatus
+	Git                Git
+	TaskMetric         TaskMetric
+	DependencyEnv      DependencyEnv
+	AuditLog           AuditLog
+	GitWebhookDelivery GitWebhookDelivery
+	SshKey             SshKey
+	ScheduleSkip       ScheduleSkip
+	Calendar           Calendar
 }
 
 type ModelListMap struct {
-	Artifacts       []Artifact
-	Tags            []Tag
-	Nodes           []Node
-	Projects        []Project
-	Spiders         []Spider
-	Tasks           []Task
-	Jobs            []Job
-	Schedules       []Schedule
-	Users           []User
-	Settings        []Setting
-	Tokens          []Token
-	Variables       []Variable
-	TaskQueueItems  []TaskQueueItem
-	TaskStats       []TaskStat
-	Plugins         []Plugin
-	SpiderStats     []SpiderStat
-	DataSources     []DataSource
-	DataCollections []DataCollection
-	Results         []Result
-	Passwords       []Password
-	ExtraValues     []ExtraValue
-	PluginStatus    []PluginStatus
-	Gits            []Git
+	Artifacts            []Artifact
+	Tags                 []Tag
+	Nodes                []Node
+	Projects             []Project
+	Spiders              []Spider
+	Tasks                []Task
+	Jobs                 []Job
+	Schedules            []Schedule
+	Users                []User
+	Settings             []Setting
+	Tokens               []Token
+	Variables            []Variable
+	TaskQueueItems       []TaskQueueItem
+	TaskStats            []TaskStat
+	Plugins              []Plugin
+	SpiderStats          []SpiderStat
+	DataSources          []DataSource
+	DataCollections      []DataCollection
+	Results              []Result
+	Passwords            []Password
+	ExtraValues          []ExtraValue
+	PluginStatus         []PluginStatus
+	Gits                 []Git
+	TaskMetrics          []TaskMetric
+	DependencyEnvs       []DependencyEnv
+	AuditLogs            []AuditLog
+	GitWebhookDeliveries []GitWebhookDelivery
+	SshKeys              []SshKey
+	ScheduleSkips        []ScheduleSkip
+	Calendars            []Calendar
 }
 
 func NewModelMap() (m *ModelMap) {
@@ -58,27 +72,34 @@ func NewModelMap() (m *ModelMap) {
 
 func NewModelListMap() (m *ModelListMap) {
 	return &ModelListMap{
-		Artifacts:       []Artifact{},
-		Tags:            []Tag{},
-		Nodes:           []Node{},
-		Projects:        []Project{},
-		Spiders:         []Spider{},
-		Tasks:           []Task{},
-		Jobs:            []Job{},
-		Schedules:       []Schedule{},
-		Users:           []User{},
-		Settings:        []Setting{},
-		Tokens:          []Token{},
-		Variables:       []Variable{},
-		TaskQueueItems:  []TaskQueueItem{},
-		TaskStats:       []TaskStat{},
-		Plugins:         []Plugin{},
-		SpiderStats:     []SpiderStat{},
-		DataSources:     []DataSource{},
-		DataCollections: []DataCollection{},
-		Results:         []Result{},
-		Passwords:       []Password{},
-		ExtraValues:     []ExtraValue{},
-		PluginStatus:    []PluginStatus{},
+		Artifacts:            []Artifact{},
+		Tags:                 []Tag{},
+		Nodes:                []Node{},
+		Projects:             []Project{},
+		Spiders:              []Spider{},
+		Tasks:                []Task{},
+		Jobs:                 []Job{},
+		Schedules:            []Schedule{},
+		Users:                []User{},
+		Settings:             []Setting{},
+		Tokens:               []Token{},
+		Variables:            []Variable{},
+		TaskQueueItems:       []TaskQueueItem{},
+		TaskStats:            []TaskStat{},
+		Plugins:              []Plugin{},
+		SpiderStats:          []SpiderStat{},
+		DataSources:          []DataSource{},
+		DataCollections:      []DataCollection{},
+		Results:              []Result{},
+		Passwords:            []Password{},
+		ExtraValues:          []ExtraValue{},
+		PluginStatus:         []PluginStatus{},
+		TaskMetrics:          []TaskMetric{},
+		DependencyEnvs:       []DependencyEnv{},
+		AuditLogs:            []AuditLog{},
+		GitWebhookDeliveries: []GitWebhookDelivery{},
+		SshKeys:              []SshKey{},
+		ScheduleSkips:        []ScheduleSkip{},
+		Calendars:            []Calendar{},
 	}
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"encoding/json"
 	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/audit"
 	"github.com/doubletrey/crawlab-core/constants"
 	"github.com/doubletrey/crawlab-core/errors"
 	"github.com/doubletrey/crawlab-core/interfaces"
@@ -69,11 +70,11 @@ func (svc *BaseService) DeleteById(id primitive.ObjectID, args ...interface{}) (
 }
 
 func (svc *BaseService) Delete(query bson.M, args ...interface{}) (err error) {
-	return svc.delete(query)
+	return svc.delete(query, args...)
 }
 
 func (svc *BaseService) DeleteList(query bson.M, args ...interface{}) (err error) {
-	return svc.deleteList(query)
+	return svc.deleteList(query, args...)
 }
 
 func (svc *BaseService) ForceDeleteList(query bson.M, args ...interface{}) (err error) {
@@ -81,15 +82,15 @@ func (svc *BaseService) ForceDeleteList(query bson.M, args ...interface{}) (err
 }
 
 func (svc *BaseService) UpdateById(id primitive.ObjectID, update bson.M, args ...interface{}) (err error) {
-	return svc.updateId(id, update)
+	return svc.updateId(id, update, args...)
 }
 
 func (svc *BaseService) Update(query bson.M, update bson.M, fields []string, args ...interface{}) (err error) {
-	return svc.update(query, update, fields)
+	return svc.update(query, update, fields, args...)
 }
 
 func (svc *BaseService) UpdateDoc(query bson.M, doc interfaces.Model, fields []string, args ...interface{}) (err error) {
-	return svc.update(query, doc, fields)
+	return svc.update(query, doc, fields, args...)
 }
 
 func (svc *BaseService) Insert(u interfaces.User, docs ...interface{}) (err error) {
@@ -123,7 +124,7 @@ func (svc *BaseService) deleteId(id primitive.ObjectID, args ...interface{}) (er
 	if err != nil {
 		return err
 	}
-	return delegate.NewModelDelegate(doc, svc._getUserFromArgs(args...)).Delete()
+	return delegate.NewModelDelegate(doc, args...).Delete()
 }
 
 func (svc *BaseService) delete(query bson.M, args ...interface{}) (err error) {
@@ -134,7 +135,7 @@ func (svc *BaseService) delete(query bson.M, args ...interface{}) (err error) {
 	if err := svc.find(query, nil).One(&doc); err != nil {
 		return err
 	}
-	return svc.deleteId(doc.Id, svc._getUserFromArgs(args...))
+	return svc.deleteId(doc.Id, args...)
 }
 
 func (svc *BaseService) deleteList(query bson.M, args ...interface{}) (err error) {
@@ -151,7 +152,7 @@ func (svc *BaseService) deleteList(query bson.M, args ...interface{}) (err error
 		if !ok {
 			return errors.ErrorModelInvalidType
 		}
-		if err := delegate.NewModelDelegate(doc, svc._getUserFromArgs(args...)).Delete(); err != nil {
+		if err := delegate.NewModelDelegate(doc, args...).Delete(); err != nil {
 			return err
 		}
 	}
@@ -174,7 +175,7 @@ func (svc *BaseService) update(query bson.M, update interface{}, fields []string
 	if err != nil {
 		return err
 	}
-	return svc._update(query, update, svc._getUserFromArgs(args...))
+	return svc._update(query, update, args...)
 }
 
 func (svc *BaseService) updateId(id primitive.ObjectID, update interface{}, args ...interface{}) (err error) {
@@ -182,7 +183,7 @@ func (svc *BaseService) updateId(id primitive.ObjectID, update interface{}, args
 	if err != nil {
 		return err
 	}
-	return svc._updateById(id, update, svc._getUserFromArgs(args...))
+	return svc._updateById(id, update, args...)
 }
 
 func (svc *BaseService) insert(u interfaces.User, docs ...interface{}) (err error) {
@@ -269,6 +270,9 @@ func (svc *BaseService) _update(query bson.M, update interface{}, args ...interf
 		ids = append(ids, item.GetId())
 	}
 
+	// original docs
+	oldDocs := svc._getAuditDocs(ids)
+
 	// update model objects
 	if err := svc.col.Update(query, update); err != nil {
 		return err
@@ -276,10 +280,20 @@ func (svc *BaseService) _update(query bson.M, update interface{}, args ...interf
 
 	// update artifacts
 	u := svc._getUserFromArgs(args...)
-	return mongo.GetMongoCol(interfaces.ModelColNameArtifact).Update(query, svc._getUpdateArtifactUpdate(u))
+	if err := mongo.GetMongoCol(interfaces.ModelColNameArtifact).Update(bson.M{"_id": bson.M{"$in": ids}}, svc._getUpdateArtifactUpdate(u)); err != nil {
+		return err
+	}
+
+	// audit logs
+	svc._audit(ids, oldDocs, args...)
+
+	return nil
 }
 
 func (svc *BaseService) _updateById(id primitive.ObjectID, update interface{}, args ...interface{}) (err error) {
+	// original doc
+	oldDocs := svc._getAuditDocs([]primitive.ObjectID{id})
+
 	// update model object
 	if err := svc.col.UpdateId(id, update); err != nil {
 		return err
@@ -287,7 +301,50 @@ func (svc *BaseService) _updateById(id primitive.ObjectID, update interface{}, a
 
 	// update artifact
 	u := svc._getUserFromArgs(args...)
-	return mongo.GetMongoCol(interfaces.ModelColNameArtifact).UpdateId(id, svc._getUpdateArtifactUpdate(u))
+	if err := mongo.GetMongoCol(interfaces.ModelColNameArtifact).UpdateId(id, svc._getUpdateArtifactUpdate(u)); err != nil {
+		return err
+	}
+
+	// audit log
+	svc._audit([]primitive.ObjectID{id}, oldDocs, args...)
+
+	return nil
+}
+
+// _getAuditDocs docs of given ids as stored in database for audit logs
+func (svc *BaseService) _getAuditDocs(ids []primitive.ObjectID) (res map[primitive.ObjectID]bson.M) {
+	if delegate.IsSkipModel(svc.id) || len(ids) == 0 {
+		return nil
+	}
+	var docs []bson.M
+	if err := svc.col.Find(bson.M{"_id": bson.M{"$in": ids}}, nil).All(&docs); err != nil {
+		trace.PrintError(err)
+		return nil
+	}
+	res = map[primitive.ObjectID]bson.M{}
+	for _, doc := range docs {
+		id, ok := doc["_id"].(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		res[id] = doc
+	}
+	return res
+}
+
+// _audit record changes of docs of given ids in audit logs
+func (svc *BaseService) _audit(ids []primitive.ObjectID, oldDocs map[primitive.ObjectID]bson.M, args ...interface{}) {
+	if delegate.IsSkipModel(svc.id) {
+		return
+	}
+	u := svc._getUserFromArgs(args...)
+	source := utils.GetAuditSourceFromArgs(args...)
+	newDocs := svc._getAuditDocs(ids)
+	for _, id := range ids {
+		if err := audit.Record(svc.col.GetName(), id, constants.AuditActionUpdate, oldDocs[id], newDocs[id], u, source); err != nil {
+			trace.PrintError(err)
+		}
+	}
 }
 
 func (svc *BaseService) _getUpdateBsonM(update interface{}, fields []string) (res bson.M, err error) {
@@ -0,0 +1,45 @@
+package service_test
+
+import (
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/delegate"
+	models2 "github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+func TestBaseService_Update_Audit(t *testing.T) {
+	SetupTest(t)
+
+	s := &models2.Schedule{Name: "test_schedule", Cron: "* * * * *"}
+	err := delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+
+	svc, err := service.NewService()
+	require.Nil(t, err)
+
+	// update by grpc on behalf of the user
+	u := &models2.User{Id: primitive.NewObjectID()}
+	query := bson.M{"_id": s.Id}
+	err = svc.GetBaseService(interfaces.ModelIdSchedule).Update(query, bson.M{"cron": "0 * * * *"}, nil, u, constants.AuditSourceGrpc)
+	require.Nil(t, err)
+
+	l, err := svc.GetAuditLog(bson.M{"resource_id": s.Id, "action": constants.AuditActionUpdate}, nil)
+	require.Nil(t, err)
+	require.Equal(t, u.Id, l.UserId)
+	require.Equal(t, string(constants.AuditSourceGrpc), l.Source)
+	require.Equal(t, 1, len(l.Diff))
+	require.Equal(t, "cron", l.Diff[0].Key)
+
+	// delete list by the user
+	err = svc.GetBaseService(interfaces.ModelIdSchedule).DeleteList(query, u)
+	require.Nil(t, err)
+	l, err = svc.GetAuditLog(bson.M{"resource_id": s.Id, "action": constants.AuditActionDelete}, nil)
+	require.Nil(t, err)
+	require.Equal(t, u.Id, l.UserId)
+	require.Equal(t, string(constants.AuditSourceHttp), l.Source)
+}
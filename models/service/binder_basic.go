@@ -75,6 +75,20 @@ func (b *BasicBinder) Bind() (res interfaces.Model, err error) {
 		return b.Process(&m.PluginStatus)
 	case interfaces.ModelIdGit:
 		return b.Process(&m.Git)
+	case interfaces.ModelIdTaskMetric:
+		return b.Process(&m.TaskMetric)
+	case interfaces.ModelIdDependencyEnv:
+		return b.Process(&m.DependencyEnv)
+	case interfaces.ModelIdAuditLog:
+		return b.Process(&m.AuditLog)
+	case interfaces.ModelIdGitWebhookDelivery:
+		return b.Process(&m.GitWebhookDelivery)
+	case interfaces.ModelIdSshKey:
+		return b.Process(&m.SshKey)
+	case interfaces.ModelIdScheduleSkip:
+		return b.Process(&m.ScheduleSkip)
+	case interfaces.ModelIdCalendar:
+		return b.Process(&m.Calendar)
 	default:
 		return nil, errors.ErrorModelInvalidModelId
 	}
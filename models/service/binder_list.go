@@ -78,6 +78,20 @@ func (b *ListBinder) Bind() (list arraylist.List, err error) {
 		return b.Process(m.PluginStatus)
 	case interfaces.ModelIdGit:
 		return b.Process(m.Gits)
+	case interfaces.ModelIdTaskMetric:
+		return b.Process(m.TaskMetrics)
+	case interfaces.ModelIdDependencyEnv:
+		return b.Process(m.DependencyEnvs)
+	case interfaces.ModelIdAuditLog:
+		return b.Process(m.AuditLogs)
+	case interfaces.ModelIdGitWebhookDelivery:
+		return b.Process(m.GitWebhookDeliveries)
+	case interfaces.ModelIdSshKey:
+		return b.Process(m.SshKeys)
+	case interfaces.ModelIdScheduleSkip:
+		return b.Process(m.ScheduleSkips)
+	case interfaces.ModelIdCalendar:
+		return b.Process(m.Calendars)
 	default:
 		return list, errors.ErrorModelInvalidModelId
 	}
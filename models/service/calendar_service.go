@@ -0,0 +1,36 @@
+package service
+
+import (
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	models2 "github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func convertTypeCalendar(d interface{}, err error) (res *models2.Calendar, err2 error) {
+	if err != nil {
+		return nil, err
+	}
+	res, ok := d.(*models2.Calendar)
+	if !ok {
+		return nil, errors.ErrorModelInvalidType
+	}
+	return res, nil
+}
+
+func (svc *Service) GetCalendarById(id primitive.ObjectID) (res *models2.Calendar, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdCalendar).GetById(id)
+	return convertTypeCalendar(d, err)
+}
+
+func (svc *Service) GetCalendar(query bson.M, opts *mongo.FindOptions) (res *models2.Calendar, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdCalendar).Get(query, opts)
+	return convertTypeCalendar(d, err)
+}
+
+func (svc *Service) GetCalendarList(query bson.M, opts *mongo.FindOptions) (res []models2.Calendar, err error) {
+	err = svc.getListSerializeTarget(interfaces.ModelIdCalendar, query, opts, &res)
+	return res, err
+}
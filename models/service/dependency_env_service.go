@@ -0,0 +1,36 @@
+package service
+
+import (
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	models2 "github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func convertTypeDependencyEnv(d interface{}, err error) (res *models2.DependencyEnv, err2 error) {
+	if err != nil {
+		return nil, err
+	}
+	res, ok := d.(*models2.DependencyEnv)
+	if !ok {
+		return nil, errors.ErrorModelInvalidType
+	}
+	return res, nil
+}
+
+func (svc *Service) GetDependencyEnvById(id primitive.ObjectID) (res *models2.DependencyEnv, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdDependencyEnv).GetById(id)
+	return convertTypeDependencyEnv(d, err)
+}
+
+func (svc *Service) GetDependencyEnv(query bson.M, opts *mongo.FindOptions) (res *models2.DependencyEnv, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdDependencyEnv).Get(query, opts)
+	return convertTypeDependencyEnv(d, err)
+}
+
+func (svc *Service) GetDependencyEnvList(query bson.M, opts *mongo.FindOptions) (res []models2.DependencyEnv, err error) {
+	err = svc.getListSerializeTarget(interfaces.ModelIdDependencyEnv, query, opts, &res)
+	return res, err
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	models2 "github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func convertTypeGitWebhookDelivery(d interface{}, err error) (res *models2.GitWebhookDelivery, err2 error) {
+	if err != nil {
+		return nil, err
+	}
+	res, ok := d.(*models2.GitWebhookDelivery)
+	if !ok {
+		return nil, errors.ErrorModelInvalidType
+	}
+	return res, nil
+}
+
+func (svc *Service) GetGitWebhookDeliveryById(id primitive.ObjectID) (res *models2.GitWebhookDelivery, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdGitWebhookDelivery).GetById(id)
+	return convertTypeGitWebhookDelivery(d, err)
+}
+
+func (svc *Service) GetGitWebhookDelivery(query bson.M, opts *mongo.FindOptions) (res *models2.GitWebhookDelivery, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdGitWebhookDelivery).Get(query, opts)
+	return convertTypeGitWebhookDelivery(d, err)
+}
+
+func (svc *Service) GetGitWebhookDeliveryList(query bson.M, opts *mongo.FindOptions) (res []models2.GitWebhookDelivery, err error) {
+	err = svc.getListSerializeTarget(interfaces.ModelIdGitWebhookDelivery, query, opts, &res)
+	return res, err
+}
@@ -87,5 +87,26 @@ type ModelService interface {
 	GetGitById(id primitive.ObjectID) (res *models.Git, err error)
 	GetGit(query bson.M, opts *mongo.FindOptions) (res *models.Git, err error)
 	GetGitList(query bson.M, opts *mongo.FindOptions) (res []models.Git, err error)
+	GetTaskMetricById(id primitive.ObjectID) (res *models.TaskMetric, err error)
+	GetTaskMetric(query bson.M, opts *mongo.FindOptions) (res *models.TaskMetric, err error)
+	GetTaskMetricList(query bson.M, opts *mongo.FindOptions) (res []models.TaskMetric, err error)
+	GetDependencyEnvById(id primitive.ObjectID) (res *models.DependencyEnv, err error)
+	GetDependencyEnv(query bson.M, opts *mongo.FindOptions) (res *models.DependencyEnv, err error)
+	GetDependencyEnvList(query bson.M, opts *mongo.FindOptions) (res []models.DependencyEnv, err error)
+	GetAuditLogById(id primitive.ObjectID) (res *models.AuditLog, err error)
+	GetAuditLog(query bson.M, opts *mongo.FindOptions) (res *models.AuditLog, err error)
+	GetAuditLogList(query bson.M, opts *mongo.FindOptions) (res []models.AuditLog, err error)
+	GetGitWebhookDeliveryById(id primitive.ObjectID) (res *models.GitWebhookDelivery, err error)
+	GetGitWebhookDelivery(query bson.M, opts *mongo.FindOptions) (res *models.GitWebhookDelivery, err error)
+	GetGitWebhookDeliveryList(query bson.M, opts *mongo.FindOptions) (res []models.GitWebhookDelivery, err error)
+	GetSshKeyById(id primitive.ObjectID) (res *models.SshKey, err error)
+	GetSshKey(query bson.M, opts *mongo.FindOptions) (res *models.SshKey, err error)
+	GetSshKeyList(query bson.M, opts *mongo.FindOptions) (res []models.SshKey, err error)
+	GetScheduleSkipById(id primitive.ObjectID) (res *models.ScheduleSkip, err error)
+	GetScheduleSkip(query bson.M, opts *mongo.FindOptions) (res *models.ScheduleSkip, err error)
+	GetScheduleSkipList(query bson.M, opts *mongo.FindOptions) (res []models.ScheduleSkip, err error)
+	GetCalendarById(id primitive.ObjectID) (res *models.Calendar, err error)
+	GetCalendar(query bson.M, opts *mongo.FindOptions) (res *models.Calendar, err error)
+	GetCalendarList(query bson.M, opts *mongo.FindOptions) (res []models.Calendar, err error)
 	DropAll() (err error)
 }
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	models2 "github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func convertTypeScheduleSkip(d interface{}, err error) (res *models2.ScheduleSkip, err2 error) {
+	if err != nil {
+		return nil, err
+	}
+	res, ok := d.(*models2.ScheduleSkip)
+	if !ok {
+		return nil, errors.ErrorModelInvalidType
+	}
+	return res, nil
+}
+
+func (svc *Service) GetScheduleSkipById(id primitive.ObjectID) (res *models2.ScheduleSkip, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdScheduleSkip).GetById(id)
+	return convertTypeScheduleSkip(d, err)
+}
+
+func (svc *Service) GetScheduleSkip(query bson.M, opts *mongo.FindOptions) (res *models2.ScheduleSkip, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdScheduleSkip).Get(query, opts)
+	return convertTypeScheduleSkip(d, err)
+}
+
+func (svc *Service) GetScheduleSkipList(query bson.M, opts *mongo.FindOptions) (res []models2.ScheduleSkip, err error) {
+	err = svc.getListSerializeTarget(interfaces.ModelIdScheduleSkip, query, opts, &res)
+	return res, err
+}
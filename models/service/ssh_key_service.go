@@ -0,0 +1,36 @@
+package service
+
+import (
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	models2 "github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func convertTypeSshKey(d interface{}, err error) (res *models2.SshKey, err2 error) {
+	if err != nil {
+		return nil, err
+	}
+	res, ok := d.(*models2.SshKey)
+	if !ok {
+		return nil, errors.ErrorModelInvalidType
+	}
+	return res, nil
+}
+
+func (svc *Service) GetSshKeyById(id primitive.ObjectID) (res *models2.SshKey, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdSshKey).GetById(id)
+	return convertTypeSshKey(d, err)
+}
+
+func (svc *Service) GetSshKey(query bson.M, opts *mongo.FindOptions) (res *models2.SshKey, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdSshKey).Get(query, opts)
+	return convertTypeSshKey(d, err)
+}
+
+func (svc *Service) GetSshKeyList(query bson.M, opts *mongo.FindOptions) (res []models2.SshKey, err error) {
+	err = svc.getListSerializeTarget(interfaces.ModelIdSshKey, query, opts, &res)
+	return res, err
+}
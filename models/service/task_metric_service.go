@@ -0,0 +1,36 @@
+package service
+
+import (
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	models2 "github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func convertTypeTaskMetric(d interface{}, err error) (res *models2.TaskMetric, err2 error) {
+	if err != nil {
+		return nil, err
+	}
+	res, ok := d.(*models2.TaskMetric)
+	if !ok {
+		return nil, errors.ErrorModelInvalidType
+	}
+	return res, nil
+}
+
+func (svc *Service) GetTaskMetricById(id primitive.ObjectID) (res *models2.TaskMetric, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdTaskMetric).GetById(id)
+	return convertTypeTaskMetric(d, err)
+}
+
+func (svc *Service) GetTaskMetric(query bson.M, opts *mongo.FindOptions) (res *models2.TaskMetric, err error) {
+	d, err := svc.GetBaseService(interfaces.ModelIdTaskMetric).Get(query, opts)
+	return convertTypeTaskMetric(d, err)
+}
+
+func (svc *Service) GetTaskMetricList(query bson.M, opts *mongo.FindOptions) (res []models2.TaskMetric, err error) {
+	err = svc.getListSerializeTarget(interfaces.ModelIdTaskMetric, query, opts, &res)
+	return res, err
+}
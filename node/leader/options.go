@@ -0,0 +1,32 @@
+package leader
+
+import (
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"time"
+)
+
+type Option func(svc interfaces.NodeLeaderService)
+
+func WithConfigPath(path string) Option {
+	return func(svc interfaces.NodeLeaderService) {
+		svc.SetConfigPath(path)
+	}
+}
+
+func WithNodeKey(key string) Option {
+	return func(svc interfaces.NodeLeaderService) {
+		svc.SetNodeKey(key)
+	}
+}
+
+func WithLeaseDuration(duration time.Duration) Option {
+	return func(svc interfaces.NodeLeaderService) {
+		svc.SetLeaseDuration(duration)
+	}
+}
+
+func WithRenewInterval(interval time.Duration) Option {
+	return func(svc interfaces.NodeLeaderService) {
+		svc.SetRenewInterval(interval)
+	}
+}
@@ -0,0 +1,350 @@
+package leader
+
+import (
+	"context"
+	"github.com/apex/log"
+	"github.com/crawlab-team/go-trace"
+	config2 "github.com/doubletrey/crawlab-core/config"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/node/config"
+	"github.com/doubletrey/crawlab-core/utils"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/dig"
+	"sync"
+	"time"
+)
+
+const (
+	leaseColName = "node_leader_leases"
+	leaseId      = "master"
+)
+
+// Service leader election among masters with a lease in db. The leader keeps
+// renewing the lease before expiry, and any other master takes it over once
+// it has expired, where the fencing token is incremented on every takeover
+// so that writes of a former leader can be rejected
+type Service struct {
+	// dependencies
+	cfgSvc interfaces.NodeConfigService
+
+	// settings
+	cfgPath       string
+	nodeKey       string
+	leaseDuration time.Duration
+	renewInterval time.Duration
+
+	// internals
+	col      *mongo2.Collection
+	token    int64
+	expireTs time.Time
+	stopped  bool
+	alive    bool         // whether the lease is being kept alive in background
+	mu       sync.RWMutex // lock of lease state
+	opMu     sync.Mutex   // lock of lease operations in db
+}
+
+func (svc *Service) GetConfigPath() (path string) {
+	return svc.cfgPath
+}
+
+func (svc *Service) SetConfigPath(path string) {
+	svc.cfgPath = path
+}
+
+func (svc *Service) GetNodeKey() (key string) {
+	return svc.nodeKey
+}
+
+func (svc *Service) SetNodeKey(key string) {
+	svc.nodeKey = key
+}
+
+func (svc *Service) GetLeaseDuration() (duration time.Duration) {
+	return svc.leaseDuration
+}
+
+func (svc *Service) SetLeaseDuration(duration time.Duration) {
+	svc.leaseDuration = duration
+}
+
+func (svc *Service) GetRenewInterval() (interval time.Duration) {
+	return svc.renewInterval
+}
+
+func (svc *Service) SetRenewInterval(interval time.Duration) {
+	svc.renewInterval = interval
+}
+
+func (svc *Service) Init() (err error) {
+	return nil
+}
+
+// Start try to acquire the lease at once, then keep renewing it or taking it
+// over from an expired leader in background
+func (svc *Service) Start() {
+	svc.opMu.Lock()
+	defer svc.opMu.Unlock()
+	svc.stopped = false
+	if err := svc.acquire(); err != nil {
+		trace.PrintError(err)
+	}
+	if !svc.alive {
+		svc.alive = true
+		go svc.keepAlive()
+	}
+}
+
+func (svc *Service) Wait() {
+	utils.DefaultWait()
+	svc.Stop()
+}
+
+// Stop renewing the lease and release it, so that another master can take
+// over without waiting for expiry
+func (svc *Service) Stop() {
+	svc.opMu.Lock()
+	defer svc.opMu.Unlock()
+	svc.stopped = true
+	if err := svc.release(); err != nil {
+		trace.PrintError(err)
+	}
+}
+
+func (svc *Service) IsLeader() (ok bool) {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+	return svc.token > 0 && time.Now().Before(svc.expireTs)
+}
+
+func (svc *Service) GetToken() (token int64) {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+	if svc.token == 0 || !time.Now().Before(svc.expireTs) {
+		return 0
+	}
+	return svc.token
+}
+
+func (svc *Service) CheckToken(token int64) (err error) {
+	if token == 0 {
+		return trace.TraceError(errors.ErrorNodeNotLeader)
+	}
+	count, err := svc.col.CountDocuments(context.Background(), bson.M{
+		"_id":       leaseId,
+		"token":     token,
+		"expire_ts": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return trace.TraceError(err)
+	}
+	if count == 0 {
+		return trace.TraceError(errors.ErrorNodeStaleLeaderToken)
+	}
+	return nil
+}
+
+func (svc *Service) GetLeader() (l *interfaces.NodeLeaderLease, err error) {
+	l = &interfaces.NodeLeaderLease{}
+	if err := svc.col.FindOne(context.Background(), bson.M{"_id": leaseId}).Decode(l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (svc *Service) keepAlive() {
+	for {
+		time.Sleep(svc.renewInterval)
+
+		svc.opMu.Lock()
+		if svc.stopped {
+			svc.alive = false
+			svc.opMu.Unlock()
+			return
+		}
+		if err := svc.acquire(); err != nil {
+			trace.PrintError(err)
+		}
+		svc.opMu.Unlock()
+	}
+}
+
+// acquire renew the lease held by the master, or take it over if it has
+// expired or was held by the same node before, e.g. prior to a restart
+func (svc *Service) acquire() (err error) {
+	ctx := context.Background()
+	now := time.Now()
+	expireTs := now.Add(svc.leaseDuration)
+
+	// renew
+	svc.mu.RLock()
+	token := svc.token
+	svc.mu.RUnlock()
+	if token > 0 {
+		err := svc.col.FindOneAndUpdate(ctx, bson.M{
+			"_id":      leaseId,
+			"node_key": svc.nodeKey,
+			"token":    token,
+		}, bson.M{
+			"$set": bson.M{
+				"expire_ts": expireTs,
+				"renew_ts":  now,
+			},
+		}).Err()
+		if err == nil {
+			svc.setLease(token, expireTs)
+			return nil
+		}
+		if err != mongo2.ErrNoDocuments {
+			// keep the lease until expiry as it may be renewed next time
+			return trace.TraceError(err)
+		}
+		svc.setLease(0, time.Time{})
+	}
+
+	// take over
+	l := &interfaces.NodeLeaderLease{}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	if err := svc.col.FindOneAndUpdate(ctx, bson.M{
+		"_id": leaseId,
+		"$or": bson.A{
+			bson.M{"expire_ts": bson.M{"$lt": now}},
+			bson.M{"node_key": svc.nodeKey},
+		},
+	}, bson.M{
+		"$set": bson.M{
+			"node_key":  svc.nodeKey,
+			"expire_ts": expireTs,
+			"renew_ts":  now,
+		},
+		"$inc": bson.M{
+			"token": 1,
+		},
+	}, opts).Decode(l); err != nil {
+		svc.setLease(0, time.Time{})
+		if mongo2.IsDuplicateKeyError(err) {
+			// held by another master
+			return nil
+		}
+		return trace.TraceError(err)
+	}
+	svc.setLease(l.Token, expireTs)
+	return nil
+}
+
+// release expire the lease if it is held by the master
+func (svc *Service) release() (err error) {
+	svc.mu.RLock()
+	token := svc.token
+	svc.mu.RUnlock()
+	if token == 0 {
+		return nil
+	}
+	svc.setLease(0, time.Time{})
+	if _, err := svc.col.UpdateOne(context.Background(), bson.M{
+		"_id":   leaseId,
+		"token": token,
+	}, bson.M{
+		"$set": bson.M{
+			"expire_ts": time.Now(),
+		},
+	}); err != nil {
+		return trace.TraceError(err)
+	}
+	log.Infof("master[%s] released leadership (token: %d)", svc.nodeKey, token)
+	return nil
+}
+
+func (svc *Service) setLease(token int64, expireTs time.Time) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if token != svc.token {
+		if token > 0 {
+			log.Infof("master[%s] became leader (token: %d)", svc.nodeKey, token)
+		} else {
+			log.Infof("master[%s] lost leadership (token: %d)", svc.nodeKey, svc.token)
+		}
+	}
+	svc.token = token
+	svc.expireTs = expireTs
+}
+
+func NewNodeLeaderService(opts ...Option) (svc2 interfaces.NodeLeaderService, err error) {
+	// service
+	svc := &Service{
+		cfgPath:       config2.DefaultConfigPath,
+		leaseDuration: 15 * time.Second,
+		renewInterval: 5 * time.Second,
+	}
+
+	// lease duration and renew interval
+	if viper.GetInt("node.leader.leaseDuration") > 0 {
+		svc.leaseDuration = time.Duration(viper.GetInt("node.leader.leaseDuration")) * time.Second
+	}
+	if viper.GetInt("node.leader.renewInterval") > 0 {
+		svc.renewInterval = time.Duration(viper.GetInt("node.leader.renewInterval")) * time.Second
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	// dependency injection
+	c := dig.New()
+	if err := c.Provide(config.ProvideConfigService(svc.cfgPath)); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Invoke(func(cfgSvc interfaces.NodeConfigService) {
+		svc.cfgSvc = cfgSvc
+	}); err != nil {
+		return nil, trace.TraceError(err)
+	}
+
+	// node key
+	if svc.nodeKey == "" {
+		svc.nodeKey = svc.cfgSvc.GetNodeKey()
+	}
+
+	// lease collection
+	svc.col = mongo.GetMongoDb("").Collection(leaseColName)
+
+	// initialize
+	if err := svc.Init(); err != nil {
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+var store = sync.Map{}
+
+func GetNodeLeaderService(path string, opts ...Option) (svc interfaces.NodeLeaderService, err error) {
+	if path == "" {
+		path = config2.DefaultConfigPath
+	}
+	res, ok := store.Load(path)
+	if ok {
+		svc, ok = res.(interfaces.NodeLeaderService)
+		if ok {
+			return svc, nil
+		}
+	}
+	opts = append(opts, WithConfigPath(path))
+	svc, err = NewNodeLeaderService(opts...)
+	if err != nil {
+		return nil, err
+	}
+	store.Store(path, svc)
+	return svc, nil
+}
+
+func ProvideGetNodeLeaderService(path string, opts ...Option) func() (svc interfaces.NodeLeaderService, err error) {
+	return func() (svc interfaces.NodeLeaderService, err error) {
+		return GetNodeLeaderService(path, opts...)
+	}
+}
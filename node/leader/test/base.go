@@ -0,0 +1,71 @@
+package test
+
+import (
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/node/leader"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"testing"
+	"time"
+)
+
+func init() {
+	var err error
+	T, err = NewTest()
+	if err != nil {
+		panic(err)
+	}
+}
+
+var T *Test
+
+// Test two masters in the same process, which compete for the lease
+type Test struct {
+	// dependencies
+	leaderSvc1 interfaces.NodeLeaderService
+	leaderSvc2 interfaces.NodeLeaderService
+
+	// settings
+	LeaseDuration time.Duration
+	RenewInterval time.Duration
+}
+
+func (t *Test) Setup(t2 *testing.T) {
+	t.Cleanup()
+	t.leaderSvc1.SetRenewInterval(t.RenewInterval)
+	t.leaderSvc2.SetRenewInterval(t.RenewInterval)
+	t2.Cleanup(t.Cleanup)
+}
+
+func (t *Test) Cleanup() {
+	t.leaderSvc1.Stop()
+	t.leaderSvc2.Stop()
+	_ = mongo.GetMongoDb("").Collection("node_leader_leases").Drop(nil)
+}
+
+func NewTest() (t *Test, err error) {
+	// test
+	t = &Test{
+		LeaseDuration: 3 * time.Second,
+		RenewInterval: 1 * time.Second,
+	}
+
+	// masters
+	t.leaderSvc1, err = leader.NewNodeLeaderService(
+		leader.WithNodeKey("test_master_1"),
+		leader.WithLeaseDuration(t.LeaseDuration),
+		leader.WithRenewInterval(t.RenewInterval),
+	)
+	if err != nil {
+		return nil, err
+	}
+	t.leaderSvc2, err = leader.NewNodeLeaderService(
+		leader.WithNodeKey("test_master_2"),
+		leader.WithLeaseDuration(t.LeaseDuration),
+		leader.WithRenewInterval(t.RenewInterval),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestLeaderService_Elect(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	T.leaderSvc1.Start()
+	T.leaderSvc2.Start()
+
+	// only the first master is the leader
+	require.True(t, T.leaderSvc1.IsLeader())
+	require.False(t, T.leaderSvc2.IsLeader())
+	require.Greater(t, T.leaderSvc1.GetToken(), int64(0))
+	require.Equal(t, int64(0), T.leaderSvc2.GetToken())
+	l, err := T.leaderSvc2.GetLeader()
+	require.Nil(t, err)
+	require.Equal(t, "test_master_1", l.NodeKey)
+	require.Equal(t, T.leaderSvc1.GetToken(), l.Token)
+
+	// lease is renewed beyond its original expiry
+	time.Sleep(2 * T.LeaseDuration)
+	require.True(t, T.leaderSvc1.IsLeader())
+	require.False(t, T.leaderSvc2.IsLeader())
+	err = T.leaderSvc1.CheckToken(T.leaderSvc1.GetToken())
+	require.Nil(t, err)
+}
+
+func TestLeaderService_Release(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	T.leaderSvc1.Start()
+	T.leaderSvc2.Start()
+	require.True(t, T.leaderSvc1.IsLeader())
+
+	// second master takes over on its next renewal once the lease is released
+	T.leaderSvc1.Stop()
+	require.False(t, T.leaderSvc1.IsLeader())
+	time.Sleep(2 * T.RenewInterval)
+	require.True(t, T.leaderSvc2.IsLeader())
+	l, err := T.leaderSvc1.GetLeader()
+	require.Nil(t, err)
+	require.Equal(t, "test_master_2", l.NodeKey)
+}
+
+func TestLeaderService_Takeover(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	T.leaderSvc1.Start()
+	T.leaderSvc2.Start()
+	require.True(t, T.leaderSvc1.IsLeader())
+	token1 := T.leaderSvc1.GetToken()
+
+	// first master fails without releasing the lease, which stops renewing
+	// until the end of tests
+	T.leaderSvc1.SetRenewInterval(time.Hour)
+	time.Sleep(2 * T.LeaseDuration)
+
+	// second master takes over after expiry with a greater token
+	require.False(t, T.leaderSvc1.IsLeader())
+	require.True(t, T.leaderSvc2.IsLeader())
+	token2 := T.leaderSvc2.GetToken()
+	require.Greater(t, token2, token1)
+
+	// writes with the token of the former leader are fenced off
+	err = T.leaderSvc1.CheckToken(token1)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), errors.ErrorNodeStaleLeaderToken.Error())
+	err = T.leaderSvc2.CheckToken(token2)
+	require.Nil(t, err)
+}
@@ -14,8 +14,11 @@ import (
 	"github.com/doubletrey/crawlab-core/models/models"
 	"github.com/doubletrey/crawlab-core/models/service"
 	"github.com/doubletrey/crawlab-core/node/config"
+	"github.com/doubletrey/crawlab-core/node/leader"
 	"github.com/doubletrey/crawlab-core/plugin"
+	"github.com/doubletrey/crawlab-core/recycle"
 	"github.com/doubletrey/crawlab-core/schedule"
+	"github.com/doubletrey/crawlab-core/spider/git"
 	"github.com/doubletrey/crawlab-core/task/handler"
 	"github.com/doubletrey/crawlab-core/task/scheduler"
 	"github.com/doubletrey/crawlab-core/utils"
@@ -35,6 +38,9 @@ type MasterService struct {
 	handlerSvc   interfaces.TaskHandlerService
 	scheduleSvc  interfaces.ScheduleService
 	pluginSvc    interfaces.PluginService
+	recycleSvc   interfaces.RecycleService
+	gitSvc       interfaces.SpiderGitService
+	leaderSvc    interfaces.NodeLeaderService
 
 	// settings
 	cfgPath         string
@@ -62,6 +68,10 @@ func (svc *MasterService) Start() {
 		panic(err)
 	}
 
+	// start leader election, which is to be started before services acting
+	// only on the leader
+	svc.leaderSvc.Start()
+
 	// start monitoring worker nodes
 	go svc.Monitor()
 
@@ -77,6 +87,12 @@ func (svc *MasterService) Start() {
 	// start plugin service
 	go svc.pluginSvc.Start()
 
+	// start recycle service
+	go svc.recycleSvc.Start()
+
+	// start spider git auto sync
+	go svc.gitSvc.Start()
+
 	// wait for quit signal
 	svc.Wait()
 
@@ -89,6 +105,7 @@ func (svc *MasterService) Wait() {
 }
 
 func (svc *MasterService) Stop() {
+	svc.leaderSvc.Stop()
 	_ = svc.server.Stop()
 	log.Infof("master[%s] service has stopped", svc.GetConfigService().GetNodeKey())
 }
@@ -191,6 +208,12 @@ func (svc *MasterService) monitor() (err error) {
 		return err
 	}
 
+	// only the leader among masters monitors worker nodes, which are
+	// connected to the leader
+	if !svc.leaderSvc.IsLeader() {
+		return nil
+	}
+
 	// all worker nodes
 	query := bson.M{
 		"key":    bson.M{"$ne": svc.cfgSvc.GetNodeKey()}, // not self
@@ -295,6 +318,15 @@ func NewMasterService(opts ...Option) (res interfaces.NodeMasterService, err err
 	if err := c.Provide(plugin.ProvideGetPluginService(svc.cfgPath)); err != nil {
 		return nil, err
 	}
+	if err := c.Provide(recycle.ProvideGetRecycleService(svc.cfgPath)); err != nil {
+		return nil, err
+	}
+	if err := c.Provide(git.ProvideGetSpiderGitService(svc.cfgPath)); err != nil {
+		return nil, err
+	}
+	if err := c.Provide(leader.ProvideGetNodeLeaderService(svc.cfgPath)); err != nil {
+		return nil, err
+	}
 	if err := c.Invoke(func(
 		cfgSvc interfaces.NodeConfigService,
 		modelSvc service.ModelService,
@@ -303,6 +335,9 @@ func NewMasterService(opts ...Option) (res interfaces.NodeMasterService, err err
 		handlerSvc interfaces.TaskHandlerService,
 		scheduleSvc interfaces.ScheduleService,
 		pluginSvc interfaces.PluginService,
+		recycleSvc interfaces.RecycleService,
+		gitSvc interfaces.SpiderGitService,
+		leaderSvc interfaces.NodeLeaderService,
 	) {
 		svc.cfgSvc = cfgSvc
 		svc.modelSvc = modelSvc
@@ -311,6 +346,9 @@ func NewMasterService(opts ...Option) (res interfaces.NodeMasterService, err err
 		svc.handlerSvc = handlerSvc
 		svc.scheduleSvc = scheduleSvc
 		svc.pluginSvc = pluginSvc
+		svc.recycleSvc = recycleSvc
+		svc.gitSvc = gitSvc
+		svc.leaderSvc = leaderSvc
 	}); err != nil {
 		return nil, err
 	}
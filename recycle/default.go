@@ -0,0 +1,5 @@
+package recycle
+
+const (
+	DefaultRetentionDays = 30
+)
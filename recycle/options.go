@@ -0,0 +1,26 @@
+package recycle
+
+import (
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"time"
+)
+
+type Option func(svc interfaces.RecycleService)
+
+func WithConfigPath(path string) Option {
+	return func(svc interfaces.RecycleService) {
+		svc.SetConfigPath(path)
+	}
+}
+
+func WithRetention(retention time.Duration) Option {
+	return func(svc interfaces.RecycleService) {
+		svc.SetRetention(retention)
+	}
+}
+
+func WithPurgeInterval(interval time.Duration) Option {
+	return func(svc interfaces.RecycleService) {
+		svc.SetPurgeInterval(interval)
+	}
+}
@@ -0,0 +1,404 @@
+package recycle
+
+import (
+	"github.com/apex/log"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/audit"
+	"github.com/doubletrey/crawlab-core/config"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/doubletrey/crawlab-core/node/leader"
+	"github.com/doubletrey/crawlab-core/schedule"
+	"github.com/doubletrey/crawlab-core/spider/fs"
+	"github.com/doubletrey/crawlab-core/utils"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/dig"
+	"os"
+	"sync"
+	"time"
+)
+
+// deletedArtifact artifact of a deleted document
+type deletedArtifact struct {
+	Id  primitive.ObjectID `bson:"_id"`
+	Col string             `bson:"_col"`
+	Sys models.ArtifactSys `bson:"_sys"`
+	Obj bson.M             `bson:"_obj"`
+}
+
+// Service recycle bin of deleted documents, which are kept in artifacts
+// until restored or purged after retention period
+type Service struct {
+	// dependencies
+	modelSvc    service.ModelService
+	scheduleSvc interfaces.ScheduleService
+	leaderSvc   interfaces.NodeLeaderService
+
+	// settings
+	cfgPath       string
+	retention     time.Duration
+	purgeInterval time.Duration
+
+	// internals
+	col     *mongo.Col
+	stopped bool
+}
+
+func (svc *Service) GetConfigPath() (path string) {
+	return svc.cfgPath
+}
+
+func (svc *Service) SetConfigPath(path string) {
+	svc.cfgPath = path
+}
+
+func (svc *Service) GetRetention() (retention time.Duration) {
+	return svc.retention
+}
+
+func (svc *Service) SetRetention(retention time.Duration) {
+	svc.retention = retention
+}
+
+func (svc *Service) GetPurgeInterval() (interval time.Duration) {
+	return svc.purgeInterval
+}
+
+func (svc *Service) SetPurgeInterval(interval time.Duration) {
+	svc.purgeInterval = interval
+}
+
+func (svc *Service) Init() (err error) {
+	return nil
+}
+
+func (svc *Service) Start() {
+	for {
+		if svc.stopped {
+			return
+		}
+
+		// only the leader among masters purges expired documents
+		if svc.leaderSvc.IsLeader() {
+			if err := svc.PurgeExpired(); err != nil {
+				trace.PrintError(err)
+			}
+		}
+
+		time.Sleep(svc.purgeInterval)
+	}
+}
+
+func (svc *Service) Wait() {
+	utils.DefaultWait()
+	svc.Stop()
+}
+
+func (svc *Service) Stop() {
+	svc.stopped = true
+}
+
+// GetList deleted documents matching the query on artifacts
+func (svc *Service) GetList(query bson.M, opts *mongo.FindOptions) (res []bson.M, total int, err error) {
+	query = svc.getDeletedQuery(query)
+	if opts == nil {
+		opts = &mongo.FindOptions{}
+	}
+	if opts.Sort == nil {
+		opts.Sort = bson.D{{Key: "_sys.delete_ts", Value: -1}}
+	}
+	if err := svc.col.Find(query, opts).All(&res); err != nil {
+		if err != mongo2.ErrNoDocuments {
+			return nil, 0, trace.TraceError(err)
+		}
+	}
+	total, err = svc.col.Count(query)
+	if err != nil {
+		return nil, 0, trace.TraceError(err)
+	}
+	return res, total, nil
+}
+
+// Restore deleted document with given id back to its collection, together with
+// its tags kept in the artifact and related documents deleted along with it
+func (svc *Service) Restore(id primitive.ObjectID, args ...interface{}) (err error) {
+	a, err := svc.getDeletedArtifact(id)
+	if err != nil {
+		return err
+	}
+	if a.Col == "" || a.Obj == nil {
+		return trace.TraceError(errors.ErrorModelMissingRequiredData)
+	}
+
+	// document
+	if _, err := mongo.GetMongoCol(a.Col).Insert(a.Obj); err != nil {
+		if mongo2.IsDuplicateKeyError(err) {
+			return errors.ErrorModelAlreadyExists
+		}
+		return trace.TraceError(err)
+	}
+
+	// artifact
+	update := bson.M{
+		"_del":           false,
+		"_sys.update_ts": time.Now(),
+	}
+	if u := utils.GetUserFromArgs(args...); u != nil {
+		update["_sys.update_uid"] = u.GetId()
+	}
+	if err := svc.col.UpdateId(id, bson.M{
+		"$set":   update,
+		"$unset": bson.M{"_obj": "", "_sys.recycle_id": ""},
+	}); err != nil {
+		return trace.TraceError(err)
+	}
+
+	// audit
+	if err := audit.Record(a.Col, id, constants.AuditActionRestore, nil, a.Obj, utils.GetUserFromArgs(args...), utils.GetAuditSourceFromArgs(args...)); err != nil {
+		trace.PrintError(err)
+	}
+
+	// enabled schedule is added back to cron
+	if a.Col == interfaces.ModelColNameSchedule {
+		if err := svc.enableSchedule(id, args...); err != nil {
+			trace.PrintError(err)
+		}
+	}
+
+	// related documents
+	ids, err := svc.getRelatedIds(a)
+	if err != nil {
+		return err
+	}
+	for _, relatedId := range ids {
+		if err := svc.Restore(relatedId, args...); err != nil {
+			trace.PrintError(err)
+		}
+	}
+
+	return nil
+}
+
+// Purge deleted document with given id permanently, together with its
+// files if it is a spider and related documents deleted along with it
+func (svc *Service) Purge(id primitive.ObjectID) (err error) {
+	a, err := svc.getDeletedArtifact(id)
+	if err != nil {
+		return err
+	}
+
+	// related documents
+	ids, err := svc.getRelatedIds(a)
+	if err != nil {
+		return err
+	}
+	for _, relatedId := range ids {
+		if err := svc.Purge(relatedId); err != nil && err != mongo2.ErrNoDocuments {
+			trace.PrintError(err)
+		}
+	}
+
+	// spider files and stat, the latter of which is kept for restoring
+	if a.Col == interfaces.ModelColNameSpider {
+		svc.purgeSpiderFiles(id)
+		if err := mongo.GetMongoCol(interfaces.ModelColNameSpiderStat).DeleteId(id); err != nil && err != mongo2.ErrNoDocuments {
+			trace.PrintError(err)
+		}
+	}
+
+	// artifact
+	return svc.col.DeleteId(id)
+}
+
+// PurgeExpired purge deleted documents which have exceeded retention period
+func (svc *Service) PurgeExpired() (err error) {
+	if svc.retention <= 0 {
+		return nil
+	}
+
+	var artifacts []deletedArtifact
+	query := svc.getDeletedQuery(bson.M{
+		"_sys.delete_ts": bson.M{"$lt": time.Now().Add(-svc.retention)},
+	})
+	if err := svc.col.Find(query, nil).All(&artifacts); err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return nil
+		}
+		return trace.TraceError(err)
+	}
+
+	for _, a := range artifacts {
+		// may have been purged along with its parent
+		if err := svc.Purge(a.Id); err != nil && err != mongo2.ErrNoDocuments {
+			trace.PrintError(err)
+		}
+	}
+	if len(artifacts) > 0 {
+		log.Infof("purged %d expired deleted documents", len(artifacts))
+	}
+
+	return nil
+}
+
+func (svc *Service) getDeletedQuery(query bson.M) (res bson.M) {
+	res = bson.M{}
+	for k, v := range query {
+		res[k] = v
+	}
+	res["_del"] = true
+	return res
+}
+
+func (svc *Service) getDeletedArtifact(id primitive.ObjectID) (a *deletedArtifact, err error) {
+	a = &deletedArtifact{}
+	if err := svc.col.Find(svc.getDeletedQuery(bson.M{"_id": id}), nil).One(a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// getRelatedIds ids of deleted documents which were deleted along with the
+// document of given artifact, and are tagged with its id
+func (svc *Service) getRelatedIds(a *deletedArtifact) (ids []primitive.ObjectID, err error) {
+	var artifacts []deletedArtifact
+	if err := svc.col.Find(svc.getDeletedQuery(bson.M{"_sys.recycle_id": a.Id}), nil).All(&artifacts); err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, trace.TraceError(err)
+	}
+	for _, ra := range artifacts {
+		ids = append(ids, ra.Id)
+	}
+	return ids, nil
+}
+
+// enableSchedule add restored schedule to cron if it is enabled, where fires
+// while it was deleted are not regarded as missed
+func (svc *Service) enableSchedule(id primitive.ObjectID, args ...interface{}) (err error) {
+	s, err := svc.modelSvc.GetScheduleById(id)
+	if err != nil {
+		return err
+	}
+	if !s.Enabled {
+		return nil
+	}
+	s.Enabled = false
+	s.EntryId = -1
+	if err := svc.scheduleSvc.Enable(s, args...); err != nil {
+		if err == errors.ErrorScheduleInvalidRunAt {
+			// one-off schedule which would have fired while it was deleted
+			return svc.scheduleSvc.Disable(s, args...)
+		}
+		return err
+	}
+	return nil
+}
+
+// purgeSpiderFiles remove files of spider in fs as well as workspace and repo on
+// master, which have been kept while the spider is in recycle bin
+func (svc *Service) purgeSpiderFiles(id primitive.ObjectID) {
+	fsSvc, err := fs.GetSpiderFsService(id, fs.WithConfigPath(svc.cfgPath))
+	if err != nil {
+		trace.PrintError(err)
+		return
+	}
+	if err := fsSvc.Delete("/"); err != nil {
+		log.Warnf("unable to delete files of spider[%s]: %v", id.Hex(), err)
+	}
+	if ok, err := fsSvc.GetFsService().GetFs().Exists(fsSvc.GetRefFsPath("")); err == nil && ok {
+		if err := fsSvc.GetFsService().GetFs().DeleteDir(fsSvc.GetRefFsPath("")); err != nil {
+			log.Warnf("unable to delete files at commits of spider[%s]: %v", id.Hex(), err)
+		}
+	}
+	for _, p := range []string{fsSvc.GetWorkspacePath(), fsSvc.GetRepoPath()} {
+		if err := os.RemoveAll(p); err != nil {
+			trace.PrintError(err)
+		}
+	}
+}
+
+func NewRecycleService(opts ...Option) (svc2 interfaces.RecycleService, err error) {
+	// service
+	svc := &Service{
+		cfgPath:       config.DefaultConfigPath,
+		retention:     DefaultRetentionDays * 24 * time.Hour,
+		purgeInterval: 1 * time.Hour,
+		col:           mongo.GetMongoCol(interfaces.ModelColNameArtifact),
+	}
+
+	// retention
+	if viper.IsSet("recycle.retentionDays") {
+		svc.retention = time.Duration(viper.GetInt("recycle.retentionDays")) * 24 * time.Hour
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	// dependency injection
+	c := dig.New()
+	if err := c.Provide(service.GetService); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Provide(schedule.ProvideGetScheduleService(svc.cfgPath)); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Provide(leader.ProvideGetNodeLeaderService(svc.cfgPath)); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Invoke(func(
+		modelSvc service.ModelService,
+		scheduleSvc interfaces.ScheduleService,
+		leaderSvc interfaces.NodeLeaderService,
+	) {
+		svc.modelSvc = modelSvc
+		svc.scheduleSvc = scheduleSvc
+		svc.leaderSvc = leaderSvc
+	}); err != nil {
+		return nil, trace.TraceError(err)
+	}
+
+	// initialize
+	if err := svc.Init(); err != nil {
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+var store = sync.Map{}
+
+func GetRecycleService(path string, opts ...Option) (svc interfaces.RecycleService, err error) {
+	if path == "" {
+		path = config.DefaultConfigPath
+	}
+	res, ok := store.Load(path)
+	if ok {
+		svc, ok = res.(interfaces.RecycleService)
+		if ok {
+			return svc, nil
+		}
+	}
+	opts = append(opts, WithConfigPath(path))
+	svc, err = NewRecycleService(opts...)
+	if err != nil {
+		return nil, err
+	}
+	store.Store(path, svc)
+	return svc, nil
+}
+
+func ProvideGetRecycleService(path string, opts ...Option) func() (svc interfaces.RecycleService, err error) {
+	return func() (svc interfaces.RecycleService, err error) {
+		return GetRecycleService(path, opts...)
+	}
+}
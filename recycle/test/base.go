@@ -0,0 +1,60 @@
+package test
+
+import (
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/doubletrey/crawlab-core/recycle"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/dig"
+	"testing"
+)
+
+func init() {
+	var err error
+	T, err = NewTest()
+	if err != nil {
+		panic(err)
+	}
+}
+
+var T *Test
+
+type Test struct {
+	// dependencies
+	modelSvc   service.ModelService
+	recycleSvc interfaces.RecycleService
+}
+
+func (t *Test) Setup(t2 *testing.T) {
+	t.Cleanup()
+	t2.Cleanup(t.Cleanup)
+}
+
+func (t *Test) Cleanup() {
+	_ = t.modelSvc.GetBaseService(interfaces.ModelIdSpider).ForceDeleteList(nil)
+	_ = t.modelSvc.GetBaseService(interfaces.ModelIdSchedule).ForceDeleteList(nil)
+	_ = t.modelSvc.GetBaseService(interfaces.ModelIdTag).ForceDeleteList(nil)
+	_ = t.modelSvc.GetBaseService(interfaces.ModelIdArtifact).ForceDeleteList(bson.M{})
+}
+
+func NewTest() (t *Test, err error) {
+	// test
+	t = &Test{}
+
+	// dependency injection
+	c := dig.New()
+	if err := c.Provide(service.GetService); err != nil {
+		return nil, err
+	}
+	if err := c.Provide(recycle.NewRecycleService); err != nil {
+		return nil, err
+	}
+	if err := c.Invoke(func(modelSvc service.ModelService, recycleSvc interfaces.RecycleService) {
+		t.modelSvc = modelSvc
+		t.recycleSvc = recycleSvc
+	}); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/delegate"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+func TestRecycleService_Restore(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// spider with tags and schedule
+	s := &models.Spider{
+		Name: "test_spider",
+		Tags: []models.Tag{{Name: "test_tag"}},
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	sch := &models.Schedule{
+		Name:     "test_schedule",
+		SpiderId: s.Id,
+		Cron:     "* * * * *",
+		Enabled:  true,
+	}
+	err = delegate.NewModelDelegate(sch).Add()
+	require.Nil(t, err)
+	sch3 := &models.Schedule{
+		Name:     "test_schedule_3",
+		SpiderId: s.Id,
+		Cron:     "* * * * *",
+	}
+	err = delegate.NewModelDelegate(sch3).Add()
+	require.Nil(t, err)
+
+	// delete, where the schedule is deleted along with the spider and the
+	// other schedule is deleted on its own
+	err = delegate.NewModelDelegate(sch3).Delete()
+	require.Nil(t, err)
+	err = delegate.NewModelDelegate(s).Delete()
+	require.Nil(t, err)
+	err = delegate.NewModelDelegate(sch).Delete()
+	require.Nil(t, err)
+	err = mongo.GetMongoCol(interfaces.ModelColNameArtifact).UpdateId(sch.Id, bson.M{
+		"$set": bson.M{"_sys.recycle_id": s.Id},
+	})
+	require.Nil(t, err)
+
+	// recycle bin
+	list, total, err := T.recycleSvc.GetList(bson.M{"_col": interfaces.ModelColNameSpider}, nil)
+	require.Nil(t, err)
+	require.Equal(t, 1, total)
+	require.Equal(t, s.Id, list[0]["_id"])
+
+	// restore
+	err = T.recycleSvc.Restore(s.Id)
+	require.Nil(t, err)
+	s2, err := T.modelSvc.GetSpiderById(s.Id)
+	require.Nil(t, err)
+	require.Equal(t, s.Name, s2.Name)
+	require.Equal(t, 1, len(s2.Tags))
+	require.Equal(t, "test_tag", s2.Tags[0].Name)
+	sch2, err := T.modelSvc.GetScheduleById(sch.Id)
+	require.Nil(t, err)
+	require.Equal(t, sch.Name, sch2.Name)
+	require.True(t, sch2.Enabled)
+	require.NotEqual(t, sch.EntryId, sch2.EntryId)
+	l, err := T.modelSvc.GetAuditLog(bson.M{"resource_id": s.Id, "action": constants.AuditActionRestore}, nil)
+	require.Nil(t, err)
+	require.Equal(t, interfaces.ModelColNameSpider, l.Col)
+	list, total, err = T.recycleSvc.GetList(nil, nil)
+	require.Nil(t, err)
+	require.Equal(t, 1, total)
+	require.Equal(t, sch3.Id, list[0]["_id"])
+}
+
+func TestRecycleService_PurgeExpired(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	s := &models.Spider{Name: "test_spider"}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	err = delegate.NewModelDelegate(s).Delete()
+	require.Nil(t, err)
+
+	// not expired
+	err = T.recycleSvc.PurgeExpired()
+	require.Nil(t, err)
+	_, total, err := T.recycleSvc.GetList(nil, nil)
+	require.Nil(t, err)
+	require.Equal(t, 1, total)
+
+	// expired
+	retention := T.recycleSvc.GetRetention()
+	T.recycleSvc.SetRetention(time.Millisecond)
+	defer T.recycleSvc.SetRetention(retention)
+	time.Sleep(10 * time.Millisecond)
+	err = T.recycleSvc.PurgeExpired()
+	require.Nil(t, err)
+	_, total, err = T.recycleSvc.GetList(nil, nil)
+	require.Nil(t, err)
+	require.Equal(t, 0, total)
+}
@@ -105,6 +105,9 @@ func registerRoutesAnonymousGroup(svc *RouterService, groups *RouterGroups) {
 
 	// system info
 	svc.RegisterActionControllerToGroup(groups.AnonymousGroup, "/system-info", controllers.SystemInfoController)
+
+	// git webhook, which is verified by webhook secret
+	svc.RegisterActionControllerToGroup(groups.AnonymousGroup, "/webhooks/git", controllers.GitWebhookController)
 }
 
 func registerRoutesAuthGroup(svc *RouterService, groups *RouterGroups) {
@@ -112,7 +115,7 @@ func registerRoutesAuthGroup(svc *RouterService, groups *RouterGroups) {
 	svc.RegisterListControllerToGroup(groups.AuthGroup, "/nodes", controllers.NodeController)
 
 	// project
-	svc.RegisterListControllerToGroup(groups.AuthGroup, "/projects", controllers.ProjectController)
+	svc.RegisterListActionControllerToGroup(groups.AuthGroup, "/projects", controllers.ProjectController)
 
 	// user
 	svc.RegisterListActionControllerToGroup(groups.AuthGroup, "/users", controllers.UserController)
@@ -144,6 +147,9 @@ func registerRoutesAuthGroup(svc *RouterService, groups *RouterGroups) {
 	// schedule
 	svc.RegisterListActionControllerToGroup(groups.AuthGroup, "/schedules", controllers.ScheduleController)
 
+	// calendar
+	svc.RegisterListControllerToGroup(groups.AuthGroup, "/calendars", controllers.CalendarController)
+
 	// stats
 	svc.RegisterActionControllerToGroup(groups.AuthGroup, "/stats", controllers.StatsController)
 
@@ -155,6 +161,18 @@ func registerRoutesAuthGroup(svc *RouterService, groups *RouterGroups) {
 
 	// git
 	svc.RegisterListControllerToGroup(groups.AuthGroup, "/gits", controllers.GitController)
+
+	// recycle bin
+	svc.RegisterActionControllerToGroup(groups.AuthGroup, "/recycle-bin", controllers.RecycleBinController)
+
+	// audit
+	svc.RegisterActionControllerToGroup(groups.AuthGroup, "/audit", controllers.AuditController)
+
+	// git webhook deliveries
+	svc.RegisterActionControllerToGroup(groups.AuthGroup, "/webhooks/git/deliveries", controllers.GitWebhookDeliveryController)
+
+	// ssh keys
+	svc.RegisterActionControllerToGroup(groups.AuthGroup, "/ssh-keys", controllers.SshKeyController)
 }
 
 func registerRoutesFilterGroup(svc *RouterService, groups *RouterGroups) {
@@ -0,0 +1,264 @@
+package schedule
+
+import (
+	"fmt"
+	"github.com/apex/log"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+const (
+	calendarDateLayout = "2006-01-02"
+	blackoutTimeLayout = "15:04"
+)
+
+// ValidateCalendarDates check if the dates are valid dates or ranges of dates
+func ValidateCalendarDates(dates []models.CalendarDate) (err error) {
+	for _, d := range dates {
+		if _, err := time.Parse(calendarDateLayout, d.Date); err != nil {
+			return errors.NewScheduleError(fmt.Sprintf("%s: %s", errors.ErrorScheduleInvalidCalendarDate.Error(), d.Date))
+		}
+		if d.End == "" {
+			continue
+		}
+		if _, err := time.Parse(calendarDateLayout, d.End); err != nil || d.End < d.Date {
+			return errors.NewScheduleError(fmt.Sprintf("%s: %s", errors.ErrorScheduleInvalidCalendarDate.Error(), d.End))
+		}
+	}
+	return nil
+}
+
+// ValidateBlackouts check if start and end times as well as weekdays of the
+// blackout windows are valid
+func ValidateBlackouts(blackouts []models.ScheduleBlackout) (err error) {
+	for _, b := range blackouts {
+		if _, err := time.Parse(blackoutTimeLayout, b.Start); err != nil {
+			return errors.NewScheduleError(fmt.Sprintf("%s: %s", errors.ErrorScheduleInvalidBlackout.Error(), b.Start))
+		}
+		if _, err := time.Parse(blackoutTimeLayout, b.End); err != nil {
+			return errors.NewScheduleError(fmt.Sprintf("%s: %s", errors.ErrorScheduleInvalidBlackout.Error(), b.End))
+		}
+		for _, wd := range b.Weekdays {
+			if wd < 0 || wd > 6 {
+				return errors.NewScheduleError(fmt.Sprintf("%s: weekday %d", errors.ErrorScheduleInvalidBlackout.Error(), wd))
+			}
+		}
+	}
+	return nil
+}
+
+// IsValidBlackoutPolicy whether the blackout policy is supported, where empty
+// policy stands for skip
+func IsValidBlackoutPolicy(policy string) (ok bool) {
+	switch policy {
+	case "", constants.ScheduleBlackoutPolicySkip, constants.ScheduleBlackoutPolicyDefer:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsExcludedDate whether the date of t is excluded by any of the calendars
+func IsExcludedDate(calendars []models.Calendar, t time.Time) (ok bool) {
+	date := t.Format(calendarDateLayout)
+	for _, c := range calendars {
+		for _, d := range c.Dates {
+			end := d.End
+			if end == "" {
+				end = d.Date
+			}
+			if d.Date <= date && date <= end {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetBlackoutEnd end of the blackout window t is in, in location of t, which
+// is the latest one if t is in multiple windows
+func GetBlackoutEnd(blackouts []models.ScheduleBlackout, t time.Time) (end time.Time, ok bool) {
+	for _, b := range blackouts {
+		start, err := time.Parse(blackoutTimeLayout, b.Start)
+		if err != nil {
+			continue
+		}
+		stop, err := time.Parse(blackoutTimeLayout, b.End)
+		if err != nil {
+			continue
+		}
+
+		// windows starting on the day of t or the day before
+		for _, offset := range []int{0, -1} {
+			ws := time.Date(t.Year(), t.Month(), t.Day()+offset, start.Hour(), start.Minute(), 0, 0, t.Location())
+			if !isBlackoutWeekday(b, ws.Weekday()) {
+				continue
+			}
+			we := time.Date(t.Year(), t.Month(), t.Day()+offset, stop.Hour(), stop.Minute(), 0, 0, t.Location())
+			if !we.After(ws) {
+				we = we.AddDate(0, 0, 1)
+			}
+			if !t.Before(ws) && t.Before(we) && we.After(end) {
+				end = we
+				ok = true
+			}
+		}
+	}
+	return end, ok
+}
+
+func isBlackoutWeekday(b models.ScheduleBlackout, wd time.Weekday) (ok bool) {
+	if len(b.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range b.Weekdays {
+		if time.Weekday(d) == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// getLocation location of the schedule, which is that of the service if time
+// zone of the schedule is empty or invalid
+func (svc *Service) getLocation(s *models.Schedule) (loc *time.Location) {
+	if s.Timezone != "" {
+		if loc, err := time.LoadLocation(s.Timezone); err == nil {
+			return loc
+		}
+	}
+	return svc.loc
+}
+
+// isExcluded whether the date of t is excluded by calendars of the schedule
+func (svc *Service) isExcluded(s *models.Schedule, t time.Time) (ok bool, err error) {
+	if len(s.CalendarIds) == 0 {
+		return false, nil
+	}
+	calendars, err := svc.modelSvc.GetCalendarList(bson.M{"_id": bson.M{"$in": s.CalendarIds}}, nil)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+	return IsExcludedDate(calendars, t), nil
+}
+
+// isBlocked whether fires of the schedule at t would have been skipped or
+// deferred due to its calendars or blackout windows
+func (svc *Service) isBlocked(s *models.Schedule, t time.Time) (ok bool, err error) {
+	t = t.In(svc.getLocation(s))
+	if _, ok := GetBlackoutEnd(s.Blackouts, t); ok {
+		return true, nil
+	}
+	return svc.isExcluded(s, t)
+}
+
+// trigger fire the schedule now unless today is excluded by its calendars or
+// now is in its blackout windows, where the fire is skipped or deferred to the
+// end of the window according to its blackout policy
+func (svc *Service) trigger(s *models.Schedule, fireTs, prevFireTs time.Time) (deferred bool) {
+	now := time.Now().In(svc.getLocation(s))
+
+	// calendars
+	excluded, err := svc.isExcluded(s, now)
+	if err != nil {
+		trace.PrintError(err)
+		return false
+	}
+	if excluded {
+		svc.recordSkip(s, constants.ScheduleSkipReasonCalendar, "", nil)
+		return false
+	}
+
+	// blackout windows
+	if end, ok := GetBlackoutEnd(s.Blackouts, now); ok {
+		if s.BlackoutPolicy == constants.ScheduleBlackoutPolicyDefer {
+			svc.deferFire(s.Id, end, fireTs, prevFireTs)
+			return true
+		}
+		svc.recordSkip(s, constants.ScheduleSkipReasonBlackout, constants.ScheduleBlackoutPolicySkip, nil)
+		return false
+	}
+
+	svc.fire(s, fireTs, prevFireTs)
+	return false
+}
+
+// run trigger the schedule, which is disabled afterwards if it is one-off
+// unless the fire is deferred
+func (svc *Service) run(s *models.Schedule, fireTs, prevFireTs time.Time) {
+	if deferred := svc.trigger(s, fireTs, prevFireTs); deferred {
+		return
+	}
+	if s.Type == constants.ScheduleTypeOnce && s.Enabled {
+		if err := svc.Disable(s); err != nil {
+			trace.PrintError(err)
+		}
+	}
+}
+
+// deferredFire pending fire deferred by blackout windows
+type deferredFire struct {
+	timer      *time.Timer
+	fireTs     time.Time // scheduled time of the latest fire collapsed into it
+	prevFireTs time.Time // previous fire time of the earliest fire collapsed into it
+}
+
+// deferFire run the schedule at the given time, where fires deferred before
+// the pending one are collapsed into it, so that its fire time extends to that
+// of the latest one
+func (svc *Service) deferFire(id primitive.ObjectID, ts, fireTs, prevFireTs time.Time) {
+	svc.deferMu.Lock()
+	defer svc.deferMu.Unlock()
+	if df, ok := svc.deferred[id]; ok {
+		df.fireTs = fireTs
+		return
+	}
+	log.Infof("schedule[%s] deferred to %s as in blackout window", id.Hex(), ts.Format(time.RFC3339))
+	df := &deferredFire{
+		fireTs:     fireTs,
+		prevFireTs: prevFireTs,
+	}
+	df.timer = time.AfterFunc(time.Until(ts), func() {
+		svc.deferMu.Lock()
+		delete(svc.deferred, id)
+		fireTs, prevFireTs := df.fireTs, df.prevFireTs
+		svc.deferMu.Unlock()
+
+		// skip if the master is no longer the leader
+		if !svc.leaderSvc.IsLeader() {
+			return
+		}
+
+		// skip if the schedule has been disabled or deleted meanwhile
+		s, err := svc.modelSvc.GetScheduleById(id)
+		if err != nil {
+			trace.PrintError(err)
+			return
+		}
+		if !s.Enabled {
+			return
+		}
+
+		svc.run(s, fireTs, prevFireTs)
+	})
+	svc.deferred[id] = df
+}
+
+// stopDeferred cancel pending deferred fires
+func (svc *Service) stopDeferred() {
+	svc.deferMu.Lock()
+	defer svc.deferMu.Unlock()
+	for id, df := range svc.deferred {
+		df.timer.Stop()
+		delete(svc.deferred, id)
+	}
+}
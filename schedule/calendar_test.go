@@ -0,0 +1,64 @@
+package schedule
+
+import (
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGetBlackoutEnd(t *testing.T) {
+	blackouts := []models.ScheduleBlackout{
+		{Start: "09:00", End: "10:30"},
+		{Start: "22:00", End: "02:00", Weekdays: []int{int(time.Friday)}},
+	}
+
+	// 2021-01-01 is Friday
+	end, ok := GetBlackoutEnd(blackouts, time.Date(2021, 1, 1, 9, 30, 0, 0, time.UTC))
+	require.True(t, ok)
+	require.Equal(t, time.Date(2021, 1, 1, 10, 30, 0, 0, time.UTC), end)
+	_, ok = GetBlackoutEnd(blackouts, time.Date(2021, 1, 1, 10, 30, 0, 0, time.UTC))
+	require.False(t, ok)
+
+	// window spanning midnight, started on Friday
+	end, ok = GetBlackoutEnd(blackouts, time.Date(2021, 1, 1, 23, 0, 0, 0, time.UTC))
+	require.True(t, ok)
+	require.Equal(t, time.Date(2021, 1, 2, 2, 0, 0, 0, time.UTC), end)
+	end, ok = GetBlackoutEnd(blackouts, time.Date(2021, 1, 2, 1, 0, 0, 0, time.UTC))
+	require.True(t, ok)
+	require.Equal(t, time.Date(2021, 1, 2, 2, 0, 0, 0, time.UTC), end)
+
+	// not started on Saturday
+	_, ok = GetBlackoutEnd(blackouts, time.Date(2021, 1, 2, 23, 0, 0, 0, time.UTC))
+	require.False(t, ok)
+}
+
+func TestIsExcludedDate(t *testing.T) {
+	calendars := []models.Calendar{
+		{Dates: []models.CalendarDate{
+			{Date: "2021-01-01", Name: "New Year's Day"},
+			{Date: "2021-02-11", End: "2021-02-17", Name: "Spring Festival"},
+		}},
+	}
+	require.True(t, IsExcludedDate(calendars, time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)))
+	require.True(t, IsExcludedDate(calendars, time.Date(2021, 2, 17, 23, 0, 0, 0, time.UTC)))
+	require.False(t, IsExcludedDate(calendars, time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)))
+
+	require.Nil(t, ValidateCalendarDates(calendars[0].Dates))
+	require.NotNil(t, ValidateCalendarDates([]models.CalendarDate{{Date: "2021-13-01"}}))
+	require.NotNil(t, ValidateCalendarDates([]models.CalendarDate{{Date: "2021-02-17", End: "2021-02-11"}}))
+}
+
+func TestGetSchedule_Once(t *testing.T) {
+	runAt := time.Date(2021, 1, 1, 8, 0, 0, 0, time.UTC)
+	sch, err := GetSchedule(&models.Schedule{Type: constants.ScheduleTypeOnce, RunAt: runAt})
+	require.Nil(t, err)
+	require.Equal(t, runAt, sch.Next(runAt.Add(-time.Hour)))
+	require.True(t, sch.Next(runAt).IsZero())
+
+	_, err = GetSchedule(&models.Schedule{Type: constants.ScheduleTypeOnce})
+	require.NotNil(t, err)
+	_, err = GetSchedule(&models.Schedule{Type: "weekly"})
+	require.NotNil(t, err)
+}
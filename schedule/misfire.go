@@ -0,0 +1,142 @@
+package schedule
+
+import (
+	"github.com/apex/log"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+// maxMissedFires max number of missed fires to catch up for a schedule
+const maxMissedFires = 100
+
+// IsValidMisfirePolicy whether the misfire policy is supported, where empty
+// policy stands for the default of schedule service
+func IsValidMisfirePolicy(policy string) (ok bool) {
+	switch policy {
+	case "",
+		constants.ScheduleMisfirePolicyIgnore,
+		constants.ScheduleMisfirePolicyFireOnce,
+		constants.ScheduleMisfirePolicyFireAll:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetMissedFireTimes fire times of the cron spec with the time zone after the
+// last fire and up to now, which are within the catch-up window before now.
+// No fires are regarded as missed if the last fire time is unknown, and only
+// the latest ones are returned if there are too many
+func GetMissedFireTimes(spec, timezone string, last, now time.Time, window time.Duration) (times []time.Time, err error) {
+	sch, err := ParseSpec(spec, timezone)
+	if err != nil {
+		return nil, err
+	}
+	return getMissedFireTimes(sch, last, now, window), nil
+}
+
+func getMissedFireTimes(sch cron.Schedule, last, now time.Time, window time.Duration) (times []time.Time) {
+	if last.IsZero() || window <= 0 {
+		return nil
+	}
+	t := last
+	if start := now.Add(-window); t.Before(start) {
+		t = start
+	}
+	for {
+		t = sch.Next(t)
+		if t.IsZero() || t.After(now) {
+			break
+		}
+		times = append(times, t)
+	}
+	if len(times) > maxMissedFires {
+		times = times[len(times)-maxMissedFires:]
+	}
+	return times
+}
+
+// getMisfirePolicy misfire policy of the schedule, which falls back to the
+// default of the service
+func (svc *Service) getMisfirePolicy(s *models.Schedule) (policy string) {
+	if s.MisfirePolicy != "" {
+		return s.MisfirePolicy
+	}
+	return svc.misfirePolicy
+}
+
+// catchUp fire the schedule for its fires missed since the last fire according
+// to its misfire policy:
+//   - ignore: never fire
+//   - fire-once: fire once for all missed fires
+//   - fire-all: fire for each missed fire
+//
+// Missed fires which would have been skipped or deferred due to calendars or
+// blackout windows are dropped, and each fire is still subject to calendars,
+// blackout windows and overlap policy of the schedule at present
+func (svc *Service) catchUp(s *models.Schedule) {
+	policy := svc.getMisfirePolicy(s)
+	if policy != constants.ScheduleMisfirePolicyFireOnce && policy != constants.ScheduleMisfirePolicyFireAll {
+		return
+	}
+
+	// missed fires
+	sch, err := GetSchedule(s)
+	if err != nil {
+		trace.PrintError(err)
+		return
+	}
+	last := s.LastFireTs
+	if s.Type == constants.ScheduleTypeOnce && last.IsZero() {
+		// one-off schedule which has never fired
+		last = s.RunAt.Add(-time.Second)
+	}
+	var times []time.Time
+	for _, t := range getMissedFireTimes(sch, last, time.Now().In(svc.loc), svc.catchUpWindow) {
+		blocked, err := svc.isBlocked(s, t)
+		if err != nil {
+			trace.PrintError(err)
+			return
+		}
+		if !blocked {
+			times = append(times, t)
+		}
+	}
+	if len(times) == 0 {
+		return
+	}
+	if policy == constants.ScheduleMisfirePolicyFireOnce {
+		times = times[len(times)-1:]
+	}
+	log.Infof("schedule[%s] catching up %d missed fires (policy: %s)", s.Id.Hex(), len(times), policy)
+
+	// last fire time, which is set beforehand so that the missed fires are
+	// not caught up again if the master is down meanwhile
+	prevFireTs := s.LastFireTs
+	if err := svc.setLastFireTs(s, times[len(times)-1]); err != nil {
+		trace.PrintError(err)
+		return
+	}
+
+	// fire, where each fire covers the time since the previous one
+	for _, t := range times {
+		svc.run(s, t, prevFireTs)
+		prevFireTs = t
+	}
+}
+
+// setLastFireTs save the last fire time of the schedule
+func (svc *Service) setLastFireTs(s *models.Schedule, ts time.Time) (err error) {
+	s.SetLastFireTs(ts)
+	return mongo.GetMongoCol(interfaces.ModelColNameSchedule).UpdateId(s.Id, bson.M{
+		"$set": bson.M{
+			"last_fire_ts": ts,
+		},
+	})
+}
@@ -0,0 +1,45 @@
+package schedule
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGetMissedFireTimes(t *testing.T) {
+	last := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2021, 1, 1, 3, 30, 0, 0, time.UTC)
+
+	// all missed fires within the window
+	times, err := GetMissedFireTimes("0 * * * *", "", last, now, 24*time.Hour)
+	require.Nil(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2021, 1, 1, 1, 0, 0, 0, time.UTC),
+		time.Date(2021, 1, 1, 2, 0, 0, 0, time.UTC),
+		time.Date(2021, 1, 1, 3, 0, 0, 0, time.UTC),
+	}, times)
+
+	// missed fires before the window are dropped
+	times, err = GetMissedFireTimes("0 * * * *", "", last, now, 90*time.Minute)
+	require.Nil(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2021, 1, 1, 3, 0, 0, 0, time.UTC),
+	}, times)
+
+	// unknown last fire time
+	times, err = GetMissedFireTimes("0 * * * *", "", time.Time{}, now, 24*time.Hour)
+	require.Nil(t, err)
+	require.Empty(t, times)
+
+	// too many missed fires
+	times, err = GetMissedFireTimes("* * * * * *", "", last, now, 24*time.Hour)
+	require.Nil(t, err)
+	require.Len(t, times, maxMissedFires)
+	require.Equal(t, now, times[len(times)-1])
+}
+
+func TestIsValidMisfirePolicy(t *testing.T) {
+	require.True(t, IsValidMisfirePolicy(""))
+	require.True(t, IsValidMisfirePolicy("fire-all"))
+	require.False(t, IsValidMisfirePolicy("fire-twice"))
+}
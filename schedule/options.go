@@ -31,6 +31,18 @@ func WithSkipIfStillRunning() Option {
 	}
 }
 
+func WithMisfirePolicy(policy string) Option {
+	return func(svc interfaces.ScheduleService) {
+		svc.SetMisfirePolicy(policy)
+	}
+}
+
+func WithCatchUpWindow(window time.Duration) Option {
+	return func(svc interfaces.ScheduleService) {
+		svc.SetCatchUpWindow(window)
+	}
+}
+
 func WithUpdateInterval(interval time.Duration) Option {
 	return func(svc interfaces.ScheduleService) {
 	}
@@ -0,0 +1,113 @@
+package schedule
+
+import (
+	"github.com/apex/log"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/models/delegate"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"time"
+)
+
+// IsValidOverlapPolicy whether the overlap policy is supported, where empty
+// policy stands for the default of schedule service
+func IsValidOverlapPolicy(policy string) (ok bool) {
+	switch policy {
+	case "",
+		constants.ScheduleOverlapPolicyAllow,
+		constants.ScheduleOverlapPolicySkipIfRunning,
+		constants.ScheduleOverlapPolicyQueueOne,
+		constants.ScheduleOverlapPolicyReplace:
+		return true
+	default:
+		return false
+	}
+}
+
+// getOverlapPolicy overlap policy of the schedule, which falls back to
+// skip-if-running if skip is set for the service, or queue-one if delay is set
+func (svc *Service) getOverlapPolicy(s *models.Schedule) (policy string) {
+	if s.OverlapPolicy != "" {
+		return s.OverlapPolicy
+	}
+	if svc.skip {
+		return constants.ScheduleOverlapPolicySkipIfRunning
+	}
+	if svc.delay {
+		return constants.ScheduleOverlapPolicyQueueOne
+	}
+	return constants.ScheduleOverlapPolicyAllow
+}
+
+// checkOverlap whether the schedule is to fire according to its overlap policy
+// against pending and running tasks of its previous runs:
+//   - allow: always fire
+//   - skip-if-running: skip if any previous run is pending or running
+//   - queue-one: skip if any previous run is still pending, so that at most one
+//     run is queued behind the running one
+//   - replace: cancel previous runs and fire
+//
+// Skipped fires are recorded
+func (svc *Service) checkOverlap(s *models.Schedule) (ok bool, err error) {
+	policy := svc.getOverlapPolicy(s)
+	if policy == constants.ScheduleOverlapPolicyAllow {
+		return true, nil
+	}
+
+	// active tasks of previous runs
+	tasks, err := svc.modelSvc.GetTaskList(bson.M{
+		"schedule_id": s.Id,
+		"status": bson.M{
+			"$in": []string{constants.TaskStatusPending, constants.TaskStatusRunning},
+		},
+	}, nil)
+	if err != nil {
+		return false, err
+	}
+	if len(tasks) == 0 {
+		return true, nil
+	}
+
+	switch policy {
+	case constants.ScheduleOverlapPolicySkipIfRunning:
+		svc.recordSkip(s, constants.ScheduleSkipReasonOverlap, policy, tasks)
+		return false, nil
+	case constants.ScheduleOverlapPolicyQueueOne:
+		for _, t := range tasks {
+			if t.Status == constants.TaskStatusPending {
+				svc.recordSkip(s, constants.ScheduleSkipReasonOverlap, policy, tasks)
+				return false, nil
+			}
+		}
+		return true, nil
+	case constants.ScheduleOverlapPolicyReplace:
+		for _, t := range tasks {
+			if err := svc.schedulerSvc.Cancel(t.Id); err != nil {
+				trace.PrintError(err)
+			}
+		}
+		return true, nil
+	default:
+		return true, nil
+	}
+}
+
+// recordSkip record the skipped fire of the schedule with the reason, and active
+// tasks of previous runs if skipped due to overlap
+func (svc *Service) recordSkip(s *models.Schedule, reason, policy string, tasks []models.Task) {
+	log.Infof("schedule[%s] skipped (reason: %s, policy: %s)", s.Id.Hex(), reason, policy)
+	sk := &models.ScheduleSkip{
+		ScheduleId: s.Id,
+		SpiderId:   s.SpiderId,
+		Reason:     reason,
+		Policy:     policy,
+		Ts:         time.Now(),
+	}
+	for _, t := range tasks {
+		sk.TaskIds = append(sk.TaskIds, t.Id)
+	}
+	if err := delegate.NewModelDelegate(sk).Add(); err != nil {
+		trace.PrintError(err)
+	}
+}
@@ -3,13 +3,18 @@ package schedule
 import (
 	"github.com/crawlab-team/go-trace"
 	"github.com/doubletrey/crawlab-core/config"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
 	"github.com/doubletrey/crawlab-core/interfaces"
 	"github.com/doubletrey/crawlab-core/models/delegate"
 	"github.com/doubletrey/crawlab-core/models/models"
 	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/doubletrey/crawlab-core/node/leader"
 	"github.com/doubletrey/crawlab-core/spider/admin"
+	"github.com/doubletrey/crawlab-core/task/scheduler"
 	"github.com/doubletrey/crawlab-core/utils"
 	"github.com/robfig/cron/v3"
+	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/dig"
@@ -20,13 +25,17 @@ import (
 type Service struct {
 	// dependencies
 	interfaces.WithConfigPath
-	modelSvc service.ModelService
-	adminSvc interfaces.SpiderAdminService
+	modelSvc     service.ModelService
+	adminSvc     interfaces.SpiderAdminService
+	schedulerSvc interfaces.TaskSchedulerService
+	leaderSvc    interfaces.NodeLeaderService
 
 	// settings variables
 	loc            *time.Location
-	delay          bool
-	skip           bool
+	delay          bool   // queue one run at most behind the running one by default
+	skip           bool   // skip fires if previous runs are active by default
+	misfirePolicy  string // policy of missed fires by default
+	catchUpWindow  time.Duration
 	updateInterval time.Duration
 
 	// internals
@@ -35,6 +44,8 @@ type Service struct {
 	schedules []models.Schedule
 	stopped   bool
 	mu        sync.Mutex
+	deferred  map[primitive.ObjectID]*deferredFire // pending fires deferred by blackout windows
+	deferMu   sync.Mutex
 }
 
 func (svc *Service) GetLocation() (loc *time.Location) {
@@ -61,6 +72,22 @@ func (svc *Service) SetSkip(skip bool) {
 	svc.skip = skip
 }
 
+func (svc *Service) GetMisfirePolicy() (policy string) {
+	return svc.misfirePolicy
+}
+
+func (svc *Service) SetMisfirePolicy(policy string) {
+	svc.misfirePolicy = policy
+}
+
+func (svc *Service) GetCatchUpWindow() (window time.Duration) {
+	return svc.catchUpWindow
+}
+
+func (svc *Service) SetCatchUpWindow(window time.Duration) {
+	svc.catchUpWindow = window
+}
+
 func (svc *Service) GetUpdateInterval() (interval time.Duration) {
 	return svc.updateInterval
 }
@@ -74,6 +101,7 @@ func (svc *Service) Init() (err error) {
 }
 
 func (svc *Service) Start() {
+	svc.stopped = false
 	svc.cron.Start()
 	go svc.Update()
 }
@@ -86,18 +114,32 @@ func (svc *Service) Wait() {
 func (svc *Service) Stop() {
 	svc.stopped = true
 	svc.cron.Stop()
+	svc.stopDeferred()
 }
 
 func (svc *Service) Enable(s interfaces.Schedule, args ...interface{}) (err error) {
 	svc.mu.Lock()
 	defer svc.mu.Unlock()
 
-	id, err := svc.cron.AddFunc(s.GetCron(), svc.schedule(s.GetId()))
+	sch, err := GetSchedule(s)
 	if err != nil {
-		return trace.TraceError(err)
+		return err
+	}
+	if s.GetType() == constants.ScheduleTypeOnce && !s.GetRunAt().After(time.Now()) {
+		return errors.ErrorScheduleInvalidRunAt
+	}
+	if !s.GetEnabled() {
+		// fires while disabled are not regarded as missed
+		s.SetLastFireTs(time.Now())
 	}
 	s.SetEnabled(true)
-	s.SetEntryId(id)
+	if svc.leaderSvc.IsLeader() {
+		id := svc.cron.Schedule(sch, cron.FuncJob(svc.schedule(s.GetId())))
+		s.SetEntryId(id)
+	} else {
+		// to be added to cron of the leader on its next update
+		s.SetEntryId(-1)
+	}
 	u := utils.GetUserFromArgs(args...)
 	return delegate.NewModelDelegate(s, u).Save()
 }
@@ -129,7 +171,20 @@ func (svc *Service) GetCron() (c *cron.Cron) {
 	return svc.cron
 }
 
+func (svc *Service) Preview(spec, timezone string, n int) (times []time.Time, err error) {
+	return GetNextTimes(spec, timezone, time.Now().In(svc.loc), n)
+}
+
 func (svc *Service) update() {
+	// only the leader among masters keeps cron entries of enabled schedules,
+	// which are removed once it is no longer the leader
+	if !svc.leaderSvc.IsLeader() {
+		for _, e := range svc.cron.Entries() {
+			svc.cron.Remove(e.ID)
+		}
+		return
+	}
+
 	// fetch enabled schedules
 	if err := svc.fetch(); err != nil {
 		trace.PrintError(err)
@@ -145,6 +200,20 @@ func (svc *Service) update() {
 		if ok {
 			entryIdsMap[s.EntryId] = true
 		} else {
+			// fires missed before the schedule is added to cron, e.g. during
+			// downtime of masters
+			svc.catchUp(&s)
+
+			// one-off schedule which has fired or will never fire
+			if s.Type == constants.ScheduleTypeOnce && (!s.Enabled || !s.RunAt.After(time.Now())) {
+				if s.Enabled {
+					if err := svc.Disable(&s); err != nil {
+						trace.PrintError(err)
+					}
+				}
+				continue
+			}
+
 			if err := svc.Enable(&s); err != nil {
 				trace.PrintError(err)
 				continue
@@ -181,6 +250,11 @@ func (svc *Service) fetch() (err error) {
 
 func (svc *Service) schedule(id primitive.ObjectID) (fn func()) {
 	return func() {
+		// skip if the master is no longer the leader
+		if !svc.leaderSvc.IsLeader() {
+			return
+		}
+
 		// schedule
 		s, err := svc.modelSvc.GetScheduleById(id)
 		if err != nil {
@@ -188,54 +262,90 @@ func (svc *Service) schedule(id primitive.ObjectID) (fn func()) {
 			return
 		}
 
-		// spider
-		spider, err := svc.modelSvc.GetSpiderById(s.GetSpiderId())
-		if err != nil {
-			trace.PrintError(err)
+		// skip if disabled while its cron entry is not removed yet
+		if !s.Enabled {
 			return
 		}
 
-		// options
-		opts := &interfaces.SpiderRunOptions{
-			Mode:       s.GetMode(),
-			NodeIds:    s.GetNodeIds(),
-			NodeTags:   s.GetNodeTags(),
-			Cmd:        s.GetCmd(),
-			Param:      s.GetParam(),
-			Priority:   s.GetPriority(),
-			ScheduleId: s.GetId(),
-			UserId:     s.UserId,
+		// last fire time, where the scheduled time is now truncated to seconds
+		// as cron fires at whole seconds
+		fireTs := time.Now().Truncate(time.Second)
+		prevFireTs := s.LastFireTs
+		if err := svc.setLastFireTs(s, fireTs); err != nil {
+			trace.PrintError(err)
 		}
 
-		// normalize options
-		if opts.Mode == "" {
-			opts.Mode = spider.Mode
-		}
-		if len(opts.NodeIds) == 0 {
-			opts.NodeIds = spider.NodeIds
-		}
-		if len(opts.NodeTags) == 0 {
-			opts.NodeTags = spider.NodeTags
-		}
-		if opts.Cmd == "" {
-			opts.Cmd = spider.Cmd
-		}
-		if opts.Param == "" {
-			opts.Param = spider.Param
-		}
-		if opts.Priority == 0 {
-			if spider.Priority > 0 {
-				opts.Priority = spider.Priority
-			} else {
-				opts.Priority = 5
-			}
-		}
+		svc.run(s, fireTs, prevFireTs)
+	}
+}
 
-		// schedule
-		if err := svc.adminSvc.Schedule(s.GetSpiderId(), opts); err != nil {
-			trace.PrintError(err)
+// fire run the spider of the schedule if allowed by its overlap policy, where
+// param and environment variables are rendered with times of the fire
+func (svc *Service) fire(s *models.Schedule, fireTs, prevFireTs time.Time) {
+	// overlap policy
+	ok, err := svc.checkOverlap(s)
+	if err != nil {
+		trace.PrintError(err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	// spider
+	spider, err := svc.modelSvc.GetSpiderById(s.GetSpiderId())
+	if err != nil {
+		trace.PrintError(err)
+		return
+	}
+
+	// options
+	opts := &interfaces.SpiderRunOptions{
+		Mode:       s.GetMode(),
+		NodeIds:    s.GetNodeIds(),
+		NodeTags:   s.GetNodeTags(),
+		Cmd:        s.GetCmd(),
+		Param:      s.GetParam(),
+		Priority:   s.GetPriority(),
+		ScheduleId: s.GetId(),
+		Ref:        s.Ref,
+		UserId:     s.UserId,
+	}
+
+	// normalize options
+	if opts.Mode == "" {
+		opts.Mode = spider.Mode
+	}
+	if len(opts.NodeIds) == 0 {
+		opts.NodeIds = spider.NodeIds
+	}
+	if len(opts.NodeTags) == 0 {
+		opts.NodeTags = spider.NodeTags
+	}
+	if opts.Cmd == "" {
+		opts.Cmd = spider.Cmd
+	}
+	if opts.Priority == 0 {
+		if spider.Priority > 0 {
+			opts.Priority = spider.Priority
+		} else {
+			opts.Priority = 5
 		}
 	}
+
+	// templates of the schedule, where param of the spider is not a template
+	opts.Param, opts.Envs, ok = svc.render(s, fireTs, prevFireTs)
+	if !ok {
+		return
+	}
+	if s.Param == "" {
+		opts.Param = spider.Param
+	}
+
+	// schedule
+	if err := svc.adminSvc.Schedule(s.GetSpiderId(), opts); err != nil {
+		trace.PrintError(err)
+	}
 }
 
 func NewScheduleService(opts ...Option) (svc2 interfaces.ScheduleService, err error) {
@@ -243,10 +353,20 @@ func NewScheduleService(opts ...Option) (svc2 interfaces.ScheduleService, err er
 	svc := &Service{
 		WithConfigPath: config.NewConfigPathService(),
 		loc:            time.Local,
-		// TODO: implement delay and skip
 		delay:          false,
 		skip:           false,
+		misfirePolicy:  constants.ScheduleMisfirePolicyIgnore,
+		catchUpWindow:  1 * time.Hour,
 		updateInterval: 1 * time.Minute,
+		deferred:       map[primitive.ObjectID]*deferredFire{},
+	}
+
+	// misfire policy and catch-up window
+	if viper.GetString("schedule.misfirePolicy") != "" {
+		svc.misfirePolicy = viper.GetString("schedule.misfirePolicy")
+	}
+	if viper.GetInt("schedule.catchUpWindow") > 0 {
+		svc.catchUpWindow = time.Duration(viper.GetInt("schedule.catchUpWindow")) * time.Second
 	}
 
 	// apply options
@@ -262,12 +382,22 @@ func NewScheduleService(opts ...Option) (svc2 interfaces.ScheduleService, err er
 	if err := c.Provide(admin.ProvideSpiderAdminService(svc.GetConfigPath())); err != nil {
 		return nil, trace.TraceError(err)
 	}
+	if err := c.Provide(scheduler.ProvideGetTaskSchedulerService(svc.GetConfigPath())); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Provide(leader.ProvideGetNodeLeaderService(svc.GetConfigPath())); err != nil {
+		return nil, trace.TraceError(err)
+	}
 	if err := c.Invoke(func(
 		modelSvc service.ModelService,
 		adminSvc interfaces.SpiderAdminService,
+		schedulerSvc interfaces.TaskSchedulerService,
+		leaderSvc interfaces.NodeLeaderService,
 	) {
 		svc.modelSvc = modelSvc
 		svc.adminSvc = adminSvc
+		svc.schedulerSvc = schedulerSvc
+		svc.leaderSvc = leaderSvc
 	}); err != nil {
 		return nil, trace.TraceError(err)
 	}
@@ -279,6 +409,7 @@ func NewScheduleService(opts ...Option) (svc2 interfaces.ScheduleService, err er
 	svc.cron = cron.New(
 		cron.WithLogger(svc.logger),
 		cron.WithLocation(svc.loc),
+		cron.WithParser(parser),
 		cron.WithChain(cron.Recover(svc.logger)),
 	)
 
@@ -0,0 +1,98 @@
+package schedule
+
+import (
+	"fmt"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/robfig/cron/v3"
+	"strings"
+	"time"
+	_ "time/tzdata"
+)
+
+// parser of cron specs with optional seconds field, descriptors like @hourly
+// and time zone prefix "CRON_TZ=" or "TZ="
+var parser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// GetSpec cron spec of the schedule with time zone prefix of its time zone,
+// which is the spec as it is if time zone is empty or already prefixed
+func GetSpec(spec, timezone string) (res string) {
+	spec = strings.TrimSpace(spec)
+	if timezone == "" || strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		return spec
+	}
+	return fmt.Sprintf("CRON_TZ=%s %s", timezone, spec)
+}
+
+// ParseSpec parse cron spec with the time zone, which is validated beforehand
+func ParseSpec(spec, timezone string) (sch cron.Schedule, err error) {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return nil, errors.NewScheduleError(fmt.Sprintf("%s: %s", errors.ErrorScheduleInvalidTimezone.Error(), timezone))
+		}
+	}
+	sch, err = parser.Parse(GetSpec(spec, timezone))
+	if err != nil {
+		return nil, errors.NewScheduleError(fmt.Sprintf("%s: %v", errors.ErrorScheduleInvalidCron.Error(), err))
+	}
+	return sch, nil
+}
+
+// onceSchedule cron schedule which fires only once at the given time
+type onceSchedule struct {
+	at time.Time
+}
+
+func (s *onceSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.at) {
+		return s.at
+	}
+	return time.Time{}
+}
+
+// IsValidType whether the schedule type is supported, where empty type stands
+// for cron
+func IsValidType(t string) (ok bool) {
+	switch t {
+	case "", constants.ScheduleTypeCron, constants.ScheduleTypeOnce:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetSchedule cron schedule of the schedule, which fires by its cron spec, or
+// only at its run time if it is one-off
+func GetSchedule(s interfaces.Schedule) (sch cron.Schedule, err error) {
+	switch s.GetType() {
+	case "", constants.ScheduleTypeCron:
+		return ParseSpec(s.GetCron(), s.GetTimezone())
+	case constants.ScheduleTypeOnce:
+		if s.GetRunAt().IsZero() {
+			return nil, errors.ErrorScheduleInvalidRunAt
+		}
+		return &onceSchedule{at: s.GetRunAt()}, nil
+	default:
+		return nil, errors.NewScheduleError(fmt.Sprintf("%s: %s", errors.ErrorScheduleInvalidType.Error(), s.GetType()))
+	}
+}
+
+// GetNextTimes next n fire times of the cron spec with the time zone after
+// the given time, where times of specs without time zone are in location of
+// the given time
+func GetNextTimes(spec, timezone string, from time.Time, n int) (times []time.Time, err error) {
+	sch, err := ParseSpec(spec, timezone)
+	if err != nil {
+		return nil, err
+	}
+	t := from
+	for i := 0; i < n; i++ {
+		t = sch.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
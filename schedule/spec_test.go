@@ -0,0 +1,42 @@
+package schedule
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestGetNextTimes(t *testing.T) {
+	from := time.Date(2021, 1, 1, 0, 0, 30, 0, time.UTC)
+
+	// minute-level
+	times, err := GetNextTimes("0 * * * *", "", from, 2)
+	require.Nil(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2021, 1, 1, 1, 0, 0, 0, time.UTC),
+		time.Date(2021, 1, 1, 2, 0, 0, 0, time.UTC),
+	}, times)
+
+	// seconds-level
+	times, err = GetNextTimes("*/10 * * * * *", "", from, 2)
+	require.Nil(t, err)
+	require.Equal(t, time.Date(2021, 1, 1, 0, 0, 40, 0, time.UTC), times[0])
+	require.Equal(t, time.Date(2021, 1, 1, 0, 0, 50, 0, time.UTC), times[1])
+
+	// time zone
+	times, err = GetNextTimes("0 0 * * *", "Asia/Shanghai", from, 1)
+	require.Nil(t, err)
+	require.Equal(t, time.Date(2021, 1, 1, 16, 0, 0, 0, time.UTC), times[0].UTC())
+
+	// time zone prefix, which takes precedence
+	times, err = GetNextTimes("CRON_TZ=America/New_York 0 0 * * *", "Asia/Shanghai", from, 1)
+	require.Nil(t, err)
+	require.Equal(t, time.Date(2021, 1, 1, 5, 0, 0, 0, time.UTC), times[0].UTC())
+}
+
+func TestParseSpec_Invalid(t *testing.T) {
+	_, err := ParseSpec("0 0 * *", "")
+	require.NotNil(t, err)
+	_, err = ParseSpec("0 0 * * *", "Mars/Olympus_Mons")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,131 @@
+package schedule
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/apex/log"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/models/models"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"text/template"
+	"time"
+)
+
+// TemplateTime time exposed to templates, which is rendered in RFC3339 and
+// can be formatted otherwise, e.g. {{.FireTime.Format "2006-01-02"}}
+type TemplateTime struct {
+	time.Time
+}
+
+func (t TemplateTime) String() string {
+	return t.Format(time.RFC3339)
+}
+
+// TemplateData data exposed to templates of param and environment variables of
+// schedules, e.g. "--since={{.PrevFireTime}} --until={{.FireTime}}"
+type TemplateData struct {
+	FireTime     TemplateTime      // scheduled time of the fire
+	PrevFireTime TemplateTime      // scheduled time of the previous fire, which is zero if it is the first
+	ScheduleId   string            // hex id of the schedule
+	ScheduleName string            // name of the schedule
+	Vars         map[string]string // values of global variables by key, e.g. {{.Vars.token}}
+}
+
+// NewTemplateData template data of the fire of the schedule at fireTs, where
+// times are in the time zone of loc
+func NewTemplateData(s *models.Schedule, fireTs, prevFireTs time.Time, loc *time.Location, variables []models.Variable) (data *TemplateData) {
+	data = &TemplateData{
+		FireTime:     TemplateTime{fireTs.In(loc)},
+		PrevFireTime: TemplateTime{prevFireTs},
+		ScheduleId:   s.Id.Hex(),
+		ScheduleName: s.Name,
+		Vars:         map[string]string{},
+	}
+	if !prevFireTs.IsZero() {
+		data.PrevFireTime = TemplateTime{prevFireTs.In(loc)}
+	}
+	for _, v := range variables {
+		data.Vars[v.Key] = v.Value
+	}
+	return data
+}
+
+// ParseTemplate parse the template text, where referring to missing keys of
+// variables is an error at rendering
+func ParseTemplate(text string) (tmpl *template.Template, err error) {
+	tmpl, err = template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.NewScheduleError(fmt.Sprintf("%s: %v", errors.ErrorScheduleInvalidTemplate.Error(), err))
+	}
+	return tmpl, nil
+}
+
+// RenderTemplate render the template text with the data
+func RenderTemplate(text string, data *TemplateData) (res string, err error) {
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", errors.NewScheduleError(fmt.Sprintf("%s: %v", errors.ErrorScheduleInvalidTemplate.Error(), err))
+	}
+	return buf.String(), nil
+}
+
+// ValidateTemplates whether param and values of environment variables of the
+// schedule are valid templates
+func ValidateTemplates(s *models.Schedule) (err error) {
+	if _, err := ParseTemplate(s.Param); err != nil {
+		return err
+	}
+	for _, env := range s.Envs {
+		if env.Name == "" {
+			return errors.NewScheduleError(fmt.Sprintf("%s: empty environment variable name", errors.ErrorScheduleInvalidTemplate.Error()))
+		}
+		if _, err := ParseTemplate(env.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render param and environment variables of the schedule with the data
+func Render(param string, envs []models.Env, data *TemplateData) (resParam string, resEnvs map[string]string, err error) {
+	resParam, err = RenderTemplate(param, data)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, env := range envs {
+		value, err := RenderTemplate(env.Value, data)
+		if err != nil {
+			return "", nil, errors.NewScheduleError(fmt.Sprintf("%s (env %s)", err.Error(), env.Name))
+		}
+		if resEnvs == nil {
+			resEnvs = map[string]string{}
+		}
+		resEnvs[env.Name] = value
+	}
+	return resParam, resEnvs, nil
+}
+
+// render param and environment variables of the schedule for the fire at
+// fireTs with global variables, where the fire is recorded as skipped if
+// rendering fails
+func (svc *Service) render(s *models.Schedule, fireTs, prevFireTs time.Time) (resParam string, resEnvs map[string]string, ok bool) {
+	variables, err := svc.modelSvc.GetVariableList(nil, nil)
+	if err != nil && err != mongo2.ErrNoDocuments {
+		trace.PrintError(err)
+		return "", nil, false
+	}
+	data := NewTemplateData(s, fireTs, prevFireTs, svc.getLocation(s), variables)
+	resParam, resEnvs, err = Render(s.Param, s.Envs, data)
+	if err != nil {
+		log.Errorf("schedule[%s] failed to render templates: %v", s.Id.Hex(), err)
+		svc.recordSkip(s, constants.ScheduleSkipReasonTemplate, "", nil)
+		return "", nil, false
+	}
+	return resParam, resEnvs, true
+}
@@ -0,0 +1,48 @@
+package schedule
+
+import (
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.Nil(t, err)
+	s := &models.Schedule{
+		Id:    primitive.NewObjectID(),
+		Name:  "daily",
+		Param: "--since={{.PrevFireTime}} --until={{.FireTime}} --date={{.FireTime.Format \"2006-01-02\"}}",
+		Envs: []models.Env{
+			{Name: "SCHEDULE", Value: "{{.ScheduleName}}"},
+			{Name: "TOKEN", Value: "{{.Vars.token}}"},
+		},
+	}
+	fireTs := time.Date(2021, 5, 2, 0, 0, 0, 0, time.UTC)
+	prevFireTs := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	data := NewTemplateData(s, fireTs, prevFireTs, loc, []models.Variable{{Key: "token", Value: "secret"}})
+
+	param, envs, err := Render(s.Param, s.Envs, data)
+	require.Nil(t, err)
+	require.Equal(t, "--since=2021-05-01T08:00:00+08:00 --until=2021-05-02T08:00:00+08:00 --date=2021-05-02", param)
+	require.Equal(t, map[string]string{"SCHEDULE": "daily", "TOKEN": "secret"}, envs)
+
+	// first fire
+	data = NewTemplateData(s, fireTs, time.Time{}, loc, nil)
+	param, err = RenderTemplate("{{if .PrevFireTime.IsZero}}full{{else}}incremental{{end}}", data)
+	require.Nil(t, err)
+	require.Equal(t, "full", param)
+
+	// missing variable
+	_, _, err = Render(s.Param, s.Envs, data)
+	require.NotNil(t, err)
+}
+
+func TestValidateTemplates(t *testing.T) {
+	require.Nil(t, ValidateTemplates(&models.Schedule{Param: "--page {{.Vars.page}}"}))
+	require.NotNil(t, ValidateTemplates(&models.Schedule{Param: "--since={{.PrevFireTime"}))
+	require.NotNil(t, ValidateTemplates(&models.Schedule{Envs: []models.Env{{Name: "SINCE", Value: "{{end}}"}}}))
+	require.NotNil(t, ValidateTemplates(&models.Schedule{Envs: []models.Env{{Value: "value"}}}))
+}
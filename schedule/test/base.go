@@ -5,6 +5,7 @@ import (
 	"github.com/doubletrey/crawlab-core/models/delegate"
 	"github.com/doubletrey/crawlab-core/models/models"
 	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/doubletrey/crawlab-core/node/leader"
 	"github.com/doubletrey/crawlab-core/schedule"
 	"go.uber.org/dig"
 	"testing"
@@ -24,6 +25,7 @@ type Test struct {
 	// dependencies
 	modelSvc    service.ModelService
 	scheduleSvc interfaces.ScheduleService
+	leaderSvc   interfaces.NodeLeaderService
 
 	// test data
 	TestSchedule interfaces.Schedule
@@ -33,12 +35,14 @@ type Test struct {
 }
 
 func (t *Test) Setup(t2 *testing.T) {
+	t.leaderSvc.Start()
 	t.scheduleSvc.Start()
 	t2.Cleanup(t.Cleanup)
 }
 
 func (t *Test) Cleanup() {
 	t.scheduleSvc.Stop()
+	t.leaderSvc.Stop()
 	_ = t.modelSvc.GetBaseService(interfaces.ModelIdTask).Delete(nil)
 }
 
@@ -71,6 +75,10 @@ func main() {
 	}); err != nil {
 		return nil, err
 	}
+	t.leaderSvc, err = leader.GetNodeLeaderService(t.scheduleSvc.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
 
 	// add spider to db
 	if err := delegate.NewModelDelegate(t.TestSpider).Add(); err != nil {
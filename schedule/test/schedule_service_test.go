@@ -1,7 +1,13 @@
 package test
 
 import (
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/delegate"
+	"github.com/doubletrey/crawlab-core/models/models"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"testing"
 	"time"
 )
@@ -42,3 +48,181 @@ func TestScheduleService_Run(t *testing.T) {
 		require.False(t, task.ScheduleId.IsZero())
 	}
 }
+
+func TestScheduleService_RunDisabled(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// disabled while its cron entry is not removed yet
+	err = T.scheduleSvc.Enable(T.TestSchedule)
+	require.Nil(t, err)
+	err = T.modelSvc.GetBaseService(interfaces.ModelIdSchedule).UpdateById(T.TestSchedule.GetId(), bson.M{
+		"$set": bson.M{"enabled": false},
+	})
+	require.Nil(t, err)
+	time.Sleep(1 * time.Minute)
+
+	tasks, err := T.modelSvc.GetTaskList(nil, nil)
+	require.Nil(t, err)
+	require.Empty(t, tasks)
+}
+
+func TestScheduleService_SpiderParam(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// param of the spider is not rendered as a template
+	spider := &models.Spider{Name: "test_spider_param", Param: "--since={{.PrevFireTime}}"}
+	err = delegate.NewModelDelegate(spider).Add()
+	require.Nil(t, err)
+	s := &models.Schedule{
+		Name:     "test_schedule_spider_param",
+		SpiderId: spider.Id,
+		Cron:     "* * * * *",
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	err = T.scheduleSvc.Enable(s)
+	require.Nil(t, err)
+	time.Sleep(1 * time.Minute)
+
+	tasks, err := T.modelSvc.GetTaskList(bson.M{"spider_id": spider.Id}, nil)
+	require.Nil(t, err)
+	require.Greater(t, len(tasks), 0)
+	require.Equal(t, spider.Param, tasks[0].Param)
+}
+
+func TestScheduleService_OverlapSkipIfRunning(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// schedule with a running task of its previous run
+	s := &models.Schedule{
+		Name:          "test_schedule_overlap",
+		SpiderId:      T.TestSpider.GetId(),
+		Cron:          "* * * * *",
+		OverlapPolicy: constants.ScheduleOverlapPolicySkipIfRunning,
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	task := &models.Task{
+		SpiderId:   T.TestSpider.GetId(),
+		ScheduleId: s.Id,
+		Status:     constants.TaskStatusRunning,
+	}
+	err = delegate.NewModelDelegate(task).Add()
+	require.Nil(t, err)
+
+	time.Sleep(1 * time.Second)
+	err = T.scheduleSvc.Enable(s)
+	require.Nil(t, err)
+	time.Sleep(1 * time.Minute)
+
+	// no new task
+	total, err := T.modelSvc.GetBaseService(interfaces.ModelIdTask).Count(bson.M{"schedule_id": s.Id})
+	require.Nil(t, err)
+	require.Equal(t, 1, total)
+
+	// skipped fire recorded
+	skips, err := T.modelSvc.GetScheduleSkipList(bson.M{"schedule_id": s.Id}, nil)
+	require.Nil(t, err)
+	require.Greater(t, len(skips), 0)
+	require.Equal(t, []primitive.ObjectID{task.Id}, skips[0].TaskIds)
+}
+
+func TestScheduleService_CatchUp(t *testing.T) {
+	var err error
+
+	// enabled schedule which missed its fires while the master was down
+	lastFireTs := time.Now().Add(-3 * time.Hour)
+	s := &models.Schedule{
+		Name:          "test_schedule_catch_up",
+		SpiderId:      T.TestSpider.GetId(),
+		Cron:          "0 * * * *",
+		MisfirePolicy: constants.ScheduleMisfirePolicyFireOnce,
+		LastFireTs:    lastFireTs,
+		Enabled:       true,
+		EntryId:       -1,
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+
+	// caught up on start
+	T.scheduleSvc.SetCatchUpWindow(24 * time.Hour)
+	T.Setup(t)
+	time.Sleep(3 * time.Second)
+
+	// fired once for all missed fires
+	total, err := T.modelSvc.GetBaseService(interfaces.ModelIdTask).Count(bson.M{"schedule_id": s.Id})
+	require.Nil(t, err)
+	require.Equal(t, 1, total)
+	s2, err := T.modelSvc.GetScheduleById(s.Id)
+	require.Nil(t, err)
+	require.True(t, s2.LastFireTs.After(lastFireTs))
+	require.Equal(t, 0, s2.LastFireTs.Minute())
+}
+
+func TestScheduleService_Once(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// one-off schedule
+	s := &models.Schedule{
+		Name:     "test_schedule_once",
+		SpiderId: T.TestSpider.GetId(),
+		Type:     constants.ScheduleTypeOnce,
+		RunAt:    time.Now().Add(2 * time.Second),
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	err = T.scheduleSvc.Enable(s)
+	require.Nil(t, err)
+	time.Sleep(4 * time.Second)
+
+	// fired once and disabled
+	total, err := T.modelSvc.GetBaseService(interfaces.ModelIdTask).Count(bson.M{"schedule_id": s.Id})
+	require.Nil(t, err)
+	require.Equal(t, 1, total)
+	s2, err := T.modelSvc.GetScheduleById(s.Id)
+	require.Nil(t, err)
+	require.False(t, s2.Enabled)
+}
+
+func TestScheduleService_Calendar(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// calendar excluding today
+	cal := &models.Calendar{
+		Name: "test_calendar",
+		Dates: []models.CalendarDate{
+			{Date: time.Now().Format("2006-01-02"), Name: "test_holiday"},
+		},
+	}
+	err = delegate.NewModelDelegate(cal).Add()
+	require.Nil(t, err)
+
+	// schedule using the calendar
+	s := &models.Schedule{
+		Name:        "test_schedule_calendar",
+		SpiderId:    T.TestSpider.GetId(),
+		Cron:        "* * * * * *",
+		CalendarIds: []primitive.ObjectID{cal.Id},
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	err = T.scheduleSvc.Enable(s)
+	require.Nil(t, err)
+	time.Sleep(3 * time.Second)
+	err = T.scheduleSvc.Disable(s)
+	require.Nil(t, err)
+
+	// no tasks but skips
+	total, err := T.modelSvc.GetBaseService(interfaces.ModelIdTask).Count(bson.M{"schedule_id": s.Id})
+	require.Nil(t, err)
+	require.Equal(t, 0, total)
+	skips, err := T.modelSvc.GetScheduleSkipList(bson.M{"schedule_id": s.Id}, nil)
+	require.Nil(t, err)
+	require.Greater(t, len(skips), 0)
+	require.Equal(t, constants.ScheduleSkipReasonCalendar, skips[0].Reason)
+}
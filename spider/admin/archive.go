@@ -0,0 +1,372 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/delegate"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-core/utils"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	archiveManifestVersion  = 1
+	archiveManifestFileName = "manifest.json"
+	archiveSpidersDirName   = "spiders"
+)
+
+// archiveManifest manifest of an exported archive, where files of each spider
+// are placed under "spiders/<dir>"
+type archiveManifest struct {
+	Version int             `json:"version"`
+	Ts      time.Time       `json:"ts"`
+	Spiders []archiveSpider `json:"spiders"`
+}
+
+type archiveSpider struct {
+	Spider         models.Spider     `json:"spider"`          // spider with environment variables
+	Tags           []models.Tag      `json:"tags"`            // tags of the spider
+	DataCollection string            `json:"data_collection"` // name of the bound data collection
+	Schedules      []models.Schedule `json:"schedules"`       // schedules of the spider
+	Dir            string            `json:"dir"`             // directory of spider files in the archive
+}
+
+// Export the spiders together with their files, schedules, data collections
+// and tags as a zip (default) or tar.gz archive written to w. Git credentials
+// are not exported
+func (svc *Service) Export(ids []primitive.ObjectID, format string, w io.Writer) (err error) {
+	aw, err := utils.NewArchiveWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	// manifest
+	m := &archiveManifest{
+		Version: archiveManifestVersion,
+		Ts:      time.Now(),
+	}
+	for _, id := range ids {
+		as, err := svc.getArchiveSpider(id)
+		if err != nil {
+			return err
+		}
+		m.Spiders = append(m.Spiders, *as)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return trace.TraceError(err)
+	}
+	if err := aw.WriteFile(archiveManifestFileName, data); err != nil {
+		return err
+	}
+
+	// files
+	for _, as := range m.Spiders {
+		fsSvc, err := svc.syncSvc.GetFsService(as.Spider.Id)
+		if err != nil {
+			return err
+		}
+		files, err := svc.getFsFiles(fsSvc)
+		if err != nil {
+			return err
+		}
+		for _, filePath := range files {
+			data, err := fsSvc.GetFile(filePath)
+			if err != nil {
+				return err
+			}
+			if err := aw.WriteFile(path.Join(archiveSpidersDirName, as.Dir, filePath), data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return aw.Close()
+}
+
+// Import spiders from an archive created by Export. Spiders are bound to the
+// project in options instead of the original ones, and bindings to data
+// sources and nodes of the original cluster are dropped
+func (svc *Service) Import(data []byte, opts *interfaces.SpiderImportOptions, args ...interface{}) (res *interfaces.SpiderImportResult, err error) {
+	if opts == nil {
+		opts = &interfaces.SpiderImportOptions{}
+	}
+	switch opts.Conflict {
+	case "":
+		opts.Conflict = constants.SpiderImportConflictRename
+	case constants.SpiderImportConflictRename, constants.SpiderImportConflictSkip, constants.SpiderImportConflictOverwrite:
+	default:
+		return nil, errors.NewSpiderError(fmt.Sprintf("%s: %s", errors.ErrorSpiderInvalidImportConflict.Error(), opts.Conflict))
+	}
+
+	// files
+	files, err := utils.ReadArchive(data, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// manifest
+	m, err := svc.getArchiveManifest(files)
+	if err != nil {
+		return nil, err
+	}
+
+	// spiders
+	res = &interfaces.SpiderImportResult{
+		Renamed: map[string]string{},
+	}
+	for _, as := range m.Spiders {
+		if err := svc.importSpider(&as, files, opts, res, args...); err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+// getArchiveSpider spider to export with its tags, data collection name and schedules
+func (svc *Service) getArchiveSpider(id primitive.ObjectID) (as *archiveSpider, err error) {
+	// spider
+	s, err := svc.modelSvc.GetSpiderById(id)
+	if err != nil {
+		return nil, err
+	}
+	s.Stat = nil
+	s.GitUsername = ""
+	s.GitPassword = ""
+	s.GitHasCredential = false
+	as = &archiveSpider{
+		Spider: *s,
+		Dir:    s.Id.Hex(),
+	}
+
+	// tags
+	tags, err := svc.getTags(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tags {
+		t.Id = primitive.NilObjectID
+		t.Col = ""
+		as.Tags = append(as.Tags, t)
+	}
+
+	// data collection
+	if !s.ColId.IsZero() {
+		dc, err := svc.modelSvc.GetDataCollectionById(s.ColId)
+		if err != nil && err != mongo2.ErrNoDocuments {
+			return nil, err
+		}
+		if dc != nil {
+			as.DataCollection = dc.Name
+		}
+	}
+
+	// schedules
+	as.Schedules, err = svc.modelSvc.GetScheduleList(bson.M{"spider_id": id}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return as, nil
+}
+
+// getArchiveManifest manifest in files of the archive
+func (svc *Service) getArchiveManifest(files map[string][]byte) (m *archiveManifest, err error) {
+	data, ok := files[archiveManifestFileName]
+	if !ok {
+		return nil, trace.TraceError(errors.ErrorSpiderInvalidManifest)
+	}
+	m = &archiveManifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, errors.NewSpiderError(fmt.Sprintf("%s: %v", errors.ErrorSpiderInvalidManifest.Error(), err))
+	}
+	if m.Version > archiveManifestVersion {
+		return nil, errors.NewSpiderError(fmt.Sprintf("%s: unsupported version %d", errors.ErrorSpiderInvalidManifest.Error(), m.Version))
+	}
+	for _, as := range m.Spiders {
+		if as.Spider.Name == "" || as.Dir == "" || strings.Contains(as.Dir, "/") {
+			return nil, trace.TraceError(errors.ErrorSpiderInvalidManifest)
+		}
+	}
+	return m, nil
+}
+
+// importSpider add the spider in the archive, or overwrite the existing one
+// with the same name if conflict is "overwrite"
+func (svc *Service) importSpider(as *archiveSpider, files map[string][]byte, opts *interfaces.SpiderImportOptions, res *interfaces.SpiderImportResult, args ...interface{}) (err error) {
+	s := as.Spider
+
+	// existing spider with the same name
+	existing, err := svc.modelSvc.GetSpider(bson.M{"name": s.Name}, nil)
+	if err != nil && err != mongo2.ErrNoDocuments {
+		return err
+	}
+	if existing != nil {
+		switch opts.Conflict {
+		case constants.SpiderImportConflictSkip:
+			res.Skipped = append(res.Skipped, s.Name)
+			return nil
+		case constants.SpiderImportConflictRename:
+			name, err := svc.getImportName(s.Name)
+			if err != nil {
+				return err
+			}
+			res.Renamed[s.Name] = name
+			s.Name = name
+			existing = nil
+		}
+	}
+
+	// drop settings bound to the original cluster
+	s.Id = primitive.NilObjectID
+	s.ProjectId = opts.ProjectId
+	s.DataSourceId = primitive.NilObjectID
+	s.DataSource = nil
+	s.NodeIds = nil
+	s.GitId = primitive.NilObjectID
+	s.IsGit = false
+	s.GitAutoSync = false
+	s.Stat = nil
+	s.Tags = nil
+	if s.Mode == constants.RunTypeSelectedNodes {
+		s.Mode = constants.RunTypeRandom
+	}
+
+	// data collection
+	s.ColId = primitive.NilObjectID
+	if as.DataCollection != "" {
+		dc, err := svc.upsertDataCollection(as.DataCollection, args...)
+		if err != nil {
+			return err
+		}
+		s.ColId = dc.Id
+	}
+
+	if existing != nil {
+		// overwrite the existing spider, keeping its bindings in this cluster
+		s.Id = existing.Id
+		s.DataSourceId = existing.DataSourceId
+		s.GitId = existing.GitId
+		s.IsGit = existing.IsGit
+		s.GitAutoSync = existing.GitAutoSync
+		if opts.ProjectId.IsZero() {
+			s.ProjectId = existing.ProjectId
+		}
+		if err := delegate.NewModelDelegate(&s, args...).Save(); err != nil {
+			return err
+		}
+		if err := svc.modelSvc.GetBaseService(interfaces.ModelIdSchedule).DeleteList(bson.M{"spider_id": s.Id}, args...); err != nil {
+			return err
+		}
+	} else {
+		// add new spider
+		if err := delegate.NewModelDelegate(&s, args...).Add(); err != nil {
+			return err
+		}
+		st := &models.SpiderStat{
+			Id: s.Id,
+		}
+		if err := delegate.NewModelDelegate(st, args...).Add(); err != nil {
+			return err
+		}
+	}
+
+	// tags, which are matched by name
+	s.Tags = as.Tags
+	if _, err := svc.modelSvc.UpdateTagsById(interfaces.ModelColNameSpider, s.Id, s.GetTags()); err != nil {
+		return err
+	}
+
+	// schedules, which are to be enabled by schedule service if enabled, where
+	// fires before import are not regarded as missed. Calendars are not
+	// exported, hence not kept as they are of the source instance
+	for _, sch := range as.Schedules {
+		sch.Id = primitive.NilObjectID
+		sch.SpiderId = s.Id
+		sch.EntryId = 0
+		sch.NodeIds = nil
+		sch.UserId = primitive.NilObjectID
+		sch.LastFireTs = time.Now()
+		sch.CalendarIds = nil
+		if sch.Mode == constants.RunTypeSelectedNodes {
+			sch.Mode = constants.RunTypeRandom
+		}
+		if opts.DisableSchedules {
+			sch.Enabled = false
+		}
+		if err := delegate.NewModelDelegate(&sch, args...).Add(); err != nil {
+			return err
+		}
+	}
+
+	// files
+	if err := svc.importFiles(s.Id, as.Dir, files, existing != nil); err != nil {
+		return err
+	}
+
+	res.Ids = append(res.Ids, s.Id)
+
+	return nil
+}
+
+// importFiles save files of the spider under its directory in the archive to
+// fs, where existing files are replaced if replace is true
+func (svc *Service) importFiles(id primitive.ObjectID, dir string, files map[string][]byte, replace bool) (err error) {
+	fsSvc, err := svc.syncSvc.GetFsService(id)
+	if err != nil {
+		return err
+	}
+	prefix := path.Join(archiveSpidersDirName, dir) + "/"
+	spiderFiles := map[string][]byte{}
+	for p, data := range files {
+		if strings.HasPrefix(p, prefix) {
+			spiderFiles[strings.TrimPrefix(p, prefix)] = data
+		}
+	}
+	return fsSvc.SaveFiles(spiderFiles, replace)
+}
+
+// getImportName name of the imported spider with a numeric suffix which is
+// not taken by any existing spider
+func (svc *Service) getImportName(name string) (newName string, err error) {
+	for i := 1; ; i++ {
+		newName = fmt.Sprintf("%s (%d)", name, i)
+		count, err := svc.modelSvc.GetBaseService(interfaces.ModelIdSpider).Count(bson.M{"name": newName})
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return newName, nil
+		}
+	}
+}
+
+// upsertDataCollection data collection of the name, which is added with an
+// index on task id of results if not exists
+func (svc *Service) upsertDataCollection(name string, args ...interface{}) (dc *models.DataCollection, err error) {
+	dc, err = svc.modelSvc.GetDataCollectionByName(name, nil)
+	if err == nil {
+		return dc, nil
+	}
+	if err != mongo2.ErrNoDocuments {
+		return nil, err
+	}
+	dc = &models.DataCollection{Name: name}
+	if err := delegate.NewModelDelegate(dc, args...).Add(); err != nil {
+		return nil, err
+	}
+	_ = mongo.GetMongoCol(dc.Name).CreateIndex(mongo2.IndexModel{Keys: bson.M{"_tid": 1}})
+	return dc, nil
+}
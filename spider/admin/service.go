@@ -1,19 +1,29 @@
 package admin
 
 import (
+	"fmt"
+	"github.com/apex/log"
 	"github.com/crawlab-team/go-trace"
+	"github.com/crawlab-team/goseaweedfs"
 	config2 "github.com/doubletrey/crawlab-core/config"
 	"github.com/doubletrey/crawlab-core/constants"
 	"github.com/doubletrey/crawlab-core/errors"
 	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/delegate"
 	"github.com/doubletrey/crawlab-core/models/models"
 	"github.com/doubletrey/crawlab-core/models/service"
 	"github.com/doubletrey/crawlab-core/node/config"
+	"github.com/doubletrey/crawlab-core/spider/git"
+	"github.com/doubletrey/crawlab-core/spider/sync"
 	"github.com/doubletrey/crawlab-core/task/scheduler"
+	"github.com/doubletrey/crawlab-db/mongo"
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/dig"
+	"sort"
+	"strings"
 )
 
 type Service struct {
@@ -21,6 +31,9 @@ type Service struct {
 	nodeCfgSvc   interfaces.NodeConfigService
 	modelSvc     service.ModelService
 	schedulerSvc interfaces.TaskSchedulerService
+	syncSvc      interfaces.SpiderSyncService
+	gitSvc       interfaces.SpiderGitService
+	scheduleSvc  interfaces.ScheduleService
 
 	// settings
 	cfgPath string
@@ -34,6 +47,10 @@ func (svc *Service) SetConfigPath(path string) {
 	svc.cfgPath = path
 }
 
+func (svc *Service) SetScheduleService(scheduleSvc interfaces.ScheduleService) {
+	svc.scheduleSvc = scheduleSvc
+}
+
 func (svc *Service) Schedule(id primitive.ObjectID, opts *interfaces.SpiderRunOptions) (err error) {
 	// spider
 	s, err := svc.modelSvc.GetSpiderById(id)
@@ -49,16 +66,148 @@ func (svc *Service) Schedule(id primitive.ObjectID, opts *interfaces.SpiderRunOp
 	return nil
 }
 
-func (svc *Service) Clone(id primitive.ObjectID, opts *interfaces.SpiderCloneOptions) (err error) {
-	// TODO: implement
-	return nil
+// Clone the spider model with its tags, environment variables and data
+// collection binding, as well as all its files, under a new id
+func (svc *Service) Clone(id primitive.ObjectID, opts *interfaces.SpiderCloneOptions, args ...interface{}) (newId primitive.ObjectID, err error) {
+	if opts == nil {
+		opts = &interfaces.SpiderCloneOptions{}
+	}
+
+	// spider
+	s, err := svc.modelSvc.GetSpiderById(id)
+	if err != nil {
+		return newId, err
+	}
+
+	// new spider, git settings are not cloned as they are bound to the repo of the original spider
+	sc := *s
+	sc.Id = primitive.NilObjectID
+	sc.GitId = primitive.NilObjectID
+	sc.Stat = nil
+	sc.Name = opts.Name
+	if sc.Name == "" {
+		sc.Name = fmt.Sprintf("%s (copy)", s.Name)
+	}
+	sc.Tags, err = svc.getTags(s.Id)
+	if err != nil {
+		return newId, err
+	}
+	if err := delegate.NewModelDelegate(&sc, args...).Add(); err != nil {
+		return newId, err
+	}
+
+	// new spider stat
+	st := &models.SpiderStat{
+		Id: sc.Id,
+	}
+	if err := delegate.NewModelDelegate(st, args...).Add(); err != nil {
+		return newId, err
+	}
+
+	// files
+	if err := svc.cloneFiles(s.Id, sc.Id); err != nil {
+		return newId, err
+	}
+
+	return sc.Id, nil
 }
 
-func (svc *Service) Delete(id primitive.ObjectID) (err error) {
-	panic("implement me")
+// Delete the spider together with its schedules and queued tasks, and
+// optionally its historical tasks with their results and logs. Files of the
+// spider are kept while it is in the recycle bin so that it can be restored
+// with them, and are removed once it is purged. Historical tasks are kept in
+// the recycle bin as well, whereas their results and logs are removed
+// permanently. Nothing is removed if DryRun is set, where only the removal
+// result is reported
+func (svc *Service) Delete(id primitive.ObjectID, opts *interfaces.SpiderDeleteOptions, args ...interface{}) (res *interfaces.SpiderDeleteResult, err error) {
+	if opts == nil {
+		opts = &interfaces.SpiderDeleteOptions{}
+	}
+
+	// spider
+	s, err := svc.modelSvc.GetSpiderById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// spider fs service
+	fsSvc, err := svc.syncSvc.GetFsService(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// collect what to be removed
+	res, err = svc.getDeleteResult(s, fsSvc, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		return res, nil
+	}
+
+	// schedules, which are kept in recycle bin so that they can be restored along with the spider
+	if len(res.ScheduleIds) > 0 {
+		enabledIds, err := svc.disableSchedules(res.ScheduleIds, args...)
+		if err != nil {
+			return nil, err
+		}
+		if err := svc.modelSvc.GetBaseService(interfaces.ModelIdSchedule).DeleteList(bson.M{"_id": bson.M{"$in": res.ScheduleIds}}, args...); err != nil {
+			return nil, err
+		}
+		if err := svc.setRecycleId(res.ScheduleIds, s.Id); err != nil {
+			return nil, err
+		}
+		if err := svc.setRecycledEnabled(enabledIds); err != nil {
+			return nil, err
+		}
+	}
+
+	// queued tasks
+	if len(res.QueuedTaskIds) > 0 {
+		if err := svc.modelSvc.GetBaseService(interfaces.ModelIdTaskQueue).ForceDeleteList(bson.M{"_id": bson.M{"$in": res.QueuedTaskIds}}); err != nil {
+			return nil, err
+		}
+		if err := svc.deleteTasks(res.QueuedTaskIds, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	// historical tasks with their results and logs
+	if len(res.TaskIds) > 0 {
+		if err := svc.deleteTasks(res.TaskIds, args...); err != nil {
+			return nil, err
+		}
+		if res.Results > 0 {
+			col, err := svc.getResultCol(s)
+			if err != nil {
+				return nil, err
+			}
+			if err := col.Delete(bson.M{"_tid": bson.M{"$in": res.TaskIds}}); err != nil {
+				return nil, trace.TraceError(err)
+			}
+		}
+		for _, taskId := range res.TaskIds {
+			if err := fsSvc.GetFsService().GetFs().DeleteDir(svc.getTaskLogPath(taskId)); err != nil {
+				log.Warnf("unable to delete logs of task[%s]: %v", taskId.Hex(), err)
+			}
+		}
+	}
+
+	// spider
+	if err := delegate.NewModelDelegate(s, args...).Delete(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
 }
 
 func (svc *Service) scheduleTasks(s *models.Spider, opts *interfaces.SpiderRunOptions) (err error) {
+	// commit of spider code
+	commit, err := svc.getCommit(s, opts)
+	if err != nil {
+		return err
+	}
+
 	// main task
 	mainTask := &models.Task{
 		SpiderId:   s.Id,
@@ -67,8 +216,11 @@ func (svc *Service) scheduleTasks(s *models.Spider, opts *interfaces.SpiderRunOp
 		NodeTags:   opts.NodeTags,
 		Cmd:        opts.Cmd,
 		Param:      opts.Param,
+		Envs:       getEnvs(opts),
 		ScheduleId: opts.ScheduleId,
 		Priority:   opts.Priority,
+		Ref:        opts.Ref,
+		Commit:     commit,
 		UserId:     opts.UserId,
 	}
 
@@ -91,8 +243,11 @@ func (svc *Service) scheduleTasks(s *models.Spider, opts *interfaces.SpiderRunOp
 				Mode:     opts.Mode,
 				Cmd:      s.Cmd,
 				Param:    opts.Param,
+				Envs:     getEnvs(opts),
 				NodeId:   nodeId,
 				Priority: opts.Priority,
+				Ref:      opts.Ref,
+				Commit:   commit,
 				UserId:   opts.UserId,
 			}
 			if err := svc.schedulerSvc.Enqueue(t); err != nil {
@@ -116,6 +271,28 @@ func (svc *Service) scheduleTasks(s *models.Spider, opts *interfaces.SpiderRunOp
 	return nil
 }
 
+// getCommit commit hash of spider code to run. Files at the commit resolved
+// from the git ref are saved to fs if the ref is given, otherwise it is the
+// commit at HEAD of the workspace, which is marked dirty if the files to run
+// have uncommitted changes, or empty if there is none
+func (svc *Service) getCommit(s *models.Spider, opts *interfaces.SpiderRunOptions) (commit string, err error) {
+	if opts.Ref != "" {
+		return svc.gitSvc.Snapshot(s.Id, opts.Ref)
+	}
+	return svc.gitSvc.GetWorkspaceCommit(s.Id)
+}
+
+// getEnvs environment variables of tasks in options sorted by name
+func getEnvs(opts *interfaces.SpiderRunOptions) (envs []models.Env) {
+	for name, value := range opts.Envs {
+		envs = append(envs, models.Env{Name: name, Value: value})
+	}
+	sort.Slice(envs, func(i, j int) bool {
+		return envs[i].Name < envs[j].Name
+	})
+	return envs
+}
+
 func (svc *Service) getNodeIds(opts *interfaces.SpiderRunOptions) (nodeIds []primitive.ObjectID, err error) {
 	if opts.Mode == constants.RunTypeAllNodes {
 		query := bson.M{
@@ -158,6 +335,235 @@ func (svc *Service) isMultiTask(opts *interfaces.SpiderRunOptions) (res bool) {
 	}
 }
 
+func (svc *Service) cloneFiles(id, newId primitive.ObjectID) (err error) {
+	fsSvc, err := svc.syncSvc.GetFsService(id)
+	if err != nil {
+		return err
+	}
+	newFsSvc, err := svc.syncSvc.GetFsService(newId)
+	if err != nil {
+		return err
+	}
+	files, err := svc.getFsFiles(fsSvc)
+	if err != nil {
+		return err
+	}
+	for _, filePath := range files {
+		data, err := fsSvc.GetFile(filePath)
+		if err != nil {
+			return err
+		}
+		if err := newFsSvc.Save(filePath, data); err != nil {
+			return err
+		}
+	}
+	return newFsSvc.GetFsService().SyncToWorkspace()
+}
+
+// getFsFiles paths of all files of the spider in fs relative to its fs path
+func (svc *Service) getFsFiles(fsSvc interfaces.SpiderFsService) (files []string, err error) {
+	fsPath := fsSvc.GetFsPath()
+	ok, err := fsSvc.GetFsService().GetFs().Exists(fsPath)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	items, err := fsSvc.GetFsService().GetFs().ListDir(fsPath, true)
+	if err != nil {
+		return nil, err
+	}
+	var walk func(items []goseaweedfs.FilerFileInfo)
+	walk = func(items []goseaweedfs.FilerFileInfo) {
+		for _, item := range items {
+			if item.IsDir {
+				walk(item.Children)
+				continue
+			}
+			files = append(files, strings.TrimPrefix(item.FullPath, fsPath))
+		}
+	}
+	walk(items)
+	return files, nil
+}
+
+func (svc *Service) getDeleteResult(s *models.Spider, fsSvc interfaces.SpiderFsService, opts *interfaces.SpiderDeleteOptions) (res *interfaces.SpiderDeleteResult, err error) {
+	res = &interfaces.SpiderDeleteResult{
+		DryRun: opts.DryRun,
+	}
+
+	// schedules
+	schedules, err := svc.modelSvc.GetScheduleList(bson.M{"spider_id": s.Id}, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, sch := range schedules {
+		res.ScheduleIds = append(res.ScheduleIds, sch.Id)
+	}
+
+	// queued tasks
+	queuedTasks, err := svc.modelSvc.GetTaskList(bson.M{
+		"spider_id": s.Id,
+		"status":    constants.TaskStatusPending,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range queuedTasks {
+		res.QueuedTaskIds = append(res.QueuedTaskIds, t.Id)
+	}
+
+	// historical tasks, excluding running ones which are left to finish
+	if opts.DeleteTasks {
+		tasks, err := svc.modelSvc.GetTaskList(bson.M{
+			"spider_id": s.Id,
+			"status": bson.M{
+				"$nin": []string{constants.TaskStatusPending, constants.TaskStatusRunning},
+			},
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			res.TaskIds = append(res.TaskIds, t.Id)
+		}
+	}
+
+	if len(res.TaskIds) > 0 {
+		// results
+		col, err := svc.getResultCol(s)
+		if err != nil {
+			return nil, err
+		}
+		if col != nil {
+			res.Results, err = col.Count(bson.M{"_tid": bson.M{"$in": res.TaskIds}})
+			if err != nil {
+				return nil, trace.TraceError(err)
+			}
+		}
+
+		// logs
+		for _, taskId := range res.TaskIds {
+			ok, err := fsSvc.GetFsService().GetFs().Exists(svc.getTaskLogPath(taskId))
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				res.Logs++
+			}
+		}
+	}
+
+	// files
+	res.Files, err = svc.getFsFiles(fsSvc)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// deleteTasks delete tasks of given ids with their stats and metrics
+func (svc *Service) deleteTasks(ids []primitive.ObjectID, args ...interface{}) (err error) {
+	query := bson.M{"_id": bson.M{"$in": ids}}
+	if err := svc.modelSvc.GetBaseService(interfaces.ModelIdTask).DeleteList(query, args...); err != nil {
+		return err
+	}
+	if err := svc.modelSvc.GetBaseService(interfaces.ModelIdTaskStat).ForceDeleteList(query); err != nil {
+		return err
+	}
+	if err := svc.modelSvc.GetBaseService(interfaces.ModelIdTaskMetric).ForceDeleteList(query); err != nil {
+		return err
+	}
+	return nil
+}
+
+// disableSchedules remove enabled schedules of given ids from cron before
+// they are deleted, and return ids of those which were enabled
+func (svc *Service) disableSchedules(ids []primitive.ObjectID, args ...interface{}) (enabledIds []primitive.ObjectID, err error) {
+	schedules, err := svc.modelSvc.GetScheduleList(bson.M{
+		"_id":     bson.M{"$in": ids},
+		"enabled": true,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, sch := range schedules {
+		sch := sch
+		if svc.scheduleSvc != nil {
+			if err := svc.scheduleSvc.Disable(&sch, args...); err != nil {
+				return nil, err
+			}
+		}
+		enabledIds = append(enabledIds, sch.Id)
+	}
+	return enabledIds, nil
+}
+
+// setRecycledEnabled mark schedules of given ids as enabled in their artifacts,
+// which are disabled before deletion, so that they are added back to cron once
+// restored from recycle bin
+func (svc *Service) setRecycledEnabled(ids []primitive.ObjectID) (err error) {
+	if len(ids) == 0 {
+		return nil
+	}
+	col := mongo.GetMongoCol(interfaces.ModelColNameArtifact)
+	if err := col.Update(bson.M{"_id": bson.M{"$in": ids}}, bson.M{
+		"$set": bson.M{"_obj.enabled": true},
+	}); err != nil {
+		return trace.TraceError(err)
+	}
+	return nil
+}
+
+// getTags tags of the document of given id kept in its artifact
+func (svc *Service) getTags(id primitive.ObjectID) (tags []models.Tag, err error) {
+	a, err := svc.modelSvc.GetArtifactById(id)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(a.TagIds) == 0 {
+		return nil, nil
+	}
+	return svc.modelSvc.GetTagList(bson.M{"_id": bson.M{"$in": a.TagIds}}, nil)
+}
+
+// setRecycleId tag artifacts of deleted documents with id of the document
+// they were deleted along with, by which they are restored or purged together
+func (svc *Service) setRecycleId(ids []primitive.ObjectID, recycleId primitive.ObjectID) (err error) {
+	col := mongo.GetMongoCol(interfaces.ModelColNameArtifact)
+	if err := col.Update(bson.M{"_id": bson.M{"$in": ids}}, bson.M{
+		"$set": bson.M{"_sys.recycle_id": recycleId},
+	}); err != nil {
+		return trace.TraceError(err)
+	}
+	return nil
+}
+
+// getResultCol collection of results of the spider, which is nil if no data collection is bound
+func (svc *Service) getResultCol(s *models.Spider) (col *mongo.Col, err error) {
+	if s.ColId.IsZero() {
+		return nil, nil
+	}
+	dc, err := svc.modelSvc.GetDataCollectionById(s.ColId)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return mongo.GetMongoCol(dc.Name), nil
+}
+
+// getTaskLogPath path of task log directory in fs
+func (svc *Service) getTaskLogPath(id primitive.ObjectID) (path string) {
+	return fmt.Sprintf("/logs/%s", id.Hex())
+}
+
 func NewSpiderAdminService(opts ...Option) (svc2 interfaces.SpiderAdminService, err error) {
 	svc := &Service{
 		cfgPath: config2.DefaultConfigPath,
@@ -179,10 +585,18 @@ func NewSpiderAdminService(opts ...Option) (svc2 interfaces.SpiderAdminService,
 	if err := c.Provide(scheduler.ProvideGetTaskSchedulerService(svc.cfgPath)); err != nil {
 		return nil, trace.TraceError(err)
 	}
-	if err := c.Invoke(func(nodeCfgSvc interfaces.NodeConfigService, modelSvc service.ModelService, schedulerSvc interfaces.TaskSchedulerService) {
+	if err := c.Provide(sync.ProvideSpiderSyncService(svc.cfgPath)); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Provide(git.ProvideGetSpiderGitService(svc.cfgPath)); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Invoke(func(nodeCfgSvc interfaces.NodeConfigService, modelSvc service.ModelService, schedulerSvc interfaces.TaskSchedulerService, syncSvc interfaces.SpiderSyncService, gitSvc interfaces.SpiderGitService) {
 		svc.nodeCfgSvc = nodeCfgSvc
 		svc.modelSvc = modelSvc
 		svc.schedulerSvc = schedulerSvc
+		svc.syncSvc = syncSvc
+		svc.gitSvc = gitSvc
 	}); err != nil {
 		return nil, trace.TraceError(err)
 	}
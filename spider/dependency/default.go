@@ -0,0 +1,23 @@
+package dependency
+
+import (
+	"github.com/apex/log"
+	"github.com/mitchellh/go-homedir"
+	"path"
+)
+
+func init() {
+	rootDir, err := homedir.Dir()
+	if err != nil {
+		log.Warnf("cannot find home directory: %v", err)
+		return
+	}
+	DefaultDependencyPath = path.Join(rootDir, "crawlab_dependencies")
+}
+
+var DefaultDependencyPath string
+
+const (
+	DefaultPythonCmd = "python3"
+	DefaultNpmCmd    = "npm"
+)
@@ -0,0 +1,19 @@
+package dependency
+
+import (
+	"github.com/doubletrey/crawlab-core/interfaces"
+)
+
+type Option func(svc interfaces.SpiderDependencyService)
+
+func WithConfigPath(path string) Option {
+	return func(svc interfaces.SpiderDependencyService) {
+		svc.SetConfigPath(path)
+	}
+}
+
+func WithDependencyPathBase(path string) Option {
+	return func(svc interfaces.SpiderDependencyService) {
+		svc.SetDependencyPathBase(path)
+	}
+}
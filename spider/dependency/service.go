@@ -0,0 +1,460 @@
+package dependency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/apex/log"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/client"
+	"github.com/doubletrey/crawlab-core/models/delegate"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/doubletrey/crawlab-core/node/config"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/dig"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	readyFileName = ".ready"
+	maxLogsLength = 64 * 1024
+)
+
+// Service builds dependency environments of spiders on current node, which are
+// cached under base path and keyed by hash of dependency files, e.g.
+// <base>/<spider_id>/python-<hash> for python venv and
+// <base>/<spider_id>/node-<hash>/node_modules for node modules.
+// Environments are held by running tasks, so that outdated ones are only
+// removed once no task uses them
+type Service struct {
+	// dependencies
+	nodeCfgSvc interfaces.NodeConfigService
+	modelSvc   service.ModelService
+
+	// settings
+	cfgPath  string
+	pathBase string
+
+	// internals
+	locks   sync.Map                        // locks of building dependency environments by spider and type
+	mu      sync.Mutex                      // lock of references below
+	refs    map[string]int                  // number of tasks holding each environment
+	held    map[primitive.ObjectID][]string // environments held by each task
+	current map[string]string               // latest environment by spider and type
+}
+
+func (svc *Service) GetConfigPath() (path string) {
+	return svc.cfgPath
+}
+
+func (svc *Service) SetConfigPath(path string) {
+	svc.cfgPath = path
+}
+
+func (svc *Service) SetDependencyPathBase(path string) {
+	svc.pathBase = path
+}
+
+func (svc *Service) Prepare(id primitive.ObjectID, s interfaces.Spider, nodeId primitive.ObjectID, workspacePath string) (envs []string, err error) {
+	var paths []string
+
+	// python
+	if pythonPath, err := svc.prepare(id, s, nodeId, workspacePath, constants.DependencyEnvTypePython); err != nil {
+		svc.Release(id)
+		return nil, err
+	} else if pythonPath != "" {
+		envs = append(envs, "VIRTUAL_ENV="+pythonPath)
+		paths = append(paths, filepath.Join(pythonPath, "bin"))
+	}
+
+	// node
+	if nodePath, err := svc.prepare(id, s, nodeId, workspacePath, constants.DependencyEnvTypeNode); err != nil {
+		svc.Release(id)
+		return nil, err
+	} else if nodePath != "" {
+		envs = append(envs, "NODE_PATH="+filepath.Join(nodePath, "node_modules"))
+		paths = append(paths, filepath.Join(nodePath, "node_modules", ".bin"))
+	}
+
+	// executables
+	if len(paths) > 0 {
+		paths = append(paths, os.Getenv("PATH"))
+		envs = append(envs, "PATH="+strings.Join(paths, string(os.PathListSeparator)))
+	}
+
+	return envs, nil
+}
+
+func (svc *Service) Release(id primitive.ObjectID) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	envPaths := svc.held[id]
+	delete(svc.held, id)
+	for _, envPath := range envPaths {
+		svc.refs[envPath]--
+		if svc.refs[envPath] > 0 {
+			continue
+		}
+		delete(svc.refs, envPath)
+		if envPath == svc.current[svc.getEnvKey(envPath)] {
+			continue
+		}
+		if err := os.RemoveAll(envPath); err != nil {
+			trace.PrintError(err)
+		}
+	}
+}
+
+// prepare build dependency environment of given type if not cached, and
+// return its path, which is empty if there are no dependency files
+func (svc *Service) prepare(id primitive.ObjectID, s interfaces.Spider, nodeId primitive.ObjectID, workspacePath, envType string) (envPath string, err error) {
+	// hash of dependency files
+	files := svc.getDependencyFiles(workspacePath, envType)
+	if len(files) == 0 {
+		return "", nil
+	}
+	hash, err := svc.getHash(files)
+	if err != nil {
+		return "", err
+	}
+	envPath = filepath.Join(svc.pathBase, s.GetId().Hex(), envType+"-"+hash)
+
+	// hold the environment before checking, so that it is not removed as an
+	// outdated one meanwhile
+	svc.hold(id, envPath)
+
+	// cached
+	if svc.isReady(envPath) {
+		svc.setCurrent(envPath)
+		return envPath, nil
+	}
+
+	// lock
+	lockKey := s.GetId().Hex() + envType
+	res, _ := svc.locks.LoadOrStore(lockKey, &sync.Mutex{})
+	mu := res.(*sync.Mutex)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// cached by another runner while waiting for lock
+	if svc.isReady(envPath) {
+		svc.setCurrent(envPath)
+		return envPath, nil
+	}
+
+	// dependency env
+	e := &models.DependencyEnv{
+		SpiderId: s.GetId(),
+		NodeId:   nodeId,
+		Type:     envType,
+		Hash:     hash,
+		Status:   constants.DependencyEnvStatusInstalling,
+	}
+	if err := svc.save(e); err != nil {
+		trace.PrintError(err)
+	}
+
+	// install
+	log.Infof("installing %s dependencies of spider[%s] to %s", envType, s.GetId().Hex(), envPath)
+	logs, err := svc.install(envPath, workspacePath, envType, files)
+	e.Logs = logs
+	if err != nil {
+		_ = os.RemoveAll(envPath)
+		e.Status = constants.DependencyEnvStatusError
+		e.Error = err.Error()
+		if err := svc.save(e); err != nil {
+			trace.PrintError(err)
+		}
+		return "", trace.TraceError(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(envPath, readyFileName), []byte(time.Now().String()), os.ModePerm); err != nil {
+		return "", trace.TraceError(err)
+	}
+	e.Status = constants.DependencyEnvStatusReady
+	if err := svc.save(e); err != nil {
+		trace.PrintError(err)
+	}
+
+	// remove outdated dependency environments of the same type
+	svc.setCurrent(envPath)
+	svc.cleanup(envPath, envType)
+
+	return envPath, nil
+}
+
+func (svc *Service) install(envPath, workspacePath, envType string, files []string) (logs string, err error) {
+	if err := os.MkdirAll(envPath, os.ModePerm); err != nil {
+		return "", trace.TraceError(err)
+	}
+
+	// commands
+	var cmds []*exec.Cmd
+	timeout := viper.GetDuration("dependency.timeout")
+	if timeout == 0 {
+		timeout = 30 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	switch envType {
+	case constants.DependencyEnvTypePython:
+		python := viper.GetString("dependency.python")
+		if python == "" {
+			python = DefaultPythonCmd
+		}
+		cmds = append(cmds, exec.CommandContext(ctx, python, "-m", "venv", envPath))
+		cmds = append(cmds, exec.CommandContext(ctx, filepath.Join(envPath, "bin", "pip"), "install", "-r", files[0]))
+	case constants.DependencyEnvTypeNode:
+		npm := viper.GetString("dependency.npm")
+		if npm == "" {
+			npm = DefaultNpmCmd
+		}
+		for _, f := range files {
+			data, err := ioutil.ReadFile(f)
+			if err != nil {
+				return "", trace.TraceError(err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(envPath, filepath.Base(f)), data, os.ModePerm); err != nil {
+				return "", trace.TraceError(err)
+			}
+		}
+		if len(files) > 1 {
+			// package-lock.json exists
+			cmds = append(cmds, exec.CommandContext(ctx, npm, "ci"))
+		} else {
+			cmds = append(cmds, exec.CommandContext(ctx, npm, "install"))
+		}
+	}
+
+	// execute
+	buf := bytes.NewBuffer(nil)
+	for _, cmd := range cmds {
+		if cmd.Dir == "" {
+			cmd.Dir = envPath
+		}
+		cmd.Stdout = buf
+		cmd.Stderr = buf
+		_, _ = fmt.Fprintf(buf, "$ %s\n", strings.Join(cmd.Args, " "))
+		if err := cmd.Run(); err != nil {
+			return svc.trimLogs(buf.String()), err
+		}
+	}
+
+	return svc.trimLogs(buf.String()), nil
+}
+
+// getDependencyFiles paths of existing dependency files of given type in workspace,
+// where the main dependency file comes first
+func (svc *Service) getDependencyFiles(workspacePath, envType string) (files []string) {
+	var names []string
+	switch envType {
+	case constants.DependencyEnvTypePython:
+		names = []string{"requirements.txt"}
+	case constants.DependencyEnvTypeNode:
+		names = []string{"package.json", "package-lock.json"}
+	}
+	for i, name := range names {
+		p := filepath.Join(workspacePath, name)
+		if _, err := os.Stat(p); err != nil {
+			if i == 0 {
+				return nil
+			}
+			continue
+		}
+		files = append(files, p)
+	}
+	return files
+}
+
+func (svc *Service) getHash(files []string) (hash string, err error) {
+	h := sha256.New()
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return "", trace.TraceError(err)
+		}
+		h.Write([]byte(filepath.Base(f)))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+func (svc *Service) isReady(envPath string) (ok bool) {
+	_, err := os.Stat(filepath.Join(envPath, readyFileName))
+	return err == nil
+}
+
+// cleanup remove dependency environments of the same type other than the
+// given one, which are not held by any task
+func (svc *Service) cleanup(envPath, envType string) {
+	dirs, err := filepath.Glob(filepath.Join(filepath.Dir(envPath), envType+"-*"))
+	if err != nil {
+		return
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	for _, dir := range dirs {
+		if dir == envPath || svc.refs[dir] > 0 {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			trace.PrintError(err)
+		}
+	}
+}
+
+// hold add reference of the task to the environment
+func (svc *Service) hold(id primitive.ObjectID, envPath string) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.refs[envPath]++
+	svc.held[id] = append(svc.held[id], envPath)
+}
+
+// setCurrent set the environment as the latest one of its spider and type
+func (svc *Service) setCurrent(envPath string) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.current[svc.getEnvKey(envPath)] = envPath
+}
+
+// getEnvKey key of the spider and type of the environment, i.e. the path
+// without hash
+func (svc *Service) getEnvKey(envPath string) (key string) {
+	if i := strings.LastIndex(envPath, "-"); i > 0 {
+		return envPath[:i]
+	}
+	return envPath
+}
+
+func (svc *Service) trimLogs(logs string) (res string) {
+	if len(logs) > maxLogsLength {
+		return logs[len(logs)-maxLogsLength:]
+	}
+	return logs
+}
+
+// save add or update install status of dependency environment
+func (svc *Service) save(e *models.DependencyEnv) (err error) {
+	e.UpdateTs = time.Now()
+
+	// model base service
+	var modelSvc interfaces.ModelBaseService
+	if svc.nodeCfgSvc.IsMaster() {
+		modelSvc = svc.modelSvc.GetBaseService(interfaces.ModelIdDependencyEnv)
+	} else {
+		modelSvc, err = client.NewBaseServiceDelegate(
+			client.WithBaseServiceModelId(interfaces.ModelIdDependencyEnv),
+			client.WithBaseServiceConfigPath(svc.cfgPath),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// existing dependency env
+	if e.Id.IsZero() {
+		query := bson.M{
+			"spider_id": e.SpiderId,
+			"node_id":   e.NodeId,
+			"type":      e.Type,
+		}
+		total, err := modelSvc.Count(query)
+		if err != nil {
+			return err
+		}
+		if total > 0 {
+			doc, err := modelSvc.Get(query, nil)
+			if err != nil {
+				return err
+			}
+			e.Id = doc.GetId()
+		}
+	}
+
+	// model delegate
+	var d interfaces.ModelDelegate
+	if svc.nodeCfgSvc.IsMaster() {
+		d = delegate.NewModelDelegate(e)
+	} else {
+		d = client.NewModelDelegate(e, client.WithDelegateConfigPath(svc.cfgPath))
+	}
+	if e.Id.IsZero() {
+		return d.Add()
+	}
+	return d.Save()
+}
+
+func NewSpiderDependencyService(opts ...Option) (svc2 interfaces.SpiderDependencyService, err error) {
+	// service
+	svc := &Service{
+		pathBase: DefaultDependencyPath,
+		refs:     map[string]int{},
+		held:     map[primitive.ObjectID][]string{},
+		current:  map[string]string{},
+	}
+
+	// dependency path
+	if viper.GetString("dependency.path") != "" {
+		svc.pathBase = viper.GetString("dependency.path")
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	// dependency injection
+	c := dig.New()
+	if err := c.Provide(config.ProvideConfigService(svc.cfgPath)); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Provide(service.GetService); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Invoke(func(nodeCfgSvc interfaces.NodeConfigService, modelSvc service.ModelService) {
+		svc.nodeCfgSvc = nodeCfgSvc
+		svc.modelSvc = modelSvc
+	}); err != nil {
+		return nil, trace.TraceError(err)
+	}
+
+	return svc, nil
+}
+
+var store = sync.Map{}
+
+func GetSpiderDependencyService(path string, opts ...Option) (svc interfaces.SpiderDependencyService, err error) {
+	res, ok := store.Load(path)
+	if ok {
+		svc, ok = res.(interfaces.SpiderDependencyService)
+		if ok {
+			return svc, nil
+		}
+	}
+	opts = append(opts, WithConfigPath(path))
+	svc, err = NewSpiderDependencyService(opts...)
+	if err != nil {
+		return nil, err
+	}
+	store.Store(path, svc)
+	return svc, nil
+}
+
+func ProvideGetSpiderDependencyService(path string, opts ...Option) func() (svc interfaces.SpiderDependencyService, err error) {
+	return func() (svc interfaces.SpiderDependencyService, err error) {
+		return GetSpiderDependencyService(path, opts...)
+	}
+}
@@ -0,0 +1,233 @@
+package dependency
+
+import (
+	"errors"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fake python creating a venv whose pip records installed requirements
+const testPythonScript = `#!/bin/sh
+echo "$3" >> "$(dirname "$0")/installs.log"
+mkdir -p "$3/bin"
+printf '#!/bin/sh\ncat "$3" > "%s/installed"\n' "$3" > "$3/bin/pip"
+chmod +x "$3/bin/pip"
+`
+
+type testNodeConfigService struct {
+	interfaces.NodeConfigService
+}
+
+func (svc *testNodeConfigService) IsMaster() bool {
+	return true
+}
+
+type testModelService struct {
+	service.ModelService
+}
+
+func (svc *testModelService) GetBaseService(id interfaces.ModelId) (svc2 interfaces.ModelBaseService) {
+	return &testModelBaseService{}
+}
+
+// testModelBaseService install status is not saved in tests
+type testModelBaseService struct {
+	interfaces.ModelBaseService
+}
+
+func (svc *testModelBaseService) Count(query bson.M) (total int, err error) {
+	return 0, errors.New("not available")
+}
+
+type testEnv struct {
+	svc       *Service
+	dir       string
+	workspace string
+	spider    *models.Spider
+}
+
+func newTestEnv(t *testing.T) (e *testEnv) {
+	dir, err := ioutil.TempDir("", "crawlab-dependency")
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	// fake python
+	python := filepath.Join(dir, "python")
+	require.Nil(t, ioutil.WriteFile(python, []byte(testPythonScript), 0755))
+	viper.Set("dependency.python", python)
+	t.Cleanup(func() { viper.Set("dependency.python", "") })
+
+	e = &testEnv{
+		svc: &Service{
+			nodeCfgSvc: &testNodeConfigService{},
+			modelSvc:   &testModelService{},
+			pathBase:   filepath.Join(dir, "envs"),
+			refs:       map[string]int{},
+			held:       map[primitive.ObjectID][]string{},
+			current:    map[string]string{},
+		},
+		dir:       dir,
+		workspace: filepath.Join(dir, "workspace"),
+		spider:    &models.Spider{Id: primitive.NewObjectID()},
+	}
+	require.Nil(t, os.MkdirAll(e.workspace, os.ModePerm))
+	return e
+}
+
+func (e *testEnv) writeRequirements(t *testing.T, content string) {
+	require.Nil(t, ioutil.WriteFile(filepath.Join(e.workspace, "requirements.txt"), []byte(content), 0644))
+}
+
+func (e *testEnv) getInstalls(t *testing.T) (installs []string) {
+	data, err := ioutil.ReadFile(filepath.Join(e.dir, "installs.log"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.Nil(t, err)
+	return strings.Fields(string(data))
+}
+
+func getVirtualEnv(envs []string) (p string) {
+	for _, env := range envs {
+		if strings.HasPrefix(env, "VIRTUAL_ENV=") {
+			return strings.TrimPrefix(env, "VIRTUAL_ENV=")
+		}
+	}
+	return ""
+}
+
+func TestService_GetHash(t *testing.T) {
+	e := newTestEnv(t)
+	svc := e.svc
+
+	e.writeRequirements(t, "requests==2.25.1\n")
+	files := svc.getDependencyFiles(e.workspace, constants.DependencyEnvTypePython)
+	require.Equal(t, []string{filepath.Join(e.workspace, "requirements.txt")}, files)
+	h1, err := svc.getHash(files)
+	require.Nil(t, err)
+	require.Len(t, h1, 16)
+
+	// same content
+	h2, err := svc.getHash(files)
+	require.Nil(t, err)
+	require.Equal(t, h1, h2)
+
+	// changed content
+	e.writeRequirements(t, "requests==2.26.0\n")
+	h3, err := svc.getHash(files)
+	require.Nil(t, err)
+	require.NotEqual(t, h1, h3)
+
+	// file names are part of the hash
+	p := filepath.Join(e.workspace, "package.json")
+	require.Nil(t, ioutil.WriteFile(p, []byte("requests==2.26.0\n"), 0644))
+	h4, err := svc.getHash([]string{p})
+	require.Nil(t, err)
+	require.NotEqual(t, h3, h4)
+}
+
+func TestService_GetDependencyFiles(t *testing.T) {
+	e := newTestEnv(t)
+	svc := e.svc
+	require.Nil(t, svc.getDependencyFiles(e.workspace, constants.DependencyEnvTypePython))
+
+	// lock file without main dependency file
+	lock := filepath.Join(e.workspace, "package-lock.json")
+	require.Nil(t, ioutil.WriteFile(lock, []byte("{}"), 0644))
+	require.Nil(t, svc.getDependencyFiles(e.workspace, constants.DependencyEnvTypeNode))
+
+	// main dependency file comes first
+	pkg := filepath.Join(e.workspace, "package.json")
+	require.Nil(t, ioutil.WriteFile(pkg, []byte("{}"), 0644))
+	require.Equal(t, []string{pkg, lock}, svc.getDependencyFiles(e.workspace, constants.DependencyEnvTypeNode))
+}
+
+func TestService_Prepare(t *testing.T) {
+	e := newTestEnv(t)
+	svc := e.svc
+
+	// no dependency files
+	envs, err := svc.Prepare(primitive.NewObjectID(), e.spider, primitive.NewObjectID(), e.workspace)
+	require.Nil(t, err)
+	require.Empty(t, envs)
+
+	// installed
+	e.writeRequirements(t, "requests\n")
+	t1 := primitive.NewObjectID()
+	envs, err = svc.Prepare(t1, e.spider, primitive.NewObjectID(), e.workspace)
+	require.Nil(t, err)
+	p := getVirtualEnv(envs)
+	require.True(t, strings.HasPrefix(p, filepath.Join(svc.pathBase, e.spider.Id.Hex(), "python-")))
+	require.True(t, svc.isReady(p))
+	data, err := ioutil.ReadFile(filepath.Join(p, "installed"))
+	require.Nil(t, err)
+	require.Equal(t, "requests\n", string(data))
+	require.Contains(t, envs, "PATH="+filepath.Join(p, "bin")+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// reused
+	t2 := primitive.NewObjectID()
+	envs, err = svc.Prepare(t2, e.spider, primitive.NewObjectID(), e.workspace)
+	require.Nil(t, err)
+	require.Equal(t, p, getVirtualEnv(envs))
+	require.Equal(t, []string{p}, e.getInstalls(t))
+	require.Equal(t, 2, svc.refs[p])
+}
+
+func TestService_Prepare_Error(t *testing.T) {
+	e := newTestEnv(t)
+	svc := e.svc
+	viper.Set("dependency.python", "false")
+
+	e.writeRequirements(t, "requests\n")
+	id := primitive.NewObjectID()
+	_, err := svc.Prepare(id, e.spider, primitive.NewObjectID(), e.workspace)
+	require.NotNil(t, err)
+	dirs, err := filepath.Glob(filepath.Join(svc.pathBase, e.spider.Id.Hex(), "python-*"))
+	require.Nil(t, err)
+	require.Empty(t, dirs)
+	require.Empty(t, svc.held[id])
+	require.Empty(t, svc.refs)
+}
+
+func TestService_Release(t *testing.T) {
+	e := newTestEnv(t)
+	svc := e.svc
+
+	// task 1 runs on the old environment
+	e.writeRequirements(t, "requests==2.25.1\n")
+	t1 := primitive.NewObjectID()
+	envs, err := svc.Prepare(t1, e.spider, primitive.NewObjectID(), e.workspace)
+	require.Nil(t, err)
+	p1 := getVirtualEnv(envs)
+
+	// task 2 runs on the new environment, where the old one is still held
+	e.writeRequirements(t, "requests==2.26.0\n")
+	t2 := primitive.NewObjectID()
+	envs, err = svc.Prepare(t2, e.spider, primitive.NewObjectID(), e.workspace)
+	require.Nil(t, err)
+	p2 := getVirtualEnv(envs)
+	require.NotEqual(t, p1, p2)
+	require.True(t, svc.isReady(p1))
+
+	// the old environment is removed once released
+	svc.Release(t1)
+	_, err = os.Stat(p1)
+	require.True(t, os.IsNotExist(err))
+
+	// the latest environment is kept
+	svc.Release(t2)
+	require.True(t, svc.isReady(p2))
+	require.Empty(t, svc.refs)
+	require.Empty(t, svc.held)
+}
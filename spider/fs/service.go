@@ -3,6 +3,7 @@ package fs
 import (
 	"fmt"
 	"github.com/crawlab-team/go-trace"
+	"github.com/crawlab-team/goseaweedfs"
 	"github.com/doubletrey/crawlab-core/errors"
 	"github.com/doubletrey/crawlab-core/fs"
 	"github.com/doubletrey/crawlab-core/interfaces"
@@ -10,9 +11,14 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"os"
 	"path"
+	"strings"
 	"sync"
 )
 
+// refsDirName directory under fs and workspace base paths to keep spider files
+// at specific commits, which is apart from the latest files of spiders
+const refsDirName = ".refs"
+
 // Service implementation of interfaces.SpiderFsService
 // It is a wrapper of interfaces.FsService that manages a spider's fs related functions
 type Service struct {
@@ -68,6 +74,36 @@ func (svc *Service) Save(path string, data []byte) (err error) {
 	return svc.fsSvc.Save(path, data)
 }
 
+// SaveFiles save files keyed by path in one batch, which are synced to
+// workspace only once. Existing files not in the batch are deleted if replace
+// is true, after all files are saved
+func (svc *Service) SaveFiles(files map[string][]byte, replace bool) (err error) {
+	// normalized paths
+	paths := map[string]bool{}
+	for p, data := range files {
+		p = path.Join("/", p)
+		if err := svc.fsSvc.Save(p, data, interfaces.WithNotSyncToWorkspace()); err != nil {
+			return err
+		}
+		paths[p] = true
+	}
+
+	// delete stale files
+	if replace {
+		stalePaths, err := svc.getStalePaths(paths)
+		if err != nil {
+			return err
+		}
+		for _, p := range stalePaths {
+			if err := svc.fsSvc.Delete(p, interfaces.WithNotSyncToWorkspace()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return svc.fsSvc.SyncToWorkspace()
+}
+
 func (svc *Service) Rename(path, newPath string) (err error) {
 	return svc.fsSvc.Rename(path, newPath)
 }
@@ -96,6 +132,14 @@ func (svc *Service) GetRepoPath() (res string) {
 	return fmt.Sprintf("%s/%s", svc.repoPathBase, svc.id.Hex())
 }
 
+func (svc *Service) GetRefFsPath(hash string) (res string) {
+	return path.Join(svc.fsPathBase, refsDirName, svc.id.Hex(), hash)
+}
+
+func (svc *Service) GetRefWorkspacePath(name string) (res string) {
+	return path.Join(svc.workspacePathBase, refsDirName, svc.id.Hex(), name)
+}
+
 func (svc *Service) SetFsPathBase(path string) {
 	svc.fsPathBase = path
 }
@@ -112,6 +156,49 @@ func (svc *Service) GetFsService() (fsSvc interfaces.FsService) {
 	return svc.fsSvc
 }
 
+// getStalePaths paths of existing files and directories which contain none
+// of the given paths
+func (svc *Service) getStalePaths(paths map[string]bool) (stalePaths []string, err error) {
+	fsPath := svc.GetFsPath()
+	ok, err := svc.fsSvc.GetFs().Exists(fsPath)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	items, err := svc.fsSvc.GetFs().ListDir(fsPath, true)
+	if err != nil {
+		return nil, err
+	}
+	var walk func(items []goseaweedfs.FilerFileInfo)
+	walk = func(items []goseaweedfs.FilerFileInfo) {
+		for _, item := range items {
+			p := strings.TrimPrefix(item.FullPath, fsPath)
+			if !item.IsDir {
+				if !paths[p] {
+					stalePaths = append(stalePaths, p)
+				}
+				continue
+			}
+			var used bool
+			for savedPath := range paths {
+				if strings.HasPrefix(savedPath, p+"/") {
+					used = true
+					break
+				}
+			}
+			if !used {
+				stalePaths = append(stalePaths, p)
+				continue
+			}
+			walk(item.Children)
+		}
+	}
+	walk(items)
+	return stalePaths, nil
+}
+
 func (svc *Service) getFsPath(p string) (res string) {
 	return path.Join(svc.GetFsPath(), p)
 }
@@ -0,0 +1,10 @@
+package git
+
+import "time"
+
+// DefaultSyncFrequency frequency of git auto sync if not set in spider
+const DefaultSyncFrequency = 1 * time.Hour
+
+// refMarkerFileName file written last when saving spider files at a commit to
+// fs, which indicates the files are complete
+const refMarkerFileName = ".crawlab_commit"
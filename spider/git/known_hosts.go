@@ -0,0 +1,63 @@
+package git
+
+import (
+	"fmt"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io/ioutil"
+	"net"
+	"os"
+)
+
+// getHostKeyCallback host key callback of ssh remotes verifying host keys
+// against the known_hosts lines and files, which is set on the auth of git
+// operations. Errors of unknown or mismatched host keys are reported as
+// errors.ErrorGitUnknownHostKey
+func getHostKeyCallback(knownHosts string, knownHostsFiles ...string) (callback ssh.HostKeyCallback, err error) {
+	knownHostsCallback, err := getKnownHostsCallback(knownHosts, knownHostsFiles...)
+	if err != nil {
+		return nil, err
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if err := knownHostsCallback(hostname, remote, key); err != nil {
+			return errors.NewGitError(fmt.Sprintf("%s: %s: %v", errors.ErrorGitUnknownHostKey.Error(), hostname, err))
+		}
+		return nil
+	}, nil
+}
+
+// getKnownHostsCallback host key callback of known_hosts lines and files,
+// where files not exist are ignored
+func getKnownHostsCallback(knownHosts string, knownHostsFiles ...string) (callback ssh.HostKeyCallback, err error) {
+	// temporary file of known_hosts lines
+	f, err := ioutil.TempFile("", "crawlab-known-hosts-")
+	if err != nil {
+		return nil, trace.TraceError(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(knownHosts + "\n")
+	_ = f.Close()
+	if err != nil {
+		return nil, trace.TraceError(err)
+	}
+
+	// files
+	files := []string{f.Name()}
+	for _, filePath := range knownHostsFiles {
+		if filePath == "" {
+			continue
+		}
+		if _, err := os.Stat(filePath); err != nil {
+			continue
+		}
+		files = append(files, filePath)
+	}
+
+	callback, err = knownhosts.New(files...)
+	if err != nil {
+		return nil, errors.NewGitError(fmt.Sprintf("%s: %v", errors.ErrorGitUnknownHostKey.Error(), err))
+	}
+	return callback, nil
+}
@@ -0,0 +1,101 @@
+package git
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"net"
+	"testing"
+)
+
+// startTestSshServer start ssh server on a random local port which rejects all
+// authentications, returning its address and host key
+func startTestSshServer(t *testing.T) (addr string, hostKey ssh.PublicKey) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.Nil(t, err)
+	cfg := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			return nil, fmt.Errorf("unauthorized")
+		},
+	}
+	cfg.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _, _, _ = ssh.NewServerConn(conn, cfg)
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	return l.Addr().String(), signer.PublicKey()
+}
+
+// listRemote list refs of the ssh remote with host key verified by the callback
+func listRemote(t *testing.T, url string, callback ssh.HostKeyCallback) (err error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.Nil(t, err)
+	r := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{url}})
+	_, err = r.List(&git.ListOptions{Auth: &gitssh.PublicKeys{
+		User:   "git",
+		Signer: signer,
+		HostKeyCallbackHelper: gitssh.HostKeyCallbackHelper{
+			HostKeyCallback: callback,
+		},
+	}})
+	return err
+}
+
+func TestGetHostKeyCallback(t *testing.T) {
+	addr, hostKey := startTestSshServer(t)
+	host, port, err := net.SplitHostPort(addr)
+	require.Nil(t, err)
+	url := fmt.Sprintf("ssh://git@%s:%s/crawlab-team/example.git", host, port)
+
+	// known host, where authentication fails after host key is verified
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey)
+	callback, err := getHostKeyCallback(line)
+	require.Nil(t, err)
+	err = listRemote(t, url, callback)
+	require.NotNil(t, err)
+	require.NotContains(t, err.Error(), errors.ErrorGitUnknownHostKey.Error())
+
+	// unknown host
+	callback, err = getHostKeyCallback("")
+	require.Nil(t, err)
+	err = listRemote(t, url, callback)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), errors.ErrorGitUnknownHostKey.Error())
+
+	// mismatched host key
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(otherKey)
+	require.Nil(t, err)
+	line = knownhosts.Line([]string{knownhosts.Normalize(addr)}, otherSigner.PublicKey())
+	callback, err = getHostKeyCallback(line)
+	require.Nil(t, err)
+	err = listRemote(t, url, callback)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), errors.ErrorGitUnknownHostKey.Error())
+}
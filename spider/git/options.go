@@ -0,0 +1,20 @@
+package git
+
+import (
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"time"
+)
+
+type Option func(svc interfaces.SpiderGitService)
+
+func WithConfigPath(path string) Option {
+	return func(svc interfaces.SpiderGitService) {
+		svc.SetConfigPath(path)
+	}
+}
+
+func WithInterval(interval time.Duration) Option {
+	return func(svc interfaces.SpiderGitService) {
+		svc.SetInterval(interval)
+	}
+}
@@ -0,0 +1,744 @@
+package git
+
+import (
+	"fmt"
+	"github.com/apex/log"
+	vcs "github.com/crawlab-team/crawlab-vcs"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/config"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-core/models/service"
+	nodeconfig "github.com/doubletrey/crawlab-core/node/config"
+	"github.com/doubletrey/crawlab-core/node/leader"
+	spidersync "github.com/doubletrey/crawlab-core/spider/sync"
+	"github.com/doubletrey/crawlab-core/utils"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/mitchellh/go-homedir"
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/dig"
+	"golang.org/x/crypto/ssh"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// frequencyParser parser of git sync frequency in cron expression with
+// optional seconds field or descriptors such as "@every 5m"
+var frequencyParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// Service git service of spiders on master, which pulls the remote git repo
+// of spiders and periodically syncs those with git auto sync enabled
+type Service struct {
+	// dependencies
+	nodeCfgSvc interfaces.NodeConfigService
+	modelSvc   service.ModelService
+	syncSvc    interfaces.SpiderSyncService
+	leaderSvc  interfaces.NodeLeaderService
+
+	// settings
+	cfgPath  string
+	interval time.Duration
+
+	// internals
+	col     *mongo.Col
+	locks   sync.Map
+	stopped bool
+}
+
+func (svc *Service) GetConfigPath() (path string) {
+	return svc.cfgPath
+}
+
+func (svc *Service) SetConfigPath(path string) {
+	svc.cfgPath = path
+}
+
+func (svc *Service) GetInterval() (interval time.Duration) {
+	return svc.interval
+}
+
+func (svc *Service) SetInterval(interval time.Duration) {
+	svc.interval = interval
+}
+
+func (svc *Service) Init() (err error) {
+	return nil
+}
+
+func (svc *Service) Start() {
+	for {
+		if svc.stopped {
+			return
+		}
+
+		// only the leader among masters syncs spiders
+		if svc.leaderSvc.IsLeader() {
+			svc.syncDue()
+		}
+
+		time.Sleep(svc.interval)
+	}
+}
+
+func (svc *Service) Wait() {
+	utils.DefaultWait()
+	svc.Stop()
+}
+
+func (svc *Service) Stop() {
+	svc.stopped = true
+}
+
+func (svc *Service) GetGitClient(id primitive.ObjectID) (gitClient *vcs.GitClient, err error) {
+	gitClient, _, err = svc.getGitClient(id)
+	return gitClient, err
+}
+
+func (svc *Service) GetGitAuth(id primitive.ObjectID) (auth transport.AuthMethod, err error) {
+	g, err := svc.modelSvc.GetGitById(id)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, trace.TraceError(err)
+	}
+	return svc.getAuth(g)
+}
+
+func (svc *Service) GetRemoteRefs(id primitive.ObjectID, remoteName string) (refs []vcs.GitRef, err error) {
+	// git client
+	gitClient, g, err := svc.getGitClient(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// remote
+	r, err := gitClient.GetRemote(remoteName)
+	if err != nil {
+		if err == git.ErrRemoteNotFound {
+			return nil, nil
+		}
+		return nil, trace.TraceError(err)
+	}
+
+	// auth
+	var auth transport.AuthMethod
+	if g != nil {
+		auth, err = svc.getAuth(g)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// branches and tags
+	remoteRefs, err := r.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		return nil, trace.TraceError(err)
+	}
+	for _, ref := range remoteRefs {
+		var refType string
+		if ref.Name().IsBranch() {
+			refType = vcs.GitRefTypeBranch
+		} else if ref.Name().IsTag() {
+			refType = vcs.GitRefTypeTag
+		} else {
+			continue
+		}
+		refs = append(refs, vcs.GitRef{
+			Type:     refType,
+			Name:     ref.Name().Short(),
+			FullName: ref.Name().String(),
+			Hash:     ref.Hash().String(),
+		})
+	}
+
+	// timestamps of commits in local logs, latest first
+	logs, err := gitClient.GetLogs()
+	if err != nil {
+		return nil, err
+	}
+	logsMap := map[string]vcs.GitLog{}
+	for _, l := range logs {
+		logsMap[l.Hash] = l
+	}
+	for i, ref := range refs {
+		if l, ok := logsMap[ref.Hash]; ok {
+			refs[i].Timestamp = l.Timestamp
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Timestamp.Unix() > refs[j].Timestamp.Unix()
+	})
+
+	return refs, nil
+}
+
+func (svc *Service) Pull(id primitive.ObjectID, branch string) (hash string, err error) {
+	// forbidden if not master
+	if !svc.nodeCfgSvc.IsMaster() {
+		return "", trace.TraceError(errors.ErrorSpiderForbidden)
+	}
+
+	// lock to avoid concurrent pulls of the same spider
+	mu := svc.getLock(id)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// git
+	g, err := svc.modelSvc.GetGitById(id)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return "", trace.TraceError(errors.ErrorSpiderMissingGit)
+		}
+		return "", err
+	}
+
+	// git client
+	gitClient, err := svc.GetGitClient(id)
+	if err != nil {
+		return "", err
+	}
+
+	// auth
+	auth, err := svc.getAuth(g)
+	if err != nil {
+		return "", err
+	}
+
+	// upstream remote
+	if err := svc.ensureUpstreamRemote(gitClient, g); err != nil {
+		return "", err
+	}
+
+	// by default current branch
+	if branch == "" {
+		branch, err = gitClient.GetCurrentBranch()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// refuse to pull if there are local changes, which would otherwise be
+	// discarded or conflict with the pulled commit
+	wt, err := gitClient.GetRepository().Worktree()
+	if err != nil {
+		return "", trace.TraceError(err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return "", trace.TraceError(err)
+	}
+	if !status.IsClean() {
+		return "", errors.ErrorGitDirtyWorktree
+	}
+
+	// fetch and fast-forward
+	if err := gitClient.Pull(
+		vcs.WithRemoteNamePull(constants.GitRemoteNameUpstream),
+		vcs.WithBranchNamePull(branch),
+		vcs.WithAuthPull(auth),
+	); err != nil {
+		return "", trace.TraceError(err)
+	}
+
+	// hash of pulled commit
+	head, err := gitClient.GetRepository().Head()
+	if err != nil {
+		return "", trace.TraceError(err)
+	}
+	hash = head.Hash().String()
+
+	// sync to fs
+	fsSvc, err := svc.syncSvc.GetFsService(id)
+	if err != nil {
+		return "", err
+	}
+	if err := fsSvc.GetFsService().SyncToFs(interfaces.WithOnlyFromWorkspace()); err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
+func (svc *Service) Sync(id primitive.ObjectID) (err error) {
+	// spider
+	s, err := svc.modelSvc.GetSpiderById(id)
+	if err != nil {
+		return err
+	}
+
+	// pull
+	hash, err := svc.Pull(id, s.GitBranch)
+
+	// record result
+	update := bson.M{
+		"git_sync_ts": time.Now(),
+	}
+	if err != nil {
+		update["git_sync_error"] = err.Error()
+	} else {
+		update["git_sync_error"] = ""
+		update["git_sync_commit"] = hash
+	}
+	if err := svc.col.UpdateId(id, bson.M{"$set": update}); err != nil {
+		return trace.TraceError(err)
+	}
+
+	return err
+}
+
+func (svc *Service) GetHeadCommit(id primitive.ObjectID) (hash string, err error) {
+	gitClient, _, err := svc.getGitClient(id)
+	if err != nil {
+		return "", err
+	}
+	head, err := gitClient.GetRepository().Head()
+	if err != nil {
+		return "", trace.TraceError(err)
+	}
+	return head.Hash().String(), nil
+}
+
+func (svc *Service) GetWorkspaceCommit(id primitive.ObjectID) (commit string, err error) {
+	// lock to avoid reading the workspace during pulls
+	mu := svc.getLock(id)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// git client, which is absent if the spider is not a git repo
+	fsSvc, err := svc.syncSvc.GetFsService(id)
+	if err != nil {
+		return "", err
+	}
+	gitClient := fsSvc.GetFsService().GetGitClient()
+	if gitClient == nil {
+		return "", nil
+	}
+
+	// head commit, which is absent if nothing is committed yet
+	head, err := gitClient.GetRepository().Head()
+	if err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			return "", nil
+		}
+		return "", trace.TraceError(err)
+	}
+	commit = head.Hash().String()
+
+	// local changes
+	wt, err := gitClient.GetRepository().Worktree()
+	if err != nil {
+		return "", trace.TraceError(err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return "", trace.TraceError(err)
+	}
+	if !status.IsClean() {
+		commit += constants.GitCommitDirtySuffix
+	}
+
+	return commit, nil
+}
+
+func (svc *Service) Snapshot(id primitive.ObjectID, ref string) (hash string, err error) {
+	// forbidden if not master
+	if !svc.nodeCfgSvc.IsMaster() {
+		return "", trace.TraceError(errors.ErrorSpiderForbidden)
+	}
+
+	// lock to avoid concurrent snapshots or pulls of the same spider
+	mu := svc.getLock(id)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// git client
+	gitClient, _, err := svc.getGitClient(id)
+	if err != nil {
+		return "", err
+	}
+
+	// commit
+	c, err := svc.resolveCommit(gitClient, ref)
+	if err != nil {
+		return "", err
+	}
+	hash = c.Hash.String()
+
+	// skip if already saved, which is indicated by the marker file written last
+	fsSvc, err := svc.syncSvc.GetFsService(id)
+	if err != nil {
+		return "", err
+	}
+	fs := fsSvc.GetFsService().GetFs()
+	refFsPath := fsSvc.GetRefFsPath(hash)
+	markerPath := path.Join(refFsPath, refMarkerFileName)
+	if ok, err := fs.Exists(markerPath); err == nil && ok {
+		return hash, nil
+	}
+
+	// export files of the commit to temporary directory
+	tmpDir, err := ioutil.TempDir("", "crawlab-ref-")
+	if err != nil {
+		return "", trace.TraceError(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := svc.exportCommit(c, tmpDir); err != nil {
+		return "", err
+	}
+
+	// save to fs
+	if err := fs.SyncLocalToRemote(tmpDir, refFsPath); err != nil {
+		return "", trace.TraceError(err)
+	}
+	if err := fs.UpdateFile(markerPath, []byte(hash)); err != nil {
+		return "", trace.TraceError(err)
+	}
+
+	return hash, nil
+}
+
+// syncDue sync spiders with git auto sync enabled whose next sync time is due
+func (svc *Service) syncDue() {
+	spiders, err := svc.modelSvc.GetSpiderList(bson.M{"git_auto_sync": true}, nil)
+	if err != nil {
+		trace.PrintError(err)
+		return
+	}
+
+	now := time.Now()
+	for _, s := range spiders {
+		due, err := svc.isDue(&s, now)
+		if err != nil {
+			if err := svc.col.UpdateId(s.Id, bson.M{"$set": bson.M{"git_sync_error": err.Error()}}); err != nil {
+				trace.PrintError(err)
+			}
+			continue
+		}
+		if !due {
+			continue
+		}
+		if err := svc.Sync(s.Id); err != nil {
+			log.Warnf("git sync of spider[%s] failed: %v", s.Id.Hex(), err)
+		}
+	}
+}
+
+func (svc *Service) isDue(s *models.Spider, now time.Time) (ok bool, err error) {
+	if s.GitSyncTs.IsZero() {
+		return true, nil
+	}
+	sch, err := svc.parseFrequency(s.GitSyncFrequency)
+	if err != nil {
+		return false, err
+	}
+	return !sch.Next(s.GitSyncTs).After(now), nil
+}
+
+// parseFrequency parse git sync frequency given as duration (e.g. "5m") or as
+// cron expression, which is DefaultSyncFrequency if empty
+func (svc *Service) parseFrequency(freq string) (sch cron.Schedule, err error) {
+	if freq == "" {
+		return cron.Every(DefaultSyncFrequency), nil
+	}
+	if d, err := time.ParseDuration(freq); err == nil && d > 0 {
+		return cron.Every(d), nil
+	}
+	sch, err = frequencyParser.Parse(freq)
+	if err != nil {
+		return nil, errors.NewSpiderError(fmt.Sprintf("%s: %v", errors.ErrorSpiderInvalidGitSyncFreq.Error(), err))
+	}
+	return sch, nil
+}
+
+// ensureUpstreamRemote create upstream remote of the git url if not exists,
+// or re-create it if its url does not match
+func (svc *Service) ensureUpstreamRemote(gitClient *vcs.GitClient, g *models.Git) (err error) {
+	r, err := gitClient.GetRemote(constants.GitRemoteNameUpstream)
+	if err != nil {
+		if err != git.ErrRemoteNotFound {
+			return trace.TraceError(err)
+		}
+	} else {
+		if len(r.Config().URLs) > 0 && r.Config().URLs[0] == g.Url {
+			return nil
+		}
+		if err := gitClient.DeleteRemote(constants.GitRemoteNameUpstream); err != nil {
+			return trace.TraceError(err)
+		}
+	}
+	if _, err := gitClient.CreateRemote(&gitconfig.RemoteConfig{
+		Name: constants.GitRemoteNameUpstream,
+		URLs: []string{g.Url},
+	}); err != nil {
+		return trace.TraceError(err)
+	}
+	return nil
+}
+
+// resolveCommit commit of the git ref, which is a tag, a local branch, a
+// (short) commit hash, or a branch of upstream remote
+func (svc *Service) resolveCommit(gitClient *vcs.GitClient, ref string) (c *object.Commit, err error) {
+	if ref == "" {
+		return nil, trace.TraceError(errors.ErrorSpiderInvalidGitRef)
+	}
+	r := gitClient.GetRepository()
+	h, err := r.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		h, err = r.ResolveRevision(plumbing.Revision(fmt.Sprintf("%s/%s", constants.GitRemoteNameUpstream, ref)))
+		if err != nil {
+			return nil, errors.NewSpiderError(fmt.Sprintf("%s: %s", errors.ErrorSpiderInvalidGitRef.Error(), ref))
+		}
+	}
+	c, err = r.CommitObject(*h)
+	if err != nil {
+		return nil, trace.TraceError(err)
+	}
+	return c, nil
+}
+
+// exportCommit write files of the commit to the directory
+func (svc *Service) exportCommit(c *object.Commit, dir string) (err error) {
+	files, err := c.Files()
+	if err != nil {
+		return trace.TraceError(err)
+	}
+	return files.ForEach(func(f *object.File) error {
+		// skip submodules and symlinks
+		if !f.Mode.IsFile() {
+			return nil
+		}
+		content, err := f.Contents()
+		if err != nil {
+			return trace.TraceError(err)
+		}
+		filePath := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+			return trace.TraceError(err)
+		}
+		mode, err := f.Mode.ToOSFileMode()
+		if err != nil {
+			mode = os.FileMode(0644)
+		}
+		if err := ioutil.WriteFile(filePath, []byte(content), mode); err != nil {
+			return trace.TraceError(err)
+		}
+		return nil
+	})
+}
+
+// getGitClient git client of the spider workspace together with its git
+// settings, which are nil if not exist. Auth is not set on the client, and
+// is given to remote operations by getAuth instead
+func (svc *Service) getGitClient(id primitive.ObjectID) (gitClient *vcs.GitClient, g *models.Git, err error) {
+	// spider fs service
+	fsSvc, err := svc.syncSvc.GetFsService(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// git client
+	gitClient = fsSvc.GetFsService().GetGitClient()
+	if gitClient == nil {
+		return nil, nil, trace.TraceError(errors.ErrorSpiderMissingGit)
+	}
+
+	// git
+	g, err = svc.modelSvc.GetGitById(id)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return gitClient, nil, nil
+		}
+		return nil, nil, trace.TraceError(err)
+	}
+
+	return gitClient, g, nil
+}
+
+// getAuth auth of remote operations with the git settings, where host keys
+// of ssh remotes are verified against known hosts if host key checking is
+// enabled. It is nil if no credential is given
+func (svc *Service) getAuth(g *models.Git) (auth transport.AuthMethod, err error) {
+	switch g.AuthType {
+	case constants.GitAuthTypeHttp:
+		if g.Username == "" && g.Password == "" {
+			return nil, nil
+		}
+		return &githttp.BasicAuth{
+			Username: g.Username,
+			Password: g.Password,
+		}, nil
+	case constants.GitAuthTypeSsh:
+		// private key in git settings or deploy key
+		privateKey, passphrase := g.Password, ""
+		if !g.SshKeyId.IsZero() {
+			k, err := svc.modelSvc.GetSshKeyById(g.SshKeyId)
+			if err != nil {
+				return nil, trace.TraceError(err)
+			}
+			privateKey, passphrase, err = DecryptSshKey(k)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if privateKey == "" {
+			return nil, nil
+		}
+		var signer ssh.Signer
+		if passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(privateKey))
+		}
+		if err != nil {
+			return nil, trace.TraceError(err)
+		}
+
+		// host key callback
+		callback := ssh.InsecureIgnoreHostKey()
+		if svc.isStrictHostKey(g) {
+			callback, err = getHostKeyCallback(g.KnownHosts, svc.getKnownHostsFile())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return &gitssh.PublicKeys{
+			User:   svc.getSshUsername(g),
+			Signer: signer,
+			HostKeyCallbackHelper: gitssh.HostKeyCallbackHelper{
+				HostKeyCallback: callback,
+			},
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// getSshUsername username of ssh remote, which is the user in git url if not
+// set in git settings, or "git" if neither
+func (svc *Service) getSshUsername(g *models.Git) (username string) {
+	if g.Username != "" {
+		return g.Username
+	}
+	if ep, err := transport.NewEndpoint(g.Url); err == nil && ep.User != "" {
+		return ep.User
+	}
+	return "git"
+}
+
+// isStrictHostKey whether to verify host key of ssh remote, which is enabled
+// in git settings or globally by "git.ssh.strictHostKey" in config
+func (svc *Service) isStrictHostKey(g *models.Git) (ok bool) {
+	return g.StrictHostKey || viper.GetBool("git.ssh.strictHostKey")
+}
+
+// getKnownHostsFile known hosts file set by "git.ssh.knownHostsFile" in
+// config, which is ~/.ssh/known_hosts by default
+func (svc *Service) getKnownHostsFile() (filePath string) {
+	if viper.GetString("git.ssh.knownHostsFile") != "" {
+		return viper.GetString("git.ssh.knownHostsFile")
+	}
+	homeDir, err := homedir.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".ssh", "known_hosts")
+}
+
+func (svc *Service) getLock(id primitive.ObjectID) (mu *sync.Mutex) {
+	res, _ := svc.locks.LoadOrStore(id, &sync.Mutex{})
+	return res.(*sync.Mutex)
+}
+
+func NewSpiderGitService(opts ...Option) (svc2 interfaces.SpiderGitService, err error) {
+	// service
+	svc := &Service{
+		cfgPath:  config.DefaultConfigPath,
+		interval: 1 * time.Minute,
+		col:      mongo.GetMongoCol(interfaces.ModelColNameSpider),
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	// dependency injection
+	c := dig.New()
+	if err := c.Provide(nodeconfig.ProvideConfigService(svc.cfgPath)); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Provide(service.NewService); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Provide(spidersync.ProvideSpiderSyncService(svc.cfgPath)); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Provide(leader.ProvideGetNodeLeaderService(svc.cfgPath)); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Invoke(func(nodeCfgSvc interfaces.NodeConfigService, modelSvc service.ModelService, syncSvc interfaces.SpiderSyncService, leaderSvc interfaces.NodeLeaderService) {
+		svc.nodeCfgSvc = nodeCfgSvc
+		svc.modelSvc = modelSvc
+		svc.syncSvc = syncSvc
+		svc.leaderSvc = leaderSvc
+	}); err != nil {
+		return nil, trace.TraceError(err)
+	}
+
+	// initialize
+	if err := svc.Init(); err != nil {
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+var store = sync.Map{}
+
+func GetSpiderGitService(path string, opts ...Option) (svc interfaces.SpiderGitService, err error) {
+	if path == "" {
+		path = config.DefaultConfigPath
+	}
+	res, ok := store.Load(path)
+	if ok {
+		svc, ok = res.(interfaces.SpiderGitService)
+		if ok {
+			return svc, nil
+		}
+	}
+	opts = append(opts, WithConfigPath(path))
+	svc, err = NewSpiderGitService(opts...)
+	if err != nil {
+		return nil, err
+	}
+	store.Store(path, svc)
+	return svc, nil
+}
+
+func ProvideGetSpiderGitService(path string, opts ...Option) func() (svc interfaces.SpiderGitService, err error) {
+	return func() (svc interfaces.SpiderGitService, err error) {
+		return GetSpiderGitService(path, opts...)
+	}
+}
@@ -0,0 +1,106 @@
+package git
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-core/utils"
+	"golang.org/x/crypto/ssh"
+	"strings"
+	"time"
+)
+
+// rsaKeyBits size of generated rsa keys
+const rsaKeyBits = 4096
+
+// GenerateSshKey generate a new ssh key of the given type (ed25519 by
+// default) with encrypted private key
+func GenerateSshKey(name, keyType string) (k *models.SshKey, err error) {
+	var key interface{}
+	switch keyType {
+	case "", constants.GitSshKeyTypeEd25519:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case constants.GitSshKeyTypeRsa:
+		key, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		return nil, errors.NewGitError(fmt.Sprintf("%s: unsupported type %s", errors.ErrorGitInvalidSshKey.Error(), keyType))
+	}
+	if err != nil {
+		return nil, trace.TraceError(err)
+	}
+
+	// private key in PEM format
+	data, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, trace.TraceError(err)
+	}
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: data}))
+
+	return NewSshKey(name, privateKey, "")
+}
+
+// NewSshKey ssh key of the given private key in PEM format, which is
+// validated with the passphrase if it is encrypted
+func NewSshKey(name, privateKey, passphrase string) (k *models.SshKey, err error) {
+	// signer
+	var signer ssh.Signer
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey([]byte(privateKey))
+	}
+	if err != nil {
+		return nil, errors.NewGitError(fmt.Sprintf("%s: %v", errors.ErrorGitInvalidSshKey.Error(), err))
+	}
+	pub := signer.PublicKey()
+
+	// public key with name as comment
+	publicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+	if name != "" {
+		publicKey = fmt.Sprintf("%s %s", publicKey, name)
+	}
+
+	k = &models.SshKey{
+		Name:        name,
+		Type:        pub.Type(),
+		PublicKey:   publicKey,
+		Fingerprint: ssh.FingerprintSHA256(pub),
+		Ts:          time.Now(),
+	}
+
+	// encrypt
+	k.PrivateKey, err = utils.EncryptAES(privateKey)
+	if err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if passphrase != "" {
+		k.Passphrase, err = utils.EncryptAES(passphrase)
+		if err != nil {
+			return nil, trace.TraceError(err)
+		}
+	}
+
+	return k, nil
+}
+
+// DecryptSshKey decrypted private key and passphrase of the ssh key
+func DecryptSshKey(k *models.SshKey) (privateKey, passphrase string, err error) {
+	privateKey, err = utils.DecryptAES(k.PrivateKey)
+	if err != nil {
+		return "", "", trace.TraceError(err)
+	}
+	if k.Passphrase != "" {
+		passphrase, err = utils.DecryptAES(k.Passphrase)
+		if err != nil {
+			return "", "", trace.TraceError(err)
+		}
+	}
+	return privateKey, passphrase, nil
+}
@@ -0,0 +1,68 @@
+package git
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSshKey(t *testing.T) {
+	k, err := GenerateSshKey("deploy", "")
+	require.Nil(t, err)
+	require.Equal(t, ssh.KeyAlgoED25519, k.Type)
+	require.True(t, strings.HasPrefix(k.PublicKey, ssh.KeyAlgoED25519+" "))
+	require.True(t, strings.HasSuffix(k.PublicKey, " deploy"))
+	require.True(t, strings.HasPrefix(k.Fingerprint, "SHA256:"))
+	require.NotContains(t, k.PrivateKey, "PRIVATE KEY")
+
+	// decrypted private key matches public key
+	privateKey, passphrase, err := DecryptSshKey(k)
+	require.Nil(t, err)
+	require.Empty(t, passphrase)
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	require.Nil(t, err)
+	require.Equal(t, k.Fingerprint, ssh.FingerprintSHA256(signer.PublicKey()))
+
+	// rsa
+	k, err = GenerateSshKey("deploy", constants.GitSshKeyTypeRsa)
+	require.Nil(t, err)
+	require.Equal(t, ssh.KeyAlgoRSA, k.Type)
+
+	// unsupported type
+	_, err = GenerateSshKey("deploy", "dsa")
+	require.NotNil(t, err)
+}
+
+func TestNewSshKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), []byte("secret"), x509.PEMCipherAES256)
+	require.Nil(t, err)
+	privateKey := string(pem.EncodeToMemory(block))
+
+	// passphrase required
+	_, err = NewSshKey("deploy", privateKey, "")
+	require.NotNil(t, err)
+	_, err = NewSshKey("deploy", privateKey, "wrong")
+	require.NotNil(t, err)
+
+	// encrypted private key and passphrase
+	k, err := NewSshKey("deploy", privateKey, "secret")
+	require.Nil(t, err)
+	require.Equal(t, ssh.KeyAlgoRSA, k.Type)
+	require.NotEqual(t, "secret", k.Passphrase)
+	decryptedKey, passphrase, err := DecryptSshKey(k)
+	require.Nil(t, err)
+	require.Equal(t, privateKey, decryptedKey)
+	require.Equal(t, "secret", passphrase)
+
+	// invalid key
+	_, err = NewSshKey("deploy", "invalid", "")
+	require.NotNil(t, err)
+}
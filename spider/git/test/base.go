@@ -0,0 +1,112 @@
+package test
+
+import (
+	vcs "github.com/crawlab-team/crawlab-vcs"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/service"
+	ntest "github.com/doubletrey/crawlab-core/node/test"
+	"github.com/doubletrey/crawlab-core/spider/git"
+	"github.com/doubletrey/crawlab-core/spider/sync"
+	"go.uber.org/dig"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func init() {
+	var err error
+	T, err = NewTest()
+	if err != nil {
+		panic(err)
+	}
+}
+
+var T *Test
+
+type Test struct {
+	// dependencies
+	modelSvc service.ModelService
+	syncSvc  interfaces.SpiderSyncService
+	gitSvc   interfaces.SpiderGitService
+
+	// data
+	RemoteRepoPath string
+	DevRepoPath    string
+	DevRepo        *vcs.GitClient
+	FileName       string
+}
+
+// Setup a bare repo as git remote of spiders, which is pushed to from a dev repo
+func (t *Test) Setup(t2 *testing.T) {
+	var err error
+	t.Cleanup()
+
+	if err := vcs.CreateBareGitRepo(t.RemoteRepoPath); err != nil {
+		panic(err)
+	}
+	t.DevRepo, err = vcs.NewGitClient(
+		vcs.WithPath(t.DevRepoPath),
+		vcs.WithRemoteUrl(t.RemoteRepoPath),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	t2.Cleanup(t.Cleanup)
+}
+
+func (t *Test) Cleanup() {
+	_ = t.modelSvc.GetBaseService(interfaces.ModelIdSpider).ForceDeleteList(nil)
+	_ = t.modelSvc.GetBaseService(interfaces.ModelIdGit).ForceDeleteList(nil)
+	_ = os.RemoveAll(t.RemoteRepoPath)
+	_ = os.RemoveAll(t.DevRepoPath)
+}
+
+// Commit file with given content in dev repo and push to remote
+func (t *Test) Commit(content string) (err error) {
+	if err := ioutil.WriteFile(filepath.Join(t.DevRepoPath, t.FileName), []byte(content), os.ModePerm); err != nil {
+		return err
+	}
+	if err := t.DevRepo.CommitAll("update " + t.FileName); err != nil {
+		return err
+	}
+	return t.DevRepo.Push(vcs.WithRemoteNamePush(vcs.GitRemoteNameOrigin))
+}
+
+func NewTest() (t *Test, err error) {
+	// test
+	t = &Test{
+		FileName: "main.py",
+	}
+	t.RemoteRepoPath, err = filepath.Abs("./tmp/remote_repo")
+	if err != nil {
+		return nil, err
+	}
+	t.DevRepoPath, err = filepath.Abs("./tmp/dev_repo")
+	if err != nil {
+		return nil, err
+	}
+
+	// dependency injection
+	cfgPath := ntest.T.MasterSvc.GetConfigPath()
+	c := dig.New()
+	if err := c.Provide(service.GetService); err != nil {
+		return nil, err
+	}
+	if err := c.Provide(sync.ProvideSpiderSyncService(cfgPath)); err != nil {
+		return nil, err
+	}
+	if err := c.Provide(git.ProvideGetSpiderGitService(cfgPath)); err != nil {
+		return nil, err
+	}
+	if err := c.Invoke(func(modelSvc service.ModelService, syncSvc interfaces.SpiderSyncService, gitSvc interfaces.SpiderGitService) {
+		t.modelSvc = modelSvc
+		t.syncSvc = syncSvc
+		t.gitSvc = gitSvc
+	}); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
@@ -0,0 +1,187 @@
+package test
+
+import (
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/models/delegate"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpiderGitService_Sync(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// spider with git remote
+	s := &models.Spider{
+		Name:        "test_spider",
+		GitBranch:   "master",
+		GitAutoSync: true,
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	g := &models.Git{Id: s.Id, Url: T.RemoteRepoPath}
+	err = delegate.NewModelDelegate(g).Add()
+	require.Nil(t, err)
+
+	// initial commit
+	err = T.Commit("print('v1')")
+	require.Nil(t, err)
+
+	// sync
+	err = T.gitSvc.Sync(s.Id)
+	require.Nil(t, err)
+	s, err = T.modelSvc.GetSpiderById(s.Id)
+	require.Nil(t, err)
+	require.Empty(t, s.GitSyncError)
+	require.False(t, s.GitSyncTs.IsZero())
+	logs, err := T.DevRepo.GetLogs()
+	require.Nil(t, err)
+	require.Equal(t, logs[0].Hash, s.GitSyncCommit)
+
+	// files in fs
+	fsSvc, err := T.syncSvc.GetFsService(s.Id)
+	require.Nil(t, err)
+	data, err := fsSvc.GetFile(T.FileName)
+	require.Nil(t, err)
+	require.Equal(t, "print('v1')", string(data))
+
+	// new commit to be fast-forwarded
+	err = T.Commit("print('v2')")
+	require.Nil(t, err)
+	err = T.gitSvc.Sync(s.Id)
+	require.Nil(t, err)
+	s, err = T.modelSvc.GetSpiderById(s.Id)
+	require.Nil(t, err)
+	logs, err = T.DevRepo.GetLogs()
+	require.Nil(t, err)
+	require.Equal(t, logs[0].Hash, s.GitSyncCommit)
+	data, err = fsSvc.GetFile(T.FileName)
+	require.Nil(t, err)
+	require.Equal(t, "print('v2')", string(data))
+}
+
+func TestSpiderGitService_SyncError(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// spider without git remote
+	s := &models.Spider{
+		Name:        "test_spider",
+		GitAutoSync: true,
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+
+	// sync
+	err = T.gitSvc.Sync(s.Id)
+	require.NotNil(t, err)
+	s, err = T.modelSvc.GetSpiderById(s.Id)
+	require.Nil(t, err)
+	require.NotEmpty(t, s.GitSyncError)
+	require.Empty(t, s.GitSyncCommit)
+}
+
+func TestSpiderGitService_PullDirty(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	s := &models.Spider{
+		Name:      "test_spider",
+		GitBranch: "master",
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	g := &models.Git{Id: s.Id, Url: T.RemoteRepoPath}
+	err = delegate.NewModelDelegate(g).Add()
+	require.Nil(t, err)
+	err = T.Commit("print('v1')")
+	require.Nil(t, err)
+	hash, err := T.gitSvc.Pull(s.Id, "")
+	require.Nil(t, err)
+	commit, err := T.gitSvc.GetWorkspaceCommit(s.Id)
+	require.Nil(t, err)
+	require.Equal(t, hash, commit)
+
+	// local change in workspace
+	gitClient, err := T.gitSvc.GetGitClient(s.Id)
+	require.Nil(t, err)
+	filePath := filepath.Join(gitClient.GetPath(), T.FileName)
+	err = ioutil.WriteFile(filePath, []byte("print('local')"), os.ModePerm)
+	require.Nil(t, err)
+
+	// pull is refused, where the local change is kept
+	err = T.Commit("print('v2')")
+	require.Nil(t, err)
+	_, err = T.gitSvc.Pull(s.Id, "")
+	require.Equal(t, errors.ErrorGitDirtyWorktree, err)
+	data, err := ioutil.ReadFile(filePath)
+	require.Nil(t, err)
+	require.Equal(t, "print('local')", string(data))
+	head, err := T.gitSvc.GetHeadCommit(s.Id)
+	require.Nil(t, err)
+	require.Equal(t, hash, head)
+	commit, err = T.gitSvc.GetWorkspaceCommit(s.Id)
+	require.Nil(t, err)
+	require.Equal(t, hash+constants.GitCommitDirtySuffix, commit)
+}
+
+func TestSpiderGitService_Snapshot(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// spider with git remote
+	s := &models.Spider{
+		Name:      "test_spider",
+		GitBranch: "master",
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	g := &models.Git{Id: s.Id, Url: T.RemoteRepoPath}
+	err = delegate.NewModelDelegate(g).Add()
+	require.Nil(t, err)
+
+	// two commits
+	err = T.Commit("print('v1')")
+	require.Nil(t, err)
+	logs, err := T.DevRepo.GetLogs()
+	require.Nil(t, err)
+	hashV1 := logs[0].Hash
+	err = T.Commit("print('v2')")
+	require.Nil(t, err)
+	err = T.gitSvc.Sync(s.Id)
+	require.Nil(t, err)
+
+	// head commit
+	head, err := T.gitSvc.GetHeadCommit(s.Id)
+	require.Nil(t, err)
+	require.NotEqual(t, hashV1, head)
+
+	// snapshot of the older commit by short hash
+	hash, err := T.gitSvc.Snapshot(s.Id, hashV1[:7])
+	require.Nil(t, err)
+	require.Equal(t, hashV1, hash)
+	fsSvc, err := T.syncSvc.GetFsService(s.Id)
+	require.Nil(t, err)
+	data, err := fsSvc.GetFsService().GetFs().GetFile(fsSvc.GetRefFsPath(hash) + "/" + T.FileName)
+	require.Nil(t, err)
+	require.Equal(t, "print('v1')", string(data))
+
+	// latest files are not affected
+	data, err = fsSvc.GetFile(T.FileName)
+	require.Nil(t, err)
+	require.Equal(t, "print('v2')", string(data))
+
+	// snapshot of branch
+	hash, err = T.gitSvc.Snapshot(s.Id, "master")
+	require.Nil(t, err)
+	require.Equal(t, head, hash)
+
+	// invalid ref
+	_, err = T.gitSvc.Snapshot(s.Id, "not-exists")
+	require.NotNil(t, err)
+}
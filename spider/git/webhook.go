@@ -0,0 +1,191 @@
+package git
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"net/http"
+	"strings"
+)
+
+// Webhook push webhook of github, gitlab or gitea parsed from request
+type Webhook struct {
+	Provider   string
+	Event      string
+	DeliveryId string
+	IsPush     bool
+	RepoUrls   []string // urls of the pushed repo in different protocols
+	Branch     string   // pushed branch, empty if a tag is pushed
+	Commit     string   // hash of head commit after push
+
+	header http.Header
+	body   []byte
+}
+
+type webhookPayload struct {
+	Ref         string `json:"ref"`
+	After       string `json:"after"`
+	CheckoutSha string `json:"checkout_sha"`
+	Repository  struct {
+		CloneUrl   string `json:"clone_url"`
+		SshUrl     string `json:"ssh_url"`
+		HtmlUrl    string `json:"html_url"`
+		GitUrl     string `json:"git_url"`
+		Url        string `json:"url"`
+		GitHttpUrl string `json:"git_http_url"`
+		GitSshUrl  string `json:"git_ssh_url"`
+		Homepage   string `json:"homepage"`
+	} `json:"repository"`
+	Project struct {
+		GitHttpUrl string `json:"git_http_url"`
+		GitSshUrl  string `json:"git_ssh_url"`
+		WebUrl     string `json:"web_url"`
+	} `json:"project"`
+}
+
+// ParseWebhook parse webhook from request header and body. Gitea is checked
+// before github as it sends github event headers for compatibility
+func ParseWebhook(header http.Header, body []byte) (wh *Webhook, err error) {
+	wh = &Webhook{
+		header: header,
+		body:   body,
+	}
+	switch {
+	case header.Get("X-Gitea-Event") != "":
+		wh.Provider = constants.GitWebhookProviderGitea
+		wh.Event = header.Get("X-Gitea-Event")
+		wh.DeliveryId = header.Get("X-Gitea-Delivery")
+		wh.IsPush = wh.Event == "push"
+	case header.Get("X-Gitlab-Event") != "":
+		wh.Provider = constants.GitWebhookProviderGitlab
+		wh.Event = header.Get("X-Gitlab-Event")
+		wh.DeliveryId = header.Get("X-Gitlab-Event-UUID")
+		wh.IsPush = wh.Event == "Push Hook"
+	case header.Get("X-GitHub-Event") != "":
+		wh.Provider = constants.GitWebhookProviderGithub
+		wh.Event = header.Get("X-GitHub-Event")
+		wh.DeliveryId = header.Get("X-GitHub-Delivery")
+		wh.IsPush = wh.Event == "push"
+	default:
+		return nil, errors.ErrorGitUnknownWebhook
+	}
+	if !wh.IsPush {
+		return wh, nil
+	}
+
+	// payload
+	var p webhookPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if strings.HasPrefix(p.Ref, "refs/heads/") {
+		wh.Branch = strings.TrimPrefix(p.Ref, "refs/heads/")
+	}
+	wh.Commit = p.After
+	if p.CheckoutSha != "" {
+		wh.Commit = p.CheckoutSha
+	}
+	for _, u := range []string{
+		p.Repository.CloneUrl,
+		p.Repository.SshUrl,
+		p.Repository.HtmlUrl,
+		p.Repository.GitUrl,
+		p.Repository.Url,
+		p.Repository.GitHttpUrl,
+		p.Repository.GitSshUrl,
+		p.Repository.Homepage,
+		p.Project.GitHttpUrl,
+		p.Project.GitSshUrl,
+		p.Project.WebUrl,
+	} {
+		if u != "" {
+			wh.RepoUrls = append(wh.RepoUrls, u)
+		}
+	}
+
+	return wh, nil
+}
+
+// Verify whether the webhook is signed with the secret, which is HMAC-SHA256
+// signature of body for github and gitea, or the secret token for gitlab
+func (wh *Webhook) Verify(secret string) (ok bool) {
+	if secret == "" {
+		return false
+	}
+	switch wh.Provider {
+	case constants.GitWebhookProviderGithub:
+		signature := wh.header.Get("X-Hub-Signature-256")
+		if !strings.HasPrefix(signature, "sha256=") {
+			return false
+		}
+		return wh.verifyHmac(secret, strings.TrimPrefix(signature, "sha256="))
+	case constants.GitWebhookProviderGitea:
+		return wh.verifyHmac(secret, wh.header.Get("X-Gitea-Signature"))
+	case constants.GitWebhookProviderGitlab:
+		token := wh.header.Get("X-Gitlab-Token")
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	default:
+		return false
+	}
+}
+
+// MatchUrl whether the url is one of the urls of the pushed repo
+func (wh *Webhook) MatchUrl(url string) (ok bool) {
+	target := NormalizeRepoUrl(url)
+	if target == "" {
+		return false
+	}
+	for _, u := range wh.RepoUrls {
+		if NormalizeRepoUrl(u) == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (wh *Webhook) verifyHmac(secret, signature string) (ok bool) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(wh.body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// NormalizeRepoUrl normalize repo url of http, ssh or scp-like form into the
+// form of "host/path" without user, port and ".git" suffix for comparison
+func NormalizeRepoUrl(url string) (res string) {
+	res = strings.ToLower(strings.TrimSpace(url))
+
+	// scheme
+	if i := strings.Index(res, "://"); i >= 0 {
+		res = res[i+3:]
+	} else if i := strings.Index(res, ":"); i >= 0 && !strings.Contains(res[:i], "/") {
+		// scp-like, e.g. git@github.com:owner/repo.git
+		res = res[:i] + "/" + res[i+1:]
+	}
+
+	// user and port in host
+	host, p := res, ""
+	if i := strings.Index(res, "/"); i >= 0 {
+		host, p = res[:i], res[i:]
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	if i := strings.Index(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+
+	// suffix
+	p = strings.TrimSuffix(p, "/")
+	p = strings.TrimSuffix(p, ".git")
+
+	return host + p
+}
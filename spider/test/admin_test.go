@@ -1,10 +1,14 @@
 package test
 
 import (
+	"bytes"
 	"github.com/doubletrey/crawlab-core/constants"
 	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/delegate"
+	"github.com/doubletrey/crawlab-core/models/models"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"testing"
 	"time"
 )
@@ -27,3 +31,188 @@ func TestAdminService_Run(t *testing.T) {
 	require.False(t, task.Id.IsZero())
 	require.Equal(t, constants.TaskStatusFinished, task.Status)
 }
+
+func TestAdminService_Clone(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// spider with env, tag and file
+	s := &models.Spider{
+		Name: "test_spider_clone",
+		Cmd:  T.TestSpider.Cmd,
+		Envs: []models.Env{{Name: "ENV", Value: "value"}},
+		Tags: []models.Tag{{Name: "test_tag"}},
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	fsSvc, err := T.masterSyncSvc.GetFsService(s.Id)
+	require.Nil(t, err)
+	err = fsSvc.Save(T.ScriptName, []byte(T.Script))
+	require.Nil(t, err)
+
+	// clone
+	newId, err := T.adminSvc.Clone(s.Id, &interfaces.SpiderCloneOptions{Name: "test_spider_cloned"})
+	require.Nil(t, err)
+	require.NotEqual(t, s.Id, newId)
+
+	// validate model
+	sc, err := T.modelSvc.GetSpiderById(newId)
+	require.Nil(t, err)
+	require.Equal(t, "test_spider_cloned", sc.Name)
+	require.Equal(t, s.Cmd, sc.Cmd)
+	require.Equal(t, s.Envs, sc.Envs)
+	require.Len(t, sc.Tags, 1)
+	require.Equal(t, "test_tag", sc.Tags[0].Name)
+	_, err = T.modelSvc.GetSpiderStatById(newId)
+	require.Nil(t, err)
+
+	// validate files
+	newFsSvc, err := T.masterSyncSvc.GetFsService(newId)
+	require.Nil(t, err)
+	data, err := newFsSvc.GetFile(T.ScriptName)
+	require.Nil(t, err)
+	require.Equal(t, T.Script, string(data))
+}
+
+func TestAdminService_Delete(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// spider with file, schedule and queued task
+	s := &models.Spider{Name: "test_spider_delete", Cmd: T.TestSpider.Cmd}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	fsSvc, err := T.masterSyncSvc.GetFsService(s.Id)
+	require.Nil(t, err)
+	err = fsSvc.Save(T.ScriptName, []byte(T.Script))
+	require.Nil(t, err)
+	sch := &models.Schedule{SpiderId: s.Id, Cron: "* * * * *", Enabled: true}
+	err = delegate.NewModelDelegate(sch).Add()
+	require.Nil(t, err)
+	task := &models.Task{SpiderId: s.Id, Status: constants.TaskStatusPending}
+	err = delegate.NewModelDelegate(task).Add()
+	require.Nil(t, err)
+	err = delegate.NewModelDelegate(&models.TaskQueueItem{Id: task.Id}).Add()
+	require.Nil(t, err)
+	finishedTask := &models.Task{SpiderId: s.Id, Status: constants.TaskStatusFinished}
+	err = delegate.NewModelDelegate(finishedTask).Add()
+	require.Nil(t, err)
+
+	// dry run
+	res, err := T.adminSvc.Delete(s.Id, &interfaces.SpiderDeleteOptions{DeleteTasks: true, DryRun: true})
+	require.Nil(t, err)
+	require.True(t, res.DryRun)
+	require.Equal(t, []string{"/" + T.ScriptName}, res.Files)
+	require.Len(t, res.ScheduleIds, 1)
+	require.Len(t, res.QueuedTaskIds, 1)
+	require.Len(t, res.TaskIds, 1)
+	_, err = T.modelSvc.GetSpiderById(s.Id)
+	require.Nil(t, err)
+	_, err = fsSvc.GetFile(T.ScriptName)
+	require.Nil(t, err)
+
+	// delete
+	res, err = T.adminSvc.Delete(s.Id, &interfaces.SpiderDeleteOptions{DeleteTasks: true})
+	require.Nil(t, err)
+	require.False(t, res.DryRun)
+
+	// validate
+	_, err = T.modelSvc.GetSpiderById(s.Id)
+	require.NotNil(t, err)
+	total, err := T.modelSvc.GetBaseService(interfaces.ModelIdSchedule).Count(bson.M{"spider_id": s.Id})
+	require.Nil(t, err)
+	require.Equal(t, 0, total)
+	total, err = T.modelSvc.GetBaseService(interfaces.ModelIdTask).Count(bson.M{"spider_id": s.Id})
+	require.Nil(t, err)
+	require.Equal(t, 0, total)
+	total, err = T.modelSvc.GetBaseService(interfaces.ModelIdTaskQueue).Count(bson.M{"_id": task.Id})
+	require.Nil(t, err)
+	require.Equal(t, 0, total)
+	total, err = T.modelSvc.GetBaseService(interfaces.ModelIdArtifact).Count(bson.M{
+		"_id":             sch.Id,
+		"_sys.recycle_id": s.Id,
+		"_obj.enabled":    true,
+	})
+	require.Nil(t, err)
+	require.Equal(t, 1, total)
+
+	// files are kept until purged from recycle bin
+	ok, err := fsSvc.GetFsService().GetFs().Exists(fsSvc.GetFsPath())
+	require.Nil(t, err)
+	require.True(t, ok)
+}
+
+func TestAdminService_ExportImport(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// spider with env, tag, data collection, schedule and file
+	dc := &models.DataCollection{Name: "results_test_spider_export"}
+	err = delegate.NewModelDelegate(dc).Add()
+	require.Nil(t, err)
+	s := &models.Spider{
+		Name:  "test_spider_export",
+		Cmd:   T.TestSpider.Cmd,
+		ColId: dc.Id,
+		Envs:  []models.Env{{Name: "ENV", Value: "value"}},
+		Tags:  []models.Tag{{Name: "test_tag_export"}},
+	}
+	err = delegate.NewModelDelegate(s).Add()
+	require.Nil(t, err)
+	sch := &models.Schedule{
+		Name:        "test_schedule_export",
+		SpiderId:    s.Id,
+		Cron:        "* * * * *",
+		LastFireTs:  time.Now().Add(-24 * time.Hour),
+		CalendarIds: []primitive.ObjectID{primitive.NewObjectID()},
+	}
+	err = delegate.NewModelDelegate(sch).Add()
+	require.Nil(t, err)
+	fsSvc, err := T.masterSyncSvc.GetFsService(s.Id)
+	require.Nil(t, err)
+	err = fsSvc.Save(T.ScriptName, []byte(T.Script))
+	require.Nil(t, err)
+
+	for _, format := range []string{constants.ArchiveFormatZip, constants.ArchiveFormatTarGz} {
+		// export
+		buf := bytes.NewBuffer(nil)
+		err = T.adminSvc.Export([]primitive.ObjectID{s.Id}, format, buf)
+		require.Nil(t, err)
+
+		// import with duplicate name renamed
+		res, err := T.adminSvc.Import(buf.Bytes(), nil)
+		require.Nil(t, err)
+		require.Len(t, res.Ids, 1)
+		require.NotEmpty(t, res.Renamed[s.Name])
+
+		// validate model
+		si, err := T.modelSvc.GetSpiderById(res.Ids[0])
+		require.Nil(t, err)
+		require.Equal(t, res.Renamed[s.Name], si.Name)
+		require.Equal(t, s.Cmd, si.Cmd)
+		require.Equal(t, s.Envs, si.Envs)
+		require.Equal(t, dc.Id, si.ColId)
+		a, err := T.modelSvc.GetArtifactById(si.Id)
+		require.Nil(t, err)
+		require.Len(t, a.TagIds, 1)
+		schedules, err := T.modelSvc.GetScheduleList(bson.M{"spider_id": si.Id}, nil)
+		require.Nil(t, err)
+		require.Len(t, schedules, 1)
+		require.Equal(t, sch.Cron, schedules[0].Cron)
+		require.True(t, schedules[0].LastFireTs.After(sch.LastFireTs))
+		require.Empty(t, schedules[0].CalendarIds)
+
+		// validate files
+		newFsSvc, err := T.masterSyncSvc.GetFsService(si.Id)
+		require.Nil(t, err)
+		data, err := newFsSvc.GetFile(T.ScriptName)
+		require.Nil(t, err)
+		require.Equal(t, T.Script, string(data))
+
+		// import with duplicate name skipped
+		res, err = T.adminSvc.Import(buf.Bytes(), &interfaces.SpiderImportOptions{Conflict: constants.SpiderImportConflictSkip})
+		require.Nil(t, err)
+		require.Len(t, res.Ids, 0)
+		require.Equal(t, []string{s.Name}, res.Skipped)
+	}
+}
@@ -52,3 +52,42 @@ func TestFsService_SyncToWorkspace(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, T.Script, string(data))
 }
+
+func TestFsService_SaveFiles(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	// existing files
+	err = T.masterFsSvc.Save("old/old.py", []byte("old"))
+	require.Nil(t, err)
+	err = T.masterFsSvc.Save("lib/old.py", []byte("old"))
+	require.Nil(t, err)
+
+	// merge
+	err = T.masterFsSvc.SaveFiles(map[string][]byte{
+		T.ScriptName:   []byte(T.Script),
+		"lib/utils.py": []byte("utils"),
+	}, false)
+	require.Nil(t, err)
+	data, err := ioutil.ReadFile(path.Join(T.masterFsSvc.GetWorkspacePath(), "lib", "utils.py"))
+	require.Nil(t, err)
+	require.Equal(t, "utils", string(data))
+	_, err = T.masterFsSvc.GetFile("old/old.py")
+	require.Nil(t, err)
+
+	// replace
+	err = T.masterFsSvc.SaveFiles(map[string][]byte{
+		T.ScriptName:   []byte(T.Script),
+		"lib/utils.py": []byte("utils"),
+	}, true)
+	require.Nil(t, err)
+	_, err = T.masterFsSvc.GetFile("old/old.py")
+	require.NotNil(t, err)
+	_, err = T.masterFsSvc.GetFile("lib/old.py")
+	require.NotNil(t, err)
+	_, err = os.Stat(path.Join(T.masterFsSvc.GetWorkspacePath(), "lib", "old.py"))
+	require.True(t, os.IsNotExist(err))
+	data, err = T.masterFsSvc.GetFile("lib/utils.py")
+	require.Nil(t, err)
+	require.Equal(t, "utils", string(data))
+}
@@ -0,0 +1,12 @@
+package sys_exec
+
+// CgroupLimits resource limits applied to a cgroup, zero values mean unlimited
+type CgroupLimits struct {
+	Cpu    float64 // number of cpu cores
+	Memory int64   // in byte
+	Pids   int64   // max number of processes
+}
+
+func (l CgroupLimits) IsEmpty() bool {
+	return l.Cpu <= 0 && l.Memory <= 0 && l.Pids <= 0
+}
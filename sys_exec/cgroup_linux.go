@@ -0,0 +1,200 @@
+//go:build linux
+// +build linux
+
+package sys_exec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/crawlab-team/go-trace"
+	errors2 "github.com/doubletrey/crawlab-core/errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	cgroupCpuPeriod          = 100000 // in microsecond
+	cgroupRemoveTimeout      = 5 * time.Second
+	cgroupRemovePollInterval = 50 * time.Millisecond
+)
+
+// Cgroup is a cgroup v2 node created under a parent cgroup
+type Cgroup struct {
+	path string
+}
+
+func (cg *Cgroup) GetPath() (p string) {
+	return cg.path
+}
+
+// Attach configures the command to be started directly in the cgroup, so
+// that no process forked by it escapes the limits. The returned function
+// releases the cgroup directory and should be called once the command has
+// been started
+func (cg *Cgroup) Attach(cmd *exec.Cmd) (release func(), err error) {
+	f, err := os.Open(cg.path)
+	if err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(f.Fd())
+	return func() {
+		_ = f.Close()
+	}, nil
+}
+
+// IsOomKilled whether any process in the cgroup has been killed by oom killer
+func (cg *Cgroup) IsOomKilled() (ok bool) {
+	f, err := os.Open(filepath.Join(cg.path, "memory.events"))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "oom_kill" {
+			continue
+		}
+		n, _ := strconv.Atoi(fields[1])
+		return n > 0
+	}
+	return false
+}
+
+// Remove kills remaining processes if any and removes the cgroup once they
+// have exited, as a populated cgroup cannot be removed
+func (cg *Cgroup) Remove() (err error) {
+	// cgroup.kill is only available since linux 5.14
+	_ = cg.write("cgroup.kill", "1")
+
+	// wait for processes to exit
+	deadline := time.Now().Add(cgroupRemoveTimeout)
+	for !cg.isEmpty() && time.Now().Before(deadline) {
+		time.Sleep(cgroupRemovePollInterval)
+	}
+
+	// remove, where it may still be busy until killed processes are reaped
+	for {
+		err := os.Remove(cg.path)
+		if err == nil || os.IsNotExist(err) {
+			return nil
+		}
+		if !errors.Is(err, syscall.EBUSY) || time.Now().After(deadline) {
+			return trace.TraceError(err)
+		}
+		time.Sleep(cgroupRemovePollInterval)
+	}
+}
+
+// isEmpty whether no process is left in the cgroup
+func (cg *Cgroup) isEmpty() (ok bool) {
+	data, err := ioutil.ReadFile(filepath.Join(cg.path, "cgroup.procs"))
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	return strings.TrimSpace(string(data)) == ""
+}
+
+func (cg *Cgroup) write(name, value string) (err error) {
+	if err := ioutil.WriteFile(filepath.Join(cg.path, name), []byte(value), 0644); err != nil {
+		return trace.TraceError(err)
+	}
+	return nil
+}
+
+func (cg *Cgroup) setLimits(limits CgroupLimits) (err error) {
+	for _, f := range getCgroupLimitFiles(limits) {
+		if err := cg.write(f[0], f[1]); err != nil {
+			return err
+		}
+	}
+	if limits.Memory > 0 {
+		// kill the whole cgroup instead of a single process on oom
+		_ = cg.write("memory.oom.group", "1")
+	}
+	return nil
+}
+
+// getCgroupLimitFiles names and values of cgroup interface files to write
+// for the limits
+func getCgroupLimitFiles(limits CgroupLimits) (files [][2]string) {
+	if limits.Cpu > 0 {
+		quota := int64(limits.Cpu * cgroupCpuPeriod)
+		files = append(files, [2]string{"cpu.max", fmt.Sprintf("%d %d", quota, cgroupCpuPeriod)})
+	}
+	if limits.Memory > 0 {
+		files = append(files, [2]string{"memory.max", strconv.FormatInt(limits.Memory, 10)})
+	}
+	if limits.Pids > 0 {
+		files = append(files, [2]string{"pids.max", strconv.FormatInt(limits.Pids, 10)})
+	}
+	return files
+}
+
+// NewCgroup creates a cgroup v2 node with given name under root, which
+// should be a directory in a mounted cgroup v2 hierarchy, e.g. /sys/fs/cgroup/crawlab
+func NewCgroup(root, name string, limits CgroupLimits) (cg *Cgroup, err error) {
+	// validate cgroup v2
+	parent := filepath.Dir(root)
+	if _, err := os.Stat(filepath.Join(parent, "cgroup.controllers")); err != nil {
+		return nil, errors2.ErrorProcessCgroupNotSupported
+	}
+
+	// root cgroup
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, trace.TraceError(err)
+	}
+
+	// enable controllers for root cgroup and its children
+	for _, p := range []string{parent, root} {
+		if err := enableCgroupControllers(p, limits); err != nil {
+			return nil, err
+		}
+	}
+
+	// cgroup
+	cg = &Cgroup{path: filepath.Join(root, name)}
+	if err := os.Mkdir(cg.path, 0755); err != nil && !os.IsExist(err) {
+		return nil, trace.TraceError(err)
+	}
+
+	// limits
+	if err := cg.setLimits(limits); err != nil {
+		_ = cg.Remove()
+		return nil, err
+	}
+
+	return cg, nil
+}
+
+func enableCgroupControllers(p string, limits CgroupLimits) (err error) {
+	var controllers []string
+	if limits.Cpu > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if limits.Memory > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if limits.Pids > 0 {
+		controllers = append(controllers, "+pids")
+	}
+	if len(controllers) == 0 {
+		return nil
+	}
+	data := []byte(strings.Join(controllers, " "))
+	if err := ioutil.WriteFile(filepath.Join(p, "cgroup.subtree_control"), data, 0644); err != nil {
+		return trace.TraceError(err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+//go:build linux
+// +build linux
+
+package sys_exec
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGetCgroupLimitFiles(t *testing.T) {
+	require.Empty(t, getCgroupLimitFiles(CgroupLimits{}))
+	require.Equal(t, [][2]string{
+		{"cpu.max", "150000 100000"},
+		{"memory.max", "536870912"},
+		{"pids.max", "64"},
+	}, getCgroupLimitFiles(CgroupLimits{Cpu: 1.5, Memory: 512 * 1024 * 1024, Pids: 64}))
+	require.Equal(t, [][2]string{
+		{"cpu.max", "25000 100000"},
+	}, getCgroupLimitFiles(CgroupLimits{Cpu: 0.25}))
+}
+
+func TestCgroup_SetLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crawlab-cgroup")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cg := &Cgroup{path: dir}
+	err = cg.setLimits(CgroupLimits{Memory: 1024, Pids: 8})
+	require.Nil(t, err)
+	for name, value := range map[string]string{
+		"memory.max":       "1024",
+		"memory.oom.group": "1",
+		"pids.max":         "8",
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		require.Nil(t, err)
+		require.Equal(t, value, string(data))
+	}
+	_, err = os.Stat(filepath.Join(dir, "cpu.max"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestCgroup_Attach(t *testing.T) {
+	cg, err := NewCgroup("/sys/fs/cgroup/crawlab-test", "task-test", CgroupLimits{Pids: 16})
+	if err != nil {
+		t.Skipf("cgroup v2 not available: %v", err)
+	}
+	defer os.Remove("/sys/fs/cgroup/crawlab-test")
+
+	// process and its children are started in the cgroup
+	cmd := exec.Command("sh", "-c", "sleep 30 & sleep 30")
+	release, err := cg.Attach(cmd)
+	require.Nil(t, err)
+	err = cmd.Start()
+	release()
+	if err != nil {
+		_ = cg.Remove()
+		t.Skipf("starting in cgroup not supported: %v", err)
+	}
+	go func() { _ = cmd.Wait() }()
+	data, err := ioutil.ReadFile(filepath.Join(cg.GetPath(), "cgroup.procs"))
+	require.Nil(t, err)
+	require.Contains(t, strings.Fields(string(data)), strconv.Itoa(cmd.Process.Pid))
+
+	// remaining processes are killed before removal
+	err = cg.Remove()
+	require.Nil(t, err)
+	_, err = os.Stat(cg.GetPath())
+	require.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,33 @@
+//go:build !linux
+// +build !linux
+
+package sys_exec
+
+import (
+	"github.com/doubletrey/crawlab-core/errors"
+	"os/exec"
+)
+
+// Cgroup is only supported on linux
+type Cgroup struct {
+}
+
+func (cg *Cgroup) GetPath() (p string) {
+	return ""
+}
+
+func (cg *Cgroup) Attach(cmd *exec.Cmd) (release func(), err error) {
+	return nil, errors.ErrorProcessCgroupNotSupported
+}
+
+func (cg *Cgroup) IsOomKilled() (ok bool) {
+	return false
+}
+
+func (cg *Cgroup) Remove() (err error) {
+	return nil
+}
+
+func NewCgroup(root, name string, limits CgroupLimits) (cg *Cgroup, err error) {
+	return nil, errors.ErrorProcessCgroupNotSupported
+}
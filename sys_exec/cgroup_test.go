@@ -0,0 +1,28 @@
+package sys_exec
+
+import (
+	errors2 "github.com/doubletrey/crawlab-core/errors"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCgroupLimits_IsEmpty(t *testing.T) {
+	require.True(t, CgroupLimits{}.IsEmpty())
+	require.False(t, CgroupLimits{Cpu: 0.5}.IsEmpty())
+	require.False(t, CgroupLimits{Memory: 1024}.IsEmpty())
+	require.False(t, CgroupLimits{Pids: 10}.IsEmpty())
+}
+
+func TestNewCgroup_NotSupported(t *testing.T) {
+	// root whose parent is not in a cgroup v2 hierarchy
+	dir, err := ioutil.TempDir("", "crawlab-cgroup")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cg, err := NewCgroup(filepath.Join(dir, "crawlab"), "task-test", CgroupLimits{Memory: 1024})
+	require.Equal(t, errors2.ErrorProcessCgroupNotSupported, err)
+	require.Nil(t, cg)
+}
@@ -0,0 +1,91 @@
+//go:build !windows
+// +build !windows
+
+package sys_exec
+
+import (
+	"github.com/crawlab-team/go-trace"
+	"github.com/shirou/gopsutil/process"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// SetPgid run the command in a new process group, so that it can be killed
+// along with its descendants
+func SetPgid(cmd *exec.Cmd) {
+	if cmd == nil {
+		return
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	} else {
+		cmd.SysProcAttr.Setpgid = true
+	}
+}
+
+// GetProcessGroupPids returns ids of live (non-zombie) processes in the process group
+func GetProcessGroupPids(pgid int) (pids []int, err error) {
+	allPids, err := process.Pids()
+	if err != nil {
+		return nil, trace.TraceError(err)
+	}
+	for _, pid := range allPids {
+		if id, err := syscall.Getpgid(int(pid)); err != nil || id != pgid {
+			continue
+		}
+		if isZombie(pid) {
+			continue
+		}
+		pids = append(pids, int(pid))
+	}
+	return pids, nil
+}
+
+// KillProcessGroup sends SIGTERM to all processes in the process group,
+// and SIGKILL to those still alive after timeout
+func KillProcessGroup(pgid int, timeout time.Duration) (err error) {
+	if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+		if err == syscall.ESRCH {
+			return nil
+		}
+		return trace.TraceError(err)
+	}
+
+	// wait for processes to exit
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if pids, err := GetProcessGroupPids(pgid); err == nil && len(pids) == 0 {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// force kill
+	if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		return trace.TraceError(err)
+	}
+	return nil
+}
+
+// ForceKillPids sends SIGKILL to given processes
+func ForceKillPids(pids []int) (err error) {
+	for _, pid := range pids {
+		if err := syscall.Kill(pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+			return trace.TraceError(err)
+		}
+	}
+	return nil
+}
+
+func isZombie(pid int32) (ok bool) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return true
+	}
+	status, err := p.Status()
+	if err != nil {
+		return true
+	}
+	return status == "Z"
+}
@@ -0,0 +1,67 @@
+//go:build windows
+// +build windows
+
+package sys_exec
+
+import (
+	"github.com/crawlab-team/go-trace"
+	"github.com/shirou/gopsutil/process"
+	"os/exec"
+	"time"
+)
+
+// SetPgid process groups are not supported on windows
+func SetPgid(cmd *exec.Cmd) {
+}
+
+// GetProcessGroupPids process groups are not supported on windows, where
+// descendants are tracked by process tree instead
+func GetProcessGroupPids(pgid int) (pids []int, err error) {
+	return nil, nil
+}
+
+// KillProcessGroup terminates the process tree with given root pid, and
+// kills those still alive after timeout
+func KillProcessGroup(pgid int, timeout time.Duration) (err error) {
+	ps, err := GetProcessTree(pgid)
+	if err != nil {
+		// process exited
+		return nil
+	}
+	for _, p := range ps {
+		_ = p.Terminate()
+	}
+
+	// wait for processes to exit
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if ok, err := process.PidExists(int32(pgid)); err == nil && !ok {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// force kill
+	for _, p := range ps {
+		if err := p.Kill(); err != nil {
+			if ok, _ := p.IsRunning(); ok {
+				return trace.TraceError(err)
+			}
+		}
+	}
+	return nil
+}
+
+// ForceKillPids kills given processes
+func ForceKillPids(pids []int) (err error) {
+	for _, pid := range pids {
+		p, err := process.NewProcess(int32(pid))
+		if err != nil {
+			continue
+		}
+		if err := p.Kill(); err != nil {
+			return trace.TraceError(err)
+		}
+	}
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"github.com/crawlab-team/go-trace"
 	"github.com/shirou/gopsutil/process"
 	"os/exec"
-	"syscall"
 	"time"
 )
 
@@ -12,17 +11,6 @@ func BuildCmd(cmdStr string) *exec.Cmd {
 	return exec.Command("sh", "-c", cmdStr)
 }
 
-func SetPgid(cmd *exec.Cmd) {
-	if cmd == nil {
-		return
-	}
-	if cmd.SysProcAttr == nil {
-		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
-	} else {
-		cmd.SysProcAttr.Setpgid = true
-	}
-}
-
 type KillProcessOptions struct {
 	Timeout time.Duration
 	Force   bool
@@ -95,3 +83,24 @@ func forceKillProcess(p *process.Process) (err error) {
 	}
 	return nil
 }
+
+// GetProcessTree returns the process of given pid and all its descendants
+func GetProcessTree(pid int) (ps []*process.Process, err error) {
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return nil, err
+	}
+	return getProcessTreeRecursive(p), nil
+}
+
+func getProcessTreeRecursive(p *process.Process) (ps []*process.Process) {
+	ps = append(ps, p)
+	cps, err := p.Children()
+	if err != nil {
+		return ps
+	}
+	for _, cp := range cps {
+		ps = append(ps, getProcessTreeRecursive(cp)...)
+	}
+	return ps
+}
@@ -0,0 +1,129 @@
+package executor
+
+import (
+	"fmt"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/spf13/viper"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const containerWorkspacePath = "/workspace"
+
+// ContainerExecutor executes task in an OCI container through the cli of
+// a docker compatible runtime (e.g. docker, podman), with the workspace
+// mounted and logs streamed from the attached runtime process
+type ContainerExecutor struct {
+	opts    *interfaces.TaskExecutorOptions
+	runtime string
+	name    string
+	cmd     *exec.Cmd
+	stdout  io.Reader
+	stderr  io.Reader
+}
+
+func (e *ContainerExecutor) Start() (err error) {
+	return e.cmd.Start()
+}
+
+func (e *ContainerExecutor) Wait() (err error) {
+	return e.cmd.Wait()
+}
+
+func (e *ContainerExecutor) Stop(timeout time.Duration) (err error) {
+	seconds := strconv.Itoa(int(timeout.Seconds()))
+	if out, err := exec.Command(e.runtime, "stop", "-t", seconds, e.name).CombinedOutput(); err != nil {
+		return trace.TraceError(fmt.Errorf("%v: %s", err, out))
+	}
+	return nil
+}
+
+func (e *ContainerExecutor) Dispose() (err error) {
+	if out, err := exec.Command(e.runtime, "rm", "-f", e.name).CombinedOutput(); err != nil {
+		return trace.TraceError(fmt.Errorf("%v: %s", err, out))
+	}
+	return nil
+}
+
+// GetPid the workload runs in the container rather than the local runtime
+// cli process, which is accounted and stopped by the runtime
+func (e *ContainerExecutor) GetPid() (pid int) {
+	return 0
+}
+
+func (e *ContainerExecutor) GetStdout() (r io.Reader) {
+	return e.stdout
+}
+
+func (e *ContainerExecutor) GetStderr() (r io.Reader) {
+	return e.stderr
+}
+
+func (e *ContainerExecutor) IsOomKilled() (ok bool) {
+	out, err := exec.Command(e.runtime, "inspect", "-f", "{{.State.OOMKilled}}", e.name).Output()
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(out)) == "true"
+}
+
+func (e *ContainerExecutor) GetName() (name string) {
+	return e.name
+}
+
+// getRunArgs arguments of the runtime cli to create and run the container
+func (e *ContainerExecutor) getRunArgs() (args []string) {
+	args = []string{
+		"run",
+		"--name", e.name,
+		"--init",
+		"-v", e.opts.Cwd + ":" + containerWorkspacePath,
+		"-w", containerWorkspacePath,
+	}
+	if network := viper.GetString("task.container.network"); network != "" {
+		args = append(args, "--network", network)
+	} else {
+		args = append(args, "--network", "host")
+	}
+	for _, env := range e.opts.Env {
+		args = append(args, "-e", env)
+	}
+	if e.opts.CpuLimit > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(e.opts.CpuLimit, 'f', -1, 64))
+	}
+	if e.opts.MemoryLimit > 0 {
+		args = append(args, "--memory", strconv.FormatInt(e.opts.MemoryLimit, 10))
+	}
+	if e.opts.PidsLimit > 0 {
+		args = append(args, "--pids-limit", strconv.FormatInt(e.opts.PidsLimit, 10))
+	}
+	return append(args, e.opts.Image, "sh", "-c", e.opts.Cmd)
+}
+
+func NewContainerExecutor(opts *interfaces.TaskExecutorOptions) (e *ContainerExecutor, err error) {
+	e = &ContainerExecutor{
+		opts:    opts,
+		runtime: viper.GetString("task.container.runtime"),
+		name:    "crawlab-task-" + opts.Id,
+	}
+	if e.runtime == "" {
+		e.runtime = "docker"
+	}
+
+	// command
+	e.cmd = exec.Command(e.runtime, e.getRunArgs()...)
+
+	// stdout and stderr
+	if e.stdout, err = e.cmd.StdoutPipe(); err != nil {
+		return nil, err
+	}
+	if e.stderr, err = e.cmd.StderrPipe(); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,22 @@
+package executor
+
+import (
+	"github.com/doubletrey/crawlab-core/interfaces"
+)
+
+// NewTaskExecutor create a container executor if image is specified,
+// otherwise a local process executor
+func NewTaskExecutor(opts *interfaces.TaskExecutorOptions) (exec interfaces.TaskExecutor, err error) {
+	if opts.Image != "" {
+		e, err := NewContainerExecutor(opts)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	e, err := NewProcessExecutor(opts)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,165 @@
+package executor
+
+import (
+	"github.com/apex/log"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/sys_exec"
+	"github.com/spf13/viper"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// ProcessExecutor executes task as a local process, with resource
+// limits applied through cgroup v2 if available
+type ProcessExecutor struct {
+	opts   *interfaces.TaskExecutorOptions
+	cmd    *exec.Cmd
+	cg     *sys_exec.Cgroup
+	stdout *os.File
+	stderr *os.File
+	outW   *os.File
+	errW   *os.File
+}
+
+func (e *ProcessExecutor) Start() (err error) {
+	// start the process directly in a cgroup with resource limits
+	release := e.configureCgroup()
+	err = e.cmd.Start()
+	release()
+	if err == nil || e.cg == nil {
+		e.closeWriters()
+		return err
+	}
+
+	// starting in a cgroup requires linux 5.7, so start without limits
+	// if failed
+	log.Warnf("task[%s] unable to apply resource limits: %v", e.opts.Id, err)
+	_ = e.cg.Remove()
+	e.cg = nil
+	e.buildCmd()
+	err = e.cmd.Start()
+	e.closeWriters()
+	return err
+}
+
+func (e *ProcessExecutor) Wait() (err error) {
+	return e.cmd.Wait()
+}
+
+// Stop terminate the whole process group and force kill after timeout
+func (e *ProcessExecutor) Stop(timeout time.Duration) (err error) {
+	return sys_exec.KillProcessGroup(e.GetPid(), timeout)
+}
+
+func (e *ProcessExecutor) Dispose() (err error) {
+	e.closeWriters()
+	_ = e.stdout.Close()
+	_ = e.stderr.Close()
+	if e.cg != nil {
+		return e.cg.Remove()
+	}
+	return nil
+}
+
+func (e *ProcessExecutor) GetPid() (pid int) {
+	if e.cmd.Process == nil {
+		return 0
+	}
+	return e.cmd.Process.Pid
+}
+
+func (e *ProcessExecutor) GetStdout() (r io.Reader) {
+	return e.stdout
+}
+
+func (e *ProcessExecutor) GetStderr() (r io.Reader) {
+	return e.stderr
+}
+
+func (e *ProcessExecutor) IsOomKilled() (ok bool) {
+	return e.cg != nil && e.cg.IsOomKilled()
+}
+
+// configureCgroup create a cgroup with resource limits which the process is
+// started in, and run without limits if cgroup v2 is not available. The
+// returned function should be called once the process has been started
+func (e *ProcessExecutor) configureCgroup() (release func()) {
+	release = func() {}
+	limits := sys_exec.CgroupLimits{
+		Cpu:    e.opts.CpuLimit,
+		Memory: e.opts.MemoryLimit,
+		Pids:   e.opts.PidsLimit,
+	}
+	if limits.IsEmpty() || viper.GetBool("task.cgroup.disabled") {
+		return release
+	}
+	root := viper.GetString("task.cgroup.path")
+	if root == "" {
+		root = "/sys/fs/cgroup/crawlab"
+	}
+	cg, err := sys_exec.NewCgroup(root, "task-"+e.opts.Id, limits)
+	if err != nil {
+		log.Warnf("task[%s] unable to apply resource limits: %v", e.opts.Id, err)
+		return release
+	}
+	release, err = cg.Attach(e.cmd)
+	if err != nil {
+		log.Warnf("task[%s] unable to apply resource limits: %v", e.opts.Id, err)
+		_ = cg.Remove()
+		return func() {}
+	}
+	e.cg = cg
+	return release
+}
+
+// buildCmd build the command writing to the output pipes, which is
+// rebuilt without changing the pipes if the cgroup start failed
+func (e *ProcessExecutor) buildCmd() {
+	// command
+	e.cmd = sys_exec.BuildCmd(e.opts.Cmd)
+	e.cmd.Dir = e.opts.Cwd
+	e.cmd.Env = append(os.Environ(), e.opts.Env...)
+
+	// configure pgid to allow killing sub processes
+	sys_exec.SetPgid(e.cmd)
+
+	// stdout and stderr
+	e.cmd.Stdout = e.outW
+	e.cmd.Stderr = e.errW
+}
+
+// closeWriters close the write ends of the output pipes held by this
+// process, so that readers get EOF once the started process exits
+func (e *ProcessExecutor) closeWriters() {
+	if e.outW != nil {
+		_ = e.outW.Close()
+		e.outW = nil
+	}
+	if e.errW != nil {
+		_ = e.errW.Close()
+		e.errW = nil
+	}
+}
+
+func NewProcessExecutor(opts *interfaces.TaskExecutorOptions) (e *ProcessExecutor, err error) {
+	e = &ProcessExecutor{
+		opts: opts,
+	}
+
+	// stdout and stderr
+	if e.stdout, e.outW, err = os.Pipe(); err != nil {
+		return nil, err
+	}
+	if e.stderr, e.errW, err = os.Pipe(); err != nil {
+		_ = e.stdout.Close()
+		_ = e.outW.Close()
+		return nil, err
+	}
+
+	// command
+	e.buildCmd()
+
+	return e, nil
+}
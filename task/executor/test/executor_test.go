@@ -0,0 +1,222 @@
+package test
+
+import (
+	"bufio"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/sys_exec"
+	"github.com/doubletrey/crawlab-core/task/executor"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readLines(r io.Reader) (lines []string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+func TestProcessExecutor_Run(t *testing.T) {
+	e, err := executor.NewTaskExecutor(&interfaces.TaskExecutorOptions{
+		Id:  "test",
+		Cmd: "echo $CRAWLAB_TEST",
+		Cwd: os.TempDir(),
+		Env: []string{"CRAWLAB_TEST=it works"},
+	})
+	require.Nil(t, err)
+	_, ok := e.(*executor.ProcessExecutor)
+	require.True(t, ok)
+
+	err = e.Start()
+	require.Nil(t, err)
+	require.NotZero(t, e.GetPid())
+	lines := readLines(e.GetStdout())
+	require.Nil(t, e.Wait())
+	require.Equal(t, []string{"it works"}, lines)
+	require.False(t, e.IsOomKilled())
+	require.Nil(t, e.Dispose())
+}
+
+func TestProcessExecutor_CgroupNotSupported(t *testing.T) {
+	// cgroup root not in a cgroup v2 hierarchy
+	dir, err := ioutil.TempDir("", "crawlab-cgroup")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	viper.Set("task.cgroup.path", path.Join(dir, "crawlab"))
+	defer viper.Set("task.cgroup.path", "")
+
+	// run without limits
+	e, err := executor.NewTaskExecutor(&interfaces.TaskExecutorOptions{
+		Id:          "test",
+		Cmd:         "echo it works",
+		Cwd:         os.TempDir(),
+		MemoryLimit: 64 * 1024 * 1024,
+		PidsLimit:   16,
+	})
+	require.Nil(t, err)
+	err = e.Start()
+	require.Nil(t, err)
+	lines := readLines(e.GetStdout())
+	require.Nil(t, e.Wait())
+	require.Equal(t, []string{"it works"}, lines)
+	require.False(t, e.IsOomKilled())
+	require.Nil(t, e.Dispose())
+}
+
+func TestProcessExecutor_Stop(t *testing.T) {
+	e, err := executor.NewTaskExecutor(&interfaces.TaskExecutorOptions{
+		Id:  "test",
+		Cmd: "sleep 30",
+		Cwd: os.TempDir(),
+	})
+	require.Nil(t, err)
+
+	err = e.Start()
+	require.Nil(t, err)
+	time.Sleep(500 * time.Millisecond)
+	err = e.Stop(5 * time.Second)
+	require.Nil(t, err)
+
+	err = e.Wait()
+	require.NotNil(t, err)
+	_, ok := err.(*exec.ExitError)
+	require.True(t, ok)
+}
+
+func TestProcessExecutor_StopProcessGroup(t *testing.T) {
+	e, err := executor.NewTaskExecutor(&interfaces.TaskExecutorOptions{
+		Id:  "test",
+		Cmd: "sleep 30 & sleep 30",
+		Cwd: os.TempDir(),
+	})
+	require.Nil(t, err)
+
+	err = e.Start()
+	require.Nil(t, err)
+	go func() { _ = e.Wait() }()
+	time.Sleep(500 * time.Millisecond)
+	pids, err := sys_exec.GetProcessGroupPids(e.GetPid())
+	require.Nil(t, err)
+	require.GreaterOrEqual(t, len(pids), 2)
+
+	err = e.Stop(5 * time.Second)
+	require.Nil(t, err)
+	pids, err = sys_exec.GetProcessGroupPids(e.GetPid())
+	require.Nil(t, err)
+	require.Empty(t, pids)
+}
+
+func TestProcessExecutor_StopEscalation(t *testing.T) {
+	// ignore SIGTERM so that SIGKILL is required
+	e, err := executor.NewTaskExecutor(&interfaces.TaskExecutorOptions{
+		Id:  "test",
+		Cmd: "trap '' TERM; sleep 30",
+		Cwd: os.TempDir(),
+	})
+	require.Nil(t, err)
+
+	err = e.Start()
+	require.Nil(t, err)
+	go func() { _ = e.Wait() }()
+	time.Sleep(500 * time.Millisecond)
+
+	start := time.Now()
+	err = e.Stop(1 * time.Second)
+	require.Nil(t, err)
+	require.GreaterOrEqual(t, time.Since(start).Milliseconds(), int64(1000))
+	time.Sleep(100 * time.Millisecond)
+	pids, err := sys_exec.GetProcessGroupPids(e.GetPid())
+	require.Nil(t, err)
+	require.Empty(t, pids)
+}
+
+func TestContainerExecutor_Run(t *testing.T) {
+	// fake container runtime which records arguments and runs the command locally
+	dir, err := ioutil.TempDir("", "crawlab-executor")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	logPath := path.Join(dir, "runtime.log")
+	runtimePath := path.Join(dir, "runtime")
+	script := `#!/bin/sh
+echo "$@" >> ` + logPath + `
+case "$1" in
+run)
+  while [ "$1" != "sh" ]; do shift; done
+  exec "$@"
+  ;;
+inspect)
+  echo true
+  ;;
+esac
+`
+	err = ioutil.WriteFile(runtimePath, []byte(script), 0755)
+	require.Nil(t, err)
+	viper.Set("task.container.runtime", runtimePath)
+	defer viper.Set("task.container.runtime", "")
+
+	e, err := executor.NewTaskExecutor(&interfaces.TaskExecutorOptions{
+		Id:          "test",
+		Cmd:         "echo it works",
+		Cwd:         dir,
+		Env:         []string{"CRAWLAB_TASK_ID=test"},
+		Image:       "python:3",
+		MemoryLimit: 1024 * 1024,
+	})
+	require.Nil(t, err)
+	ce, ok := e.(*executor.ContainerExecutor)
+	require.True(t, ok)
+	require.Equal(t, "crawlab-task-test", ce.GetName())
+
+	err = e.Start()
+	require.Nil(t, err)
+	lines := readLines(e.GetStdout())
+	require.Nil(t, e.Wait())
+	require.Equal(t, []string{"it works"}, lines)
+	require.True(t, e.IsOomKilled())
+	require.Nil(t, e.Stop(3*time.Second))
+	require.Nil(t, e.Dispose())
+
+	data, err := ioutil.ReadFile(logPath)
+	require.Nil(t, err)
+	calls := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, calls, 4)
+	require.True(t, strings.HasPrefix(calls[0], "run --name crawlab-task-test"))
+	require.Contains(t, calls[0], "-v "+dir+":/workspace -w /workspace")
+	require.Contains(t, calls[0], "-e CRAWLAB_TASK_ID=test")
+	require.Contains(t, calls[0], "--memory 1048576")
+	require.True(t, strings.HasSuffix(calls[0], "python:3 sh -c echo it works"))
+	require.Equal(t, "inspect -f {{.State.OOMKilled}} crawlab-task-test", calls[1])
+	require.Equal(t, "stop -t 3 crawlab-task-test", calls[2])
+	require.Equal(t, "rm -f crawlab-task-test", calls[3])
+}
+
+func TestFakeExecutor(t *testing.T) {
+	e := NewFakeExecutor(&interfaces.TaskExecutorOptions{Id: "test"})
+	e.Lines = []string{"line 1", "line 2"}
+	e.Duration = 10 * time.Second
+
+	err := e.Start()
+	require.Nil(t, err)
+	require.True(t, e.IsStarted())
+	require.Zero(t, e.GetPid())
+	scanner := bufio.NewScanner(e.GetStdout())
+	require.True(t, scanner.Scan())
+	require.Equal(t, "line 1", scanner.Text())
+	require.True(t, scanner.Scan())
+	require.Equal(t, "line 2", scanner.Text())
+
+	err = e.Stop(time.Second)
+	require.Nil(t, err)
+	err = e.Wait()
+	require.Equal(t, ErrFakeExecutorStopped, err)
+	require.True(t, e.IsStopped())
+}
@@ -0,0 +1,139 @@
+package test
+
+import (
+	"errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrFakeExecutorStopped returned by Wait of FakeExecutor if stopped
+var ErrFakeExecutorStopped = errors.New("fake executor stopped")
+
+// FakeExecutor executes nothing but writes given output lines and exits with
+// given error in-process, which is intended for tests of components relying
+// on executors, e.g. task runners
+type FakeExecutor struct {
+	Opts     *interfaces.TaskExecutorOptions
+	Lines    []string      // lines written to stdout
+	Duration time.Duration // duration of execution
+	Err      error         // error returned by Wait
+	OomKill  bool          // whether to be reported as killed due to out of memory
+
+	mu       sync.Mutex
+	started  bool
+	stopped  bool
+	disposed bool
+	stdoutR  *io.PipeReader
+	stdoutW  *io.PipeWriter
+	stderrR  *io.PipeReader
+	stderrW  *io.PipeWriter
+	done     chan struct{}
+	stop     chan struct{}
+}
+
+func (e *FakeExecutor) Start() (err error) {
+	e.mu.Lock()
+	e.started = true
+	e.mu.Unlock()
+	// output, which does not block execution as if buffered
+	go func() {
+		defer e.stdoutW.Close()
+		defer e.stderrW.Close()
+		if len(e.Lines) > 0 {
+			_, _ = io.WriteString(e.stdoutW, strings.Join(e.Lines, "\n")+"\n")
+		}
+	}()
+
+	// execution
+	go func() {
+		defer close(e.done)
+		select {
+		case <-time.After(e.Duration):
+		case <-e.stop:
+		}
+	}()
+	return nil
+}
+
+func (e *FakeExecutor) Wait() (err error) {
+	<-e.done
+	if e.IsStopped() {
+		return ErrFakeExecutorStopped
+	}
+	return e.Err
+}
+
+func (e *FakeExecutor) Stop(timeout time.Duration) (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.stopped {
+		e.stopped = true
+		close(e.stop)
+	}
+	return nil
+}
+
+func (e *FakeExecutor) Dispose() (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.disposed = true
+	return nil
+}
+
+// GetPid no local process is executed
+func (e *FakeExecutor) GetPid() (pid int) {
+	return 0
+}
+
+func (e *FakeExecutor) GetStdout() (r io.Reader) {
+	return e.stdoutR
+}
+
+func (e *FakeExecutor) GetStderr() (r io.Reader) {
+	return e.stderrR
+}
+
+func (e *FakeExecutor) IsOomKilled() (ok bool) {
+	return e.OomKill
+}
+
+func (e *FakeExecutor) IsStarted() (ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.started
+}
+
+func (e *FakeExecutor) IsStopped() (ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stopped
+}
+
+func (e *FakeExecutor) IsDisposed() (ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.disposed
+}
+
+func NewFakeExecutor(opts *interfaces.TaskExecutorOptions) (e *FakeExecutor) {
+	e = &FakeExecutor{
+		Opts: opts,
+		done: make(chan struct{}),
+		stop: make(chan struct{}),
+	}
+	e.stdoutR, e.stdoutW = io.Pipe()
+	e.stderrR, e.stderrW = io.Pipe()
+	return e
+}
+
+// NewFakeExecutorFactory executor factory which returns the fake executor
+// with options applied
+func NewFakeExecutorFactory(e *FakeExecutor) (f interfaces.TaskExecutorFactory) {
+	return func(opts *interfaces.TaskExecutorOptions) (exec interfaces.TaskExecutor, err error) {
+		e.Opts = opts
+		return e, nil
+	}
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-core/sys_exec"
+	"github.com/shirou/gopsutil/process"
+	"sync"
+	"time"
+)
+
+// metricsCollector samples resource usage of a task's process tree and keeps
+// aggregated stats as well as a downsampled time series with bounded length
+type metricsCollector struct {
+	mu sync.Mutex
+
+	// settings
+	maxPoints int           // max number of points kept in time series
+	step      time.Duration // duration of each point, doubled when time series is downsampled
+
+	// aggregates
+	cpuTimes    map[int32]float64 // last observed cpu time (in second) of each process
+	readBytes   map[int32]uint64  // last observed read bytes of each process
+	writeBytes  map[int32]uint64  // last observed write bytes of each process
+	createTimes map[int32]int64   // create time of each observed process
+	peakRss     int64
+	peakThreads int
+
+	// time series
+	points  []models.TaskMetricPoint
+	bucket  *metricsBucket
+	lastTs  time.Time
+	lastCpu float64
+}
+
+type metricsBucket struct {
+	ts         time.Time
+	cpuSum     float64
+	count      int
+	rss        int64
+	readBytes  int64
+	writeBytes int64
+	threads    int
+}
+
+func (b *metricsBucket) point() models.TaskMetricPoint {
+	p := models.TaskMetricPoint{
+		Ts:         b.ts,
+		Rss:        b.rss,
+		ReadBytes:  b.readBytes,
+		WriteBytes: b.writeBytes,
+		Threads:    b.threads,
+	}
+	if b.count > 0 {
+		p.CpuPercent = b.cpuSum / float64(b.count)
+	}
+	return p
+}
+
+// sample collects resource usage of the process tree with given root pid
+func (c *metricsCollector) sample(pid int) {
+	ps, err := sys_exec.GetProcessTree(pid)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// iterate processes in the tree
+	var rss int64
+	var threads int
+	for _, p := range ps {
+		if _, ok := c.createTimes[p.Pid]; !ok {
+			if ct, err := p.CreateTime(); err == nil {
+				c.createTimes[p.Pid] = ct
+			}
+		}
+		if t, err := p.Times(); err == nil {
+			c.cpuTimes[p.Pid] = t.User + t.System
+		}
+		if m, err := p.MemoryInfo(); err == nil {
+			rss += int64(m.RSS)
+		}
+		if io, err := p.IOCounters(); err == nil {
+			c.readBytes[p.Pid] = io.ReadBytes
+			c.writeBytes[p.Pid] = io.WriteBytes
+		}
+		if n, err := p.NumThreads(); err == nil {
+			threads += int(n)
+		}
+	}
+
+	c.addSample(time.Now(), c.totalCpuTime(), rss, c.totalBytes(c.readBytes), c.totalBytes(c.writeBytes), threads)
+}
+
+// addSample adds a sample of the whole process tree at given time, where cpu time
+// (in second), read bytes and write bytes are cumulative values
+func (c *metricsCollector) addSample(ts time.Time, cpuTime float64, rss, readBytes, writeBytes int64, threads int) {
+	// peaks
+	if rss > c.peakRss {
+		c.peakRss = rss
+	}
+	if threads > c.peakThreads {
+		c.peakThreads = threads
+	}
+
+	// cpu percent since last sample
+	var cpuPercent float64
+	if !c.lastTs.IsZero() {
+		if elapsed := ts.Sub(c.lastTs).Seconds(); elapsed > 0 {
+			cpuPercent = (cpuTime - c.lastCpu) / elapsed * 100
+		}
+	}
+	c.lastTs = ts
+	c.lastCpu = cpuTime
+
+	// close current bucket if expired
+	if c.bucket != nil && ts.Sub(c.bucket.ts) >= c.step {
+		c.points = append(c.points, c.bucket.point())
+		c.bucket = nil
+		if len(c.points) >= c.maxPoints {
+			c.downsample()
+		}
+	}
+
+	// current bucket
+	if c.bucket == nil {
+		c.bucket = &metricsBucket{ts: ts}
+	}
+	c.bucket.cpuSum += cpuPercent
+	c.bucket.count++
+	if rss > c.bucket.rss {
+		c.bucket.rss = rss
+	}
+	if threads > c.bucket.threads {
+		c.bucket.threads = threads
+	}
+	c.bucket.readBytes = readBytes
+	c.bucket.writeBytes = writeBytes
+}
+
+// downsample merges adjacent points and doubles the step
+func (c *metricsCollector) downsample() {
+	var points []models.TaskMetricPoint
+	for i := 0; i < len(c.points); i += 2 {
+		p := c.points[i]
+		if i+1 < len(c.points) {
+			q := c.points[i+1]
+			p.CpuPercent = (p.CpuPercent + q.CpuPercent) / 2
+			if q.Rss > p.Rss {
+				p.Rss = q.Rss
+			}
+			if q.Threads > p.Threads {
+				p.Threads = q.Threads
+			}
+			p.ReadBytes = q.ReadBytes
+			p.WriteBytes = q.WriteBytes
+		}
+		points = append(points, p)
+	}
+	c.points = points
+	c.step *= 2
+}
+
+// apply aggregated stats to task stat
+func (c *metricsCollector) applyStat(ts interfaces.TaskStat) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ts.SetCpuTime(int64(c.totalCpuTime() * 1000))
+	ts.SetPeakRss(c.peakRss)
+	ts.SetReadBytes(c.totalBytes(c.readBytes))
+	ts.SetWriteBytes(c.totalBytes(c.writeBytes))
+	ts.SetPeakThreads(c.peakThreads)
+}
+
+// metric returns a snapshot of the time series including the current incomplete point
+func (c *metricsCollector) metric() (m *models.TaskMetric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m = &models.TaskMetric{
+		Interval: c.step.Milliseconds(),
+		Points:   make([]models.TaskMetricPoint, len(c.points)),
+	}
+	copy(m.Points, c.points)
+	if c.bucket != nil {
+		m.Points = append(m.Points, c.bucket.point())
+	}
+	return m
+}
+
+// alivePids ids of observed processes which are still alive
+func (c *metricsCollector) alivePids() (pids []int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for pid, ct := range c.createTimes {
+		p, err := process.NewProcess(pid)
+		if err != nil {
+			continue
+		}
+		// pid may be reused by another process
+		if ct2, err := p.CreateTime(); err != nil || ct2 != ct {
+			continue
+		}
+		if status, err := p.Status(); err != nil || status == "Z" {
+			continue
+		}
+		pids = append(pids, int(pid))
+	}
+	return pids
+}
+
+func (c *metricsCollector) totalCpuTime() (t float64) {
+	for _, v := range c.cpuTimes {
+		t += v
+	}
+	return t
+}
+
+func (c *metricsCollector) totalBytes(m map[int32]uint64) (b int64) {
+	for _, v := range m {
+		b += int64(v)
+	}
+	return b
+}
+
+func newMetricsCollector(step time.Duration, maxPoints int) (c *metricsCollector) {
+	return &metricsCollector{
+		step:        step,
+		maxPoints:   maxPoints,
+		cpuTimes:    map[int32]float64{},
+		readBytes:   map[int32]uint64{},
+		writeBytes:  map[int32]uint64{},
+		createTimes: map[int32]int64{},
+	}
+}
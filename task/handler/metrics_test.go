@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMetricsCollector_AddSample(t *testing.T) {
+	c := newMetricsCollector(time.Second, 100)
+	ts := time.Now()
+
+	// two samples in the first bucket, where cpu percent of the first is unknown
+	c.addSample(ts, 0, 100, 10, 20, 2)
+	c.addSample(ts.Add(500*time.Millisecond), 0.5, 300, 30, 40, 4)
+	m := c.metric()
+	require.Len(t, m.Points, 1)
+	require.Equal(t, int64(1000), m.Interval)
+	require.InDelta(t, 50, m.Points[0].CpuPercent, 0.001)
+	require.Equal(t, int64(300), m.Points[0].Rss)
+	require.Equal(t, 4, m.Points[0].Threads)
+	require.Equal(t, int64(30), m.Points[0].ReadBytes)
+	require.Equal(t, int64(40), m.Points[0].WriteBytes)
+
+	// the first bucket is closed by a sample after the step
+	c.addSample(ts.Add(1500*time.Millisecond), 1.5, 200, 50, 60, 3)
+	m = c.metric()
+	require.Len(t, m.Points, 2)
+	require.Equal(t, ts, m.Points[0].Ts)
+	require.InDelta(t, 100, m.Points[1].CpuPercent, 0.001)
+	require.Equal(t, int64(200), m.Points[1].Rss)
+
+	// peaks are kept across buckets
+	require.Equal(t, int64(300), c.peakRss)
+	require.Equal(t, 4, c.peakThreads)
+}
+
+func TestMetricsCollector_Downsample(t *testing.T) {
+	c := newMetricsCollector(time.Second, 4)
+	ts := time.Now()
+	for i := 0; i < 5; i++ {
+		c.addSample(ts.Add(time.Duration(i)*time.Second), 0, int64(i+1), int64(i), int64(i), i+1)
+	}
+
+	// points are merged in pairs once reaching max points, and the step is doubled
+	require.Len(t, c.points, 2)
+	require.Equal(t, 2*time.Second, c.step)
+	require.Equal(t, ts, c.points[0].Ts)
+	require.Equal(t, int64(2), c.points[0].Rss)
+	require.Equal(t, 2, c.points[0].Threads)
+	require.Equal(t, int64(1), c.points[0].ReadBytes)
+	require.Equal(t, ts.Add(2*time.Second), c.points[1].Ts)
+	require.Equal(t, int64(4), c.points[1].Rss)
+	require.Equal(t, int64(3), c.points[1].WriteBytes)
+
+	// odd point is kept as is
+	c.points = []models.TaskMetricPoint{{Rss: 1}, {Rss: 3}, {Rss: 2}}
+	c.downsample()
+	require.Len(t, c.points, 2)
+	require.Equal(t, int64(3), c.points[0].Rss)
+	require.Equal(t, int64(2), c.points[1].Rss)
+}
+
+func TestMetricsCollector_ApplyStat(t *testing.T) {
+	c := newMetricsCollector(time.Second, 100)
+	c.cpuTimes[1] = 1.5
+	c.cpuTimes[2] = 0.25
+	c.readBytes[1] = 100
+	c.readBytes[2] = 50
+	c.writeBytes[1] = 10
+	c.peakRss = 1024
+	c.peakThreads = 8
+
+	ts := &models.TaskStat{}
+	c.applyStat(ts)
+	require.Equal(t, int64(1750), ts.CpuTime)
+	require.Equal(t, int64(1024), ts.PeakRss)
+	require.Equal(t, int64(150), ts.ReadBytes)
+	require.Equal(t, int64(10), ts.WriteBytes)
+	require.Equal(t, 8, ts.PeakThreads)
+}
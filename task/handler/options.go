@@ -44,3 +44,9 @@ func WithSubscribeTimeout(timeout time.Duration) RunnerOption {
 		r.SetSubscribeTimeout(timeout)
 	}
 }
+
+func WithExecutorFactory(f interfaces.TaskExecutorFactory) RunnerOption {
+	return func(r interfaces.TaskRunner) {
+		r.SetExecutorFactory(f)
+	}
+}
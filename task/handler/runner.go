@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/apex/log"
-	"github.com/cenkalti/backoff/v4"
 	grpc "github.com/crawlab-team/crawlab-grpc"
 	clog "github.com/crawlab-team/crawlab-log"
 	"github.com/crawlab-team/go-trace"
@@ -18,8 +17,10 @@ import (
 	"github.com/doubletrey/crawlab-core/models/client"
 	"github.com/doubletrey/crawlab-core/models/delegate"
 	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-core/spider/dependency"
 	"github.com/doubletrey/crawlab-core/spider/fs"
 	"github.com/doubletrey/crawlab-core/sys_exec"
+	"github.com/doubletrey/crawlab-core/task/executor"
 	"github.com/doubletrey/crawlab-core/utils"
 	"github.com/doubletrey/crawlab-db/mongo"
 	"github.com/shirou/gopsutil/process"
@@ -29,30 +30,44 @@ import (
 	"go.uber.org/dig"
 	"os"
 	"os/exec"
+	"sync"
 	"time"
 )
 
 type Runner struct {
 	// dependencies
-	svc   interfaces.TaskHandlerService // task handler service
-	fsSvc interfaces.SpiderFsService    // spider fs service
+	svc    interfaces.TaskHandlerService      // task handler service
+	fsSvc  interfaces.SpiderFsService         // spider fs service
+	depSvc interfaces.SpiderDependencyService // spider dependency service
 
 	// settings
-	logDriverType    string
-	subscribeTimeout time.Duration
+	logDriverType        string
+	subscribeTimeout     time.Duration
+	metricsFlushInterval time.Duration
+	execFactory          interfaces.TaskExecutorFactory
 
 	// internals
-	cmd  *exec.Cmd                        // process command instance
-	pid  int                              // process id
-	tid  primitive.ObjectID               // task id
-	t    interfaces.Task                  // task model.Task
-	s    interfaces.Spider                // spider model.Spider
-	ch   chan constants.TaskSignal        // channel to communicate between Service and Runner
-	err  error                            // standard process error
-	envs []models.Env                     // environment variables
-	cwd  string                           // working directory
-	c    interfaces.GrpcClient            // grpc client
-	sub  grpc.TaskService_SubscribeClient // grpc task service stream client
+	cmd          string                           // command to execute
+	exec         interfaces.TaskExecutor          // task executor
+	env          []string                         // environment variables passed to executor
+	depEnv       []string                         // environment variables to activate dependency environments
+	pid          int                              // process id
+	tid          primitive.ObjectID               // task id
+	t            interfaces.Task                  // task model.Task
+	s            interfaces.Spider                // spider model.Spider
+	ch           chan constants.TaskSignal        // channel to communicate between Service and Runner
+	err          error                            // standard process error
+	envs         []models.Env                     // environment variables
+	cwd          string                           // working directory
+	c            interfaces.GrpcClient            // grpc client
+	sub          grpc.TaskService_SubscribeClient // grpc task service stream client
+	m            *metricsCollector                // process resource usage collector
+	mu           sync.Mutex                       // lock of execution start and cancellation
+	started      bool                             // whether the execution has started
+	cancelled    bool                             // whether the task has been cancelled
+	exited       chan struct{}                    // closed when the process exited
+	syncDuration time.Duration                    // duration of syncing files to workspace
+	startTs      time.Time                        // time when the process started
 
 	// log internals
 	scannerStdout *bufio.Scanner
@@ -70,16 +85,30 @@ func (r *Runner) Init() (err error) {
 		r.c.Start()
 	}
 
-	// working directory
-	r.cwd = r.fsSvc.GetWorkspacePath()
+	// working directory, which is an isolated workspace of the task if it is
+	// pinned to a git ref so that it is not affected by later changes of files
+	if r.t.GetRef() != "" {
+		r.cwd = r.fsSvc.GetRefWorkspacePath(r.tid.Hex())
+	} else {
+		r.cwd = r.fsSvc.GetWorkspacePath()
+	}
 
 	// sync files to workspace
+	syncStartTs := time.Now()
 	if err := r.syncFiles(); err != nil {
 		return err
 	}
+	r.syncDuration = time.Since(syncStartTs)
+
+	// prepare dependency environments
+	if err := r.prepareDependencies(); err != nil {
+		_ = r.updateTask(constants.TaskStatusError, err)
+		return err
+	}
 
 	// grpc task service stream client
 	if err := r.initSub(); err != nil {
+		r.depSvc.Release(r.tid)
 		return err
 	}
 
@@ -90,28 +119,38 @@ func (r *Runner) Run() (err error) {
 	// log task started
 	log.Infof("task[%s] started", r.tid.Hex())
 
+	// dispose when finished, including failures before execution
+	defer func() {
+		_ = r.Dispose()
+	}()
+
 	// configure cmd
 	r.configureCmd()
 
 	// configure environment variables
 	r.configureEnv()
 
+	// configure executor
+	if err := r.configureExecutor(); err != nil {
+		return r.updateTask(constants.TaskStatusError, err)
+	}
+
 	// configure logging
 	r.configureLogging()
 
-	// start process
-	if err := r.cmd.Start(); err != nil {
+	// start execution
+	if err := r.start(); err != nil {
+		if err == constants.ErrTaskCancelled {
+			return r.updateTask(constants.TaskStatusCancelled, err)
+		}
 		return r.updateTask(constants.TaskStatusError, err)
 	}
 
 	// start logging
 	go r.startLogging()
 
-	// process id
-	if r.cmd.Process == nil {
-		return r.updateTask(constants.TaskStatusError, constants.ErrNotExists)
-	}
-	r.pid = r.cmd.Process.Pid
+	// process id, which is 0 if not executed as a local process
+	r.pid = r.exec.GetPid()
 	r.t.SetPid(r.pid)
 
 	// update task status (processing)
@@ -125,28 +164,9 @@ func (r *Runner) Run() (err error) {
 	// start health check
 	go r.startHealthCheck()
 
-	// declare task status
-	status := ""
-
 	// wait for signal
 	signal := <-r.ch
-	switch signal {
-	case constants.TaskSignalFinish:
-		err = nil
-		status = constants.TaskStatusFinished
-	case constants.TaskSignalCancel:
-		err = constants.ErrTaskCancelled
-		status = constants.TaskStatusCancelled
-	case constants.TaskSignalError:
-		err = r.err
-		status = constants.TaskStatusError
-	case constants.TaskSignalLost:
-		err = constants.ErrTaskLost
-		status = constants.TaskStatusError
-	default:
-		err = constants.ErrInvalidSignal
-		status = constants.TaskStatusError
-	}
+	status, err := r.getStatus(signal)
 
 	// validate task status
 	if status == "" {
@@ -158,40 +178,53 @@ func (r *Runner) Run() (err error) {
 		return err
 	}
 
-	// dispose
-	_ = r.Dispose()
-
 	return err
 }
 
 func (r *Runner) Cancel() (err error) {
-	// kill process
-	opts := &sys_exec.KillProcessOptions{
-		Timeout: r.svc.GetCancelTimeout(),
-		Force:   false,
+	// mark as cancelled, which prevents execution from starting if not yet
+	r.mu.Lock()
+	r.cancelled = true
+	started := r.started
+	r.mu.Unlock()
+	if !started {
+		return nil
 	}
-	if err := sys_exec.KillProcess(r.cmd, opts); err != nil {
+
+	// stop execution
+	if err := r.exec.Stop(r.svc.GetCancelTimeout()); err != nil {
 		return err
 	}
 
-	// make sure the process does not exist
-	op := func() error {
-		if exists, _ := process.PidExists(int32(r.pid)); exists {
-			return errors.ErrorTaskProcessStillExists
-		}
+	// make sure the execution has exited
+	select {
+	case <-r.exited:
 		return nil
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), r.svc.GetExitWatchDuration())
-	defer cancel()
-	b := backoff.WithContext(backoff.NewConstantBackOff(1*time.Second), ctx)
-	if err := backoff.Retry(op, b); err != nil {
+	case <-time.After(r.svc.GetExitWatchDuration()):
 		return trace.TraceError(errors.ErrorTaskUnableToCancel)
 	}
-
-	return nil
 }
 
 func (r *Runner) Dispose() (err error) {
+	// release executor resources
+	if r.exec != nil {
+		if err := r.exec.Dispose(); err != nil {
+			trace.PrintError(err)
+		}
+	}
+
+	// release dependency environments
+	if r.depSvc != nil {
+		r.depSvc.Release(r.tid)
+	}
+
+	// remove isolated workspace of task pinned to a git ref
+	if r.t.GetRef() != "" && r.cwd != "" {
+		if err := os.RemoveAll(r.cwd); err != nil {
+			trace.PrintError(err)
+		}
+	}
+
 	// remove working directory
 	// TODO: make it configurable
 	//if err := os.RemoveAll(r.cwd); err != nil {
@@ -209,6 +242,10 @@ func (r *Runner) SetSubscribeTimeout(timeout time.Duration) {
 	r.subscribeTimeout = timeout
 }
 
+func (r *Runner) SetExecutorFactory(f interfaces.TaskExecutorFactory) {
+	r.execFactory = f
+}
+
 func (r *Runner) GetTaskId() (id primitive.ObjectID) {
 	return r.tid
 }
@@ -234,14 +271,27 @@ func (r *Runner) configureCmd() {
 		cmdStr += " " + r.t.GetParam()
 	}
 
-	// get cmd instance
-	r.cmd = sys_exec.BuildCmd(cmdStr)
-
-	// set working directory
-	r.cmd.Dir = r.cwd
+	r.cmd = cmdStr
+}
 
-	// configure pgid to allow killing sub processes
-	//sys_exec.SetPgid(r.cmd)
+// configureExecutor create executor of the task, which runs in a
+// container if image is specified in the spider, otherwise a local process
+func (r *Runner) configureExecutor() (err error) {
+	opts := &interfaces.TaskExecutorOptions{
+		Id:          r.tid.Hex(),
+		Cmd:         r.cmd,
+		Cwd:         r.cwd,
+		Env:         r.env,
+		Image:       r.s.GetImage(),
+		CpuLimit:    r.s.GetCpuLimit(),
+		MemoryLimit: r.s.GetMemoryLimit() * 1024 * 1024,
+		PidsLimit:   r.s.GetPidsLimit(),
+	}
+	r.exec, err = r.execFactory(opts)
+	if err != nil {
+		return trace.TraceError(err)
+	}
+	return nil
 }
 
 func (r *Runner) getLogDriver() (driver clog.Driver, err error) {
@@ -266,12 +316,10 @@ func (r *Runner) getLogDriverOptions() (options interface{}) {
 
 func (r *Runner) configureLogging() {
 	// set stdout reader
-	stdout, _ := r.cmd.StdoutPipe()
-	r.scannerStdout = bufio.NewScanner(stdout)
+	r.scannerStdout = bufio.NewScanner(r.exec.GetStdout())
 
 	// set stderr reader
-	stderr, _ := r.cmd.StderrPipe()
-	r.scannerStderr = bufio.NewScanner(stderr)
+	r.scannerStderr = bufio.NewScanner(r.exec.GetStderr())
 }
 
 func (r *Runner) startLogging() {
@@ -304,14 +352,43 @@ func (r *Runner) startLoggingReaderStderr() {
 	utils.LogDebug("scannerStderr reached end")
 }
 
+// startHealthCheck watch the local process and sample its resource usage,
+// which is not applicable if not executed as a local process
 func (r *Runner) startHealthCheck() {
+	if r.pid == 0 {
+		return
+	}
+	lastFlushTs := time.Now()
 	for {
+		select {
+		case <-r.exited:
+			// process exited normally and handled by Runner.wait
+			return
+		default:
+		}
+
 		exists, _ := process.PidExists(int32(r.pid))
 		if !exists {
-			// process lost
-			r.ch <- constants.TaskSignalLost
-			return
+			select {
+			case <-r.exited:
+				return
+			default:
+				// process lost
+				r.ch <- constants.TaskSignalLost
+				return
+			}
 		}
+
+		// sample resource usage of process tree
+		r.m.sample(r.pid)
+
+		// flush resource usage periodically
+		if time.Since(lastFlushTs) >= r.metricsFlushInterval {
+			r._updateTaskStatMetrics()
+			r._updateTaskMetric()
+			lastFlushTs = time.Now()
+		}
+
 		time.Sleep(1 * time.Second)
 	}
 }
@@ -346,45 +423,50 @@ func (r *Runner) configureEnv() {
 	//col := utils.GetSpiderCol(r.s.Col, r.s.Name)
 
 	// default envs
-	r.cmd.Env = append(os.Environ(), "CRAWLAB_TASK_ID="+r.tid.Hex())
+	r.env = []string{"CRAWLAB_TASK_ID=" + r.tid.Hex()}
 	if viper.GetString("grpc.address") != "" {
-		r.cmd.Env = append(r.cmd.Env, "CRAWLAB_GRPC_ADDRESS="+viper.GetString("grpc.address"))
+		r.env = append(r.env, "CRAWLAB_GRPC_ADDRESS="+viper.GetString("grpc.address"))
 	}
 	if viper.GetString("grpc.authKey") != "" {
-		r.cmd.Env = append(r.cmd.Env, "CRAWLAB_GRPC_AUTH_KEY="+viper.GetString("grpc.authKey"))
+		r.env = append(r.env, "CRAWLAB_GRPC_AUTH_KEY="+viper.GetString("grpc.authKey"))
 	} else {
-		r.cmd.Env = append(r.cmd.Env, "CRAWLAB_GRPC_AUTH_KEY="+constants.DefaultGrpcAuthKey)
+		r.env = append(r.env, "CRAWLAB_GRPC_AUTH_KEY="+constants.DefaultGrpcAuthKey)
 	}
-	//r.cmd.Env = append(r.cmd.Env, "CRAWLAB_COLLECTION="+col)
-	//r.cmd.Env = append(r.cmd.Env, "CRAWLAB_MONGO_HOST="+viper.GetString("mongo.host"))
-	//r.cmd.Env = append(r.cmd.Env, "CRAWLAB_MONGO_PORT="+viper.GetString("mongo.port"))
+	// dependency environments
+	r.env = append(r.env, r.depEnv...)
+
+	//r.env = append(r.env, "CRAWLAB_COLLECTION="+col)
+	//r.env = append(r.env, "CRAWLAB_MONGO_HOST="+viper.GetString("mongo.host"))
+	//r.env = append(r.env, "CRAWLAB_MONGO_PORT="+viper.GetString("mongo.port"))
 	//if viper.GetString("mongo.db") != "" {
-	//	r.cmd.Env = append(r.cmd.Env, "CRAWLAB_MONGO_DB="+viper.GetString("mongo.db"))
+	//	r.env = append(r.env, "CRAWLAB_MONGO_DB="+viper.GetString("mongo.db"))
 	//}
 	//if viper.GetString("mongo.username") != "" {
-	//	r.cmd.Env = append(r.cmd.Env, "CRAWLAB_MONGO_USERNAME="+viper.GetString("mongo.username"))
+	//	r.env = append(r.env, "CRAWLAB_MONGO_USERNAME="+viper.GetString("mongo.username"))
 	//}
 	//if viper.GetString("mongo.password") != "" {
-	//	r.cmd.Env = append(r.cmd.Env, "CRAWLAB_MONGO_PASSWORD="+viper.GetString("mongo.password"))
+	//	r.env = append(r.env, "CRAWLAB_MONGO_PASSWORD="+viper.GetString("mongo.password"))
 	//}
 	//if viper.GetString("mongo.authSource") != "" {
-	//	r.cmd.Env = append(r.cmd.Env, "CRAWLAB_MONGO_AUTHSOURCE="+viper.GetString("mongo.authSource"))
+	//	r.env = append(r.env, "CRAWLAB_MONGO_AUTHSOURCE="+viper.GetString("mongo.authSource"))
 	//}
-	//r.cmd.Env = append(r.cmd.Env, "PYTHONUNBUFFERED=0")
-	//r.cmd.Env = append(r.cmd.Env, "PYTHONIOENCODING=utf-8")
-	//r.cmd.Env = append(r.cmd.Env, "TZ=Asia/Shanghai")
-	//r.cmd.Env = append(r.cmd.Env, "CRAWLAB_DEDUP_FIELD="+r.s.DedupField)
-	//r.cmd.Env = append(r.cmd.Env, "CRAWLAB_DEDUP_METHOD="+r.s.DedupMethod)
+	//r.env = append(r.env, "PYTHONUNBUFFERED=0")
+	//r.env = append(r.env, "PYTHONIOENCODING=utf-8")
+	//r.env = append(r.env, "TZ=Asia/Shanghai")
+	//r.env = append(r.env, "CRAWLAB_DEDUP_FIELD="+r.s.DedupField)
+	//r.env = append(r.env, "CRAWLAB_DEDUP_METHOD="+r.s.DedupMethod)
 	//if r.s.IsDedup {
-	//	r.cmd.Env = append(r.cmd.Env, "CRAWLAB_IS_DEDUP=1")
+	//	r.env = append(r.env, "CRAWLAB_IS_DEDUP=1")
 	//} else {
-	//	r.cmd.Env = append(r.cmd.Env, "CRAWLAB_IS_DEDUP=0")
+	//	r.env = append(r.env, "CRAWLAB_IS_DEDUP=0")
 	//}
 
-	// TODO: implement task environment variables
-	//for _, env := range r.s.Envs {
-	//	r.cmd.Env = append(r.cmd.Env, env.Name+"="+env.Value)
-	//}
+	// task environment variables, e.g. those rendered from schedule
+	if t, ok := r.t.(*models.Task); ok {
+		for _, env := range t.Envs {
+			r.env = append(r.env, env.Name+"="+env.Value)
+		}
+	}
 
 	// TODO: implement global environment variables
 	//variables, err := models.MustGetRootService().GetVariableList(nil, nil)
@@ -392,7 +474,7 @@ func (r *Runner) configureEnv() {
 	//	return err
 	//}
 	//for _, variable := range variables {
-	//	r.cmd.Env = append(r.cmd.Env, variable.Key+"="+variable.Value)
+	//	r.env = append(r.env, variable.Key+"="+variable.Value)
 	//}
 	//return nil
 }
@@ -401,12 +483,33 @@ func (r *Runner) configureEnv() {
 // to task runner's channel (Runner.ch) according to exit code
 func (r *Runner) wait() {
 	// wait for process to finish
-	if err := r.cmd.Wait(); err != nil {
+	err := r.exec.Wait()
+	close(r.exited)
+
+	// descendant processes left behind
+	r.checkLeakedProcesses()
+
+	if err != nil {
+		// cancelled
+		if r.isCancelled() {
+			r.ch <- constants.TaskSignalCancel
+			return
+		}
+
+		// killed by oom killer
+		if r.exec.IsOomKilled() {
+			r.err = constants.ErrTaskOomKilled
+			r.ch <- constants.TaskSignalError
+			return
+		}
+
 		exitError, ok := err.(*exec.ExitError)
 		if !ok {
+			r.err = err
 			r.ch <- constants.TaskSignalError
 			return
 		}
+
 		exitCode := exitError.ExitCode()
 		if exitCode == -1 {
 			// cancel error
@@ -424,6 +527,75 @@ func (r *Runner) wait() {
 	r.ch <- constants.TaskSignalFinish
 }
 
+// checkLeakedProcesses detect descendant processes still alive after the main
+// process exited, which are reported on the task and then killed
+func (r *Runner) checkLeakedProcesses() {
+	if r.pid == 0 {
+		return
+	}
+
+	// processes in the process group and processes observed in the process tree
+	pids, err := sys_exec.GetProcessGroupPids(r.pid)
+	if err != nil {
+		trace.PrintError(err)
+	}
+	for _, pid := range r.m.alivePids() {
+		if !utils.Contains(pids, pid) {
+			pids = append(pids, pid)
+		}
+	}
+	if len(pids) == 0 {
+		return
+	}
+
+	// report
+	log.Warnf("task[%s] leaked processes: %v", r.tid.Hex(), pids)
+	r.t.SetLeakedPids(pids)
+
+	// kill
+	if err := sys_exec.ForceKillPids(pids); err != nil {
+		trace.PrintError(err)
+	}
+}
+
+// start start execution unless the task has been cancelled before
+func (r *Runner) start() (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancelled {
+		return constants.ErrTaskCancelled
+	}
+	if err := r.exec.Start(); err != nil {
+		return err
+	}
+	r.started = true
+	r.startTs = time.Now()
+	return nil
+}
+
+// isCancelled whether the task has been cancelled
+func (r *Runner) isCancelled() (ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cancelled
+}
+
+// getStatus task status and error by the signal
+func (r *Runner) getStatus(signal constants.TaskSignal) (status string, err error) {
+	switch signal {
+	case constants.TaskSignalFinish:
+		return constants.TaskStatusFinished, nil
+	case constants.TaskSignalCancel:
+		return constants.TaskStatusCancelled, constants.ErrTaskCancelled
+	case constants.TaskSignalError:
+		return constants.TaskStatusError, r.err
+	case constants.TaskSignalLost:
+		return constants.TaskStatusError, constants.ErrTaskLost
+	default:
+		return constants.TaskStatusError, constants.ErrInvalidSignal
+	}
+}
+
 // updateTask update and get updated info of task (Runner.t)
 func (r *Runner) updateTask(status string, e error) (err error) {
 	if r.t != nil && status != "" {
@@ -459,18 +631,45 @@ func (r *Runner) updateTask(status string, e error) (err error) {
 }
 
 func (r *Runner) syncFiles() (err error) {
-	// skip if files sync is locked
-	if r.svc.IsSyncLocked(r.s.GetId()) {
-		return
+	// files at the commit if pinned to a git ref
+	if r.t.GetRef() != "" {
+		return r.syncRefFiles()
 	}
 
-	// lock files sync
-	r.svc.LockSync(r.s.GetId())
-	defer r.svc.UnlockSync(r.s.GetId())
-	if err := r.fsSvc.GetFsService().SyncToWorkspace(); err != nil {
-		return err
+	// changed files since last sync, which is shared by tasks of the spider
+	// on current node and waits for the ongoing sync of other tasks if any
+	return r.fsSvc.GetFsService().SyncToWorkspace()
+}
+
+// syncRefFiles sync spider files at the commit of the task from fs to its
+// isolated workspace
+func (r *Runner) syncRefFiles() (err error) {
+	if r.t.GetCommit() == "" {
+		return trace.TraceError(errors.ErrorTaskMissingCommit)
+	}
+	if err := os.MkdirAll(r.cwd, os.ModePerm); err != nil {
+		return trace.TraceError(err)
 	}
+	if err := r.fsSvc.GetFsService().GetFs().SyncRemoteToLocal(r.fsSvc.GetRefFsPath(r.t.GetCommit()), r.cwd); err != nil {
+		return trace.TraceError(err)
+	}
+	return nil
+}
 
+// prepareDependencies build or reuse cached dependency environments of the
+// spider on current node, which are not applicable to containers
+func (r *Runner) prepareDependencies() (err error) {
+	if r.s.GetImage() != "" {
+		return nil
+	}
+	n, err := r.svc.GetCurrentNode()
+	if err != nil {
+		return err
+	}
+	r.depEnv, err = r.depSvc.Prepare(r.tid, r.s, n.GetId(), r.cwd)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -511,12 +710,21 @@ func (r *Runner) _updateTaskStat(status string) {
 	case constants.TaskStatusPending:
 		// do nothing
 	case constants.TaskStatusRunning:
-		ts.SetStartTs(time.Now())
+		// queue-to-start latency from enqueue to start of the process
+		startTs := r.startTs
+		if startTs.IsZero() {
+			startTs = time.Now()
+		}
+		ts.SetStartTs(startTs)
 		ts.SetWaitDuration(ts.GetStartTs().Sub(ts.GetCreateTs()).Milliseconds())
+		log.Infof("task[%s] waited %dms before start", r.tid.Hex(), ts.GetWaitDuration())
+		ts.SetSyncDuration(r.syncDuration.Milliseconds())
 	case constants.TaskStatusFinished, constants.TaskStatusError, constants.TaskStatusCancelled:
 		ts.SetEndTs(time.Now())
 		ts.SetRuntimeDuration(ts.GetEndTs().Sub(ts.GetStartTs()).Milliseconds())
 		ts.SetTotalDuration(ts.GetEndTs().Sub(ts.GetCreateTs()).Milliseconds())
+		r.m.applyStat(ts)
+		r._updateTaskMetric()
 	}
 	if r.svc.GetNodeConfigService().IsMaster() {
 		if err := delegate.NewModelDelegate(ts).Save(); err != nil {
@@ -588,6 +796,55 @@ func (r *Runner) _updateSpiderStat(status string) {
 
 }
 
+// _updateTaskStatMetrics update resource usage aggregates of task stat
+func (r *Runner) _updateTaskStatMetrics() {
+	ts := &models.TaskStat{}
+	r.m.applyStat(ts)
+	update := bson.M{
+		"$set": bson.M{
+			"cpu_time":     ts.GetCpuTime(),
+			"peak_rss":     ts.GetPeakRss(),
+			"read_bytes":   ts.GetReadBytes(),
+			"write_bytes":  ts.GetWriteBytes(),
+			"peak_threads": ts.GetPeakThreads(),
+		},
+	}
+	if err := r._updateById(interfaces.ModelIdTaskStat, interfaces.ModelColNameTaskStat, r.tid, update); err != nil {
+		trace.PrintError(err)
+	}
+}
+
+// _updateTaskMetric update resource usage time series of task
+func (r *Runner) _updateTaskMetric() {
+	m := r.m.metric()
+	m.SetId(r.tid)
+	if r.svc.GetNodeConfigService().IsMaster() {
+		if err := delegate.NewModelDelegate(m).Save(); err != nil {
+			trace.PrintError(err)
+			return
+		}
+	} else {
+		if err := client.NewModelDelegate(m, client.WithDelegateConfigPath(r.svc.GetConfigPath())).Save(); err != nil {
+			trace.PrintError(err)
+			return
+		}
+	}
+}
+
+func (r *Runner) _updateById(modelId interfaces.ModelId, colName string, id primitive.ObjectID, update bson.M) (err error) {
+	if r.svc.GetNodeConfigService().IsMaster() {
+		return mongo.GetMongoCol(colName).UpdateId(id, update)
+	}
+	modelSvc, err := client.NewBaseServiceDelegate(
+		client.WithBaseServiceModelId(modelId),
+		client.WithBaseServiceConfigPath(r.svc.GetConfigPath()),
+	)
+	if err != nil {
+		return err
+	}
+	return modelSvc.UpdateById(id, update)
+}
+
 func NewTaskRunner(id primitive.ObjectID, svc interfaces.TaskHandlerService, opts ...RunnerOption) (r2 interfaces.TaskRunner, err error) {
 	// validate options
 	if id.IsZero() {
@@ -596,11 +853,15 @@ func NewTaskRunner(id primitive.ObjectID, svc interfaces.TaskHandlerService, opt
 
 	// runner
 	r := &Runner{
-		logDriverType:    clog.DriverTypeFs,
-		subscribeTimeout: 30 * time.Second,
-		svc:              svc,
-		tid:              id,
-		ch:               make(chan constants.TaskSignal),
+		logDriverType:        clog.DriverTypeFs,
+		subscribeTimeout:     30 * time.Second,
+		metricsFlushInterval: 30 * time.Second,
+		svc:                  svc,
+		tid:                  id,
+		ch:                   make(chan constants.TaskSignal, 2), // both wait and health check may send
+		exited:               make(chan struct{}),
+		m:                    newMetricsCollector(5*time.Second, 120),
+		execFactory:          executor.NewTaskExecutor,
 	}
 
 	// apply options
@@ -628,12 +889,17 @@ func NewTaskRunner(id primitive.ObjectID, svc interfaces.TaskHandlerService, opt
 	if err := c.Provide(gclient.ProvideGetClient(r.svc.GetConfigPath())); err != nil {
 		return nil, trace.TraceError(err)
 	}
+	if err := c.Provide(dependency.ProvideGetSpiderDependencyService(r.svc.GetConfigPath())); err != nil {
+		return nil, trace.TraceError(err)
+	}
 	if err := c.Invoke(func(
 		fsSvc interfaces.SpiderFsService,
 		c interfaces.GrpcClient,
+		depSvc interfaces.SpiderDependencyService,
 	) {
 		r.fsSvc = fsSvc
 		r.c = c
+		r.depSvc = depSvc
 	}); err != nil {
 		return nil, trace.TraceError(err)
 	}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/models"
+	etest "github.com/doubletrey/crawlab-core/task/executor/test"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+// testHandlerService task handler service with settings required by runners
+type testHandlerService struct {
+	interfaces.TaskHandlerService
+}
+
+func (svc *testHandlerService) GetCancelTimeout() (timeout time.Duration) {
+	return 1 * time.Second
+}
+
+func (svc *testHandlerService) GetExitWatchDuration() (duration time.Duration) {
+	return 3 * time.Second
+}
+
+func newTestRunner(e *etest.FakeExecutor) (r *Runner) {
+	r = &Runner{
+		svc:    &testHandlerService{},
+		tid:    primitive.NewObjectID(),
+		t:      &models.Task{},
+		s:      &models.Spider{CpuLimit: 0.5},
+		cmd:    "echo it works",
+		ch:     make(chan constants.TaskSignal),
+		exited: make(chan struct{}),
+		m:      newMetricsCollector(5*time.Second, 120),
+	}
+	WithExecutorFactory(etest.NewFakeExecutorFactory(e))(r)
+	return r
+}
+
+// execute the task as Runner.Run does without saving it, and get status and
+// error by the signal
+func executeTestRunner(t *testing.T, r *Runner) (status string, err error) {
+	require.Nil(t, r.configureExecutor())
+	r.configureLogging()
+	require.Nil(t, r.start())
+	r.pid = r.exec.GetPid()
+	go r.wait()
+	go r.startHealthCheck()
+	return r.getStatus(<-r.ch)
+}
+
+func TestRunner_Finish(t *testing.T) {
+	e := etest.NewFakeExecutor(nil)
+	e.Lines = []string{"it works"}
+	r := newTestRunner(e)
+
+	status, err := executeTestRunner(t, r)
+	require.Nil(t, err)
+	require.Equal(t, constants.TaskStatusFinished, status)
+	require.Equal(t, r.tid.Hex(), e.Opts.Id)
+	require.Equal(t, "echo it works", e.Opts.Cmd)
+	require.Equal(t, 0.5, e.Opts.CpuLimit)
+	require.True(t, r.scannerStdout.Scan())
+	require.Equal(t, "it works", r.scannerStdout.Text())
+
+	require.Nil(t, r.Dispose())
+	require.True(t, e.IsDisposed())
+}
+
+func TestRunner_Error(t *testing.T) {
+	e := etest.NewFakeExecutor(nil)
+	e.Err = constants.ErrNotExists
+	r := newTestRunner(e)
+
+	status, err := executeTestRunner(t, r)
+	require.Equal(t, constants.TaskStatusError, status)
+	require.Equal(t, constants.ErrNotExists, err)
+}
+
+func TestRunner_OomKilled(t *testing.T) {
+	e := etest.NewFakeExecutor(nil)
+	e.Err = constants.ErrNotExists
+	e.OomKill = true
+	r := newTestRunner(e)
+
+	status, err := executeTestRunner(t, r)
+	require.Equal(t, constants.TaskStatusError, status)
+	require.Equal(t, constants.ErrTaskOomKilled, err)
+}
+
+func TestRunner_Cancel(t *testing.T) {
+	e := etest.NewFakeExecutor(nil)
+	e.Duration = 30 * time.Second
+	r := newTestRunner(e)
+
+	type result struct {
+		status string
+		err    error
+	}
+	ch := make(chan result)
+	go func() {
+		status, err := executeTestRunner(t, r)
+		ch <- result{status, err}
+	}()
+	for !e.IsStarted() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.Nil(t, r.Cancel())
+	res := <-ch
+	require.True(t, e.IsStopped())
+	require.Equal(t, constants.TaskStatusCancelled, res.status)
+	require.Equal(t, constants.ErrTaskCancelled, res.err)
+}
+
+func TestRunner_CancelBeforeStart(t *testing.T) {
+	e := etest.NewFakeExecutor(nil)
+	r := newTestRunner(e)
+
+	// cancelled before execution is configured
+	require.Nil(t, r.Cancel())
+	require.Nil(t, r.configureExecutor())
+	require.Equal(t, constants.ErrTaskCancelled, r.start())
+	require.False(t, e.IsStarted())
+}
@@ -38,10 +38,9 @@ type Service struct {
 	cancelTimeout     time.Duration
 
 	// internals variables
-	stopped   bool
-	mu        sync.Mutex
-	runners   sync.Map // pool of task runners started
-	syncLocks sync.Map // files sync locks map of task runners
+	stopped bool
+	mu      sync.Mutex
+	runners sync.Map // pool of task runners started
 }
 
 func (svc *Service) Start() {
@@ -77,8 +76,14 @@ func (svc *Service) Run(taskId primitive.ObjectID) (err error) {
 
 	// create a goroutine to run task
 	go func() {
-		// delete runner from pool
-		defer svc.deleteRunner(r.GetTaskId())
+		// delete runner from pool, and report status so that the freed runner
+		// is available to the scheduler without waiting for the next report
+		defer func() {
+			svc.deleteRunner(r.GetTaskId())
+			if err := svc.reportStatus(); err != nil {
+				trace.PrintError(err)
+			}
+		}()
 
 		// run task process (blocking)
 		// error or finish after task runner ends
@@ -130,19 +135,6 @@ func (svc *Service) ReportStatus() {
 	}
 }
 
-func (svc *Service) IsSyncLocked(spiderId primitive.ObjectID) (ok bool) {
-	_, ok = svc.syncLocks.Load(spiderId)
-	return ok
-}
-
-func (svc *Service) LockSync(spiderId primitive.ObjectID) {
-	svc.syncLocks.Store(spiderId, true)
-}
-
-func (svc *Service) UnlockSync(spiderId primitive.ObjectID) {
-	svc.syncLocks.Delete(spiderId)
-}
-
 //func (svc *Service) GetMaxRunners() (maxRunners int) {
 //	return svc.maxRunners
 //}
@@ -310,9 +302,9 @@ func (svc *Service) reportStatus() (err error) {
 		return err
 	}
 
-	// update node
-	ar := n.GetMaxRunners() - svc.getRunnerCount()
-	n.SetAvailableRunners(ar)
+	// update node, where available runners are left to the task scheduler
+	// as they are derived from runner slots held on the node
+	n.SetRunners(svc.getRunnerCount())
 
 	// save node
 	if svc.cfgSvc.IsMaster() {
@@ -342,7 +334,6 @@ func NewTaskHandlerService(opts ...Option) (svc2 interfaces.TaskHandlerService,
 		cancelTimeout:     5 * time.Second,
 		mu:                sync.Mutex{},
 		runners:           sync.Map{},
-		syncLocks:         sync.Map{},
 	}
 
 	// apply options
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"fmt"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// key of the scheduler registered in event service
+const eventKey = "task:scheduler"
+
+// watchEvents trigger scheduling when runners may have been freed up, i.e.
+// tasks end or nodes report more available runners, whose changes are saved
+// on master by model delegates. Runner slots of ended tasks are released here
+func (svc *Service) watchEvents() {
+	// last reported available runners by node id
+	runners := map[primitive.ObjectID]int{}
+
+	ch := make(chan interfaces.EventData, 100)
+	include := fmt.Sprintf("^model:(%s|%s):change$", interfaces.ModelColNameTask, interfaces.ModelColNameNode)
+	svc.eventSvc.Register(eventKey, include, "^$", &ch)
+	defer svc.eventSvc.Unregister(eventKey)
+
+	for {
+		if svc.IsStopped() {
+			return
+		}
+
+		select {
+		case ed := <-ch:
+			if !isRunnerFreed(ed.GetData(), runners) {
+				continue
+			}
+			if t, ok := ed.GetData().(interfaces.Task); ok {
+				if err := svc.releaseSlot(t.GetId()); err != nil {
+					trace.PrintError(err)
+				}
+			}
+			svc.Trigger()
+		case <-time.After(svc.interval):
+		}
+	}
+}
+
+// isRunnerFreed whether the changed model indicates a runner is freed up,
+// i.e. a task has ended or a node reports more available runners than last
+// time as recorded in runners, which is updated by node changes
+func isRunnerFreed(data interface{}, runners map[primitive.ObjectID]int) (ok bool) {
+	switch d := data.(type) {
+	case interfaces.Task:
+		switch d.GetStatus() {
+		case constants.TaskStatusFinished,
+			constants.TaskStatusError,
+			constants.TaskStatusCancelled,
+			constants.TaskStatusAbnormal:
+			return true
+		}
+	case interfaces.Node:
+		last, exists := runners[d.GetId()]
+		runners[d.GetId()] = d.GetAvailableRunners()
+		return d.GetAvailableRunners() > 0 && (!exists || d.GetAvailableRunners() > last)
+	}
+	return false
+}
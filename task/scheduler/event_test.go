@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+func TestIsRunnerFreed(t *testing.T) {
+	runners := map[primitive.ObjectID]int{}
+	require.True(t, isRunnerFreed(&models.Task{Status: constants.TaskStatusFinished}, runners))
+	require.True(t, isRunnerFreed(&models.Task{Status: constants.TaskStatusCancelled}, runners))
+	require.False(t, isRunnerFreed(&models.Task{Status: constants.TaskStatusRunning}, runners))
+	require.False(t, isRunnerFreed(&models.Spider{}, runners))
+
+	// only increases of available runners of nodes
+	id := primitive.NewObjectID()
+	require.True(t, isRunnerFreed(&models.Node{Id: id, AvailableRunners: 1}, runners))
+	require.False(t, isRunnerFreed(&models.Node{Id: id, AvailableRunners: 1}, runners))
+	require.False(t, isRunnerFreed(&models.Node{Id: id, AvailableRunners: 0}, runners))
+	require.True(t, isRunnerFreed(&models.Node{Id: id, AvailableRunners: 2}, runners))
+	require.False(t, isRunnerFreed(&models.Node{Id: primitive.NewObjectID(), AvailableRunners: 0}, runners))
+}
+
+func TestService_Trigger(t *testing.T) {
+	svc := &Service{trigger: make(chan struct{}, 1)}
+
+	// bursts are collapsed into one pending trigger
+	svc.Trigger()
+	svc.Trigger()
+	require.Len(t, svc.trigger, 1)
+	<-svc.trigger
+	require.Len(t, svc.trigger, 0)
+}
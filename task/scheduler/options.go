@@ -18,3 +18,27 @@ func WithInterval(interval time.Duration) Option {
 		svc.SetInterval(interval)
 	}
 }
+
+func WithReconcileInterval(interval time.Duration) Option {
+	return func(svc interfaces.TaskSchedulerService) {
+		svc.SetReconcileInterval(interval)
+	}
+}
+
+func WithAgingInterval(interval time.Duration) Option {
+	return func(svc interfaces.TaskSchedulerService) {
+		svc.SetAgingInterval(interval)
+	}
+}
+
+func WithFairShare(mode string, weights map[string]float64) Option {
+	return func(svc interfaces.TaskSchedulerService) {
+		svc.SetFairShare(mode, weights)
+	}
+}
+
+func WithBatchSize(size int) Option {
+	return func(svc interfaces.TaskSchedulerService) {
+		svc.SetBatchSize(size)
+	}
+}
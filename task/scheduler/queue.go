@@ -0,0 +1,291 @@
+package scheduler
+
+import (
+	"bytes"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"sort"
+	"time"
+)
+
+// number of recently finished tasks whose average runtime is taken to
+// estimate waiting time of pending tasks
+const estimateSampleSize = 100
+
+// getEffectivePriority priority of the task queue item raised by one for each
+// aging interval it has waited, which is never raised beyond 1, the highest
+func getEffectivePriority(tq *models.TaskQueueItem, now time.Time, agingInterval time.Duration) (p int) {
+	p = tq.Priority
+	if agingInterval <= 0 || tq.Ts.IsZero() || p <= 1 {
+		return p
+	}
+	p -= int(now.Sub(tq.Ts) / agingInterval)
+	if p < 1 {
+		p = 1
+	}
+	return p
+}
+
+// sortTaskQueueItems sort task queue items by effective priority and then by
+// enqueue order
+func sortTaskQueueItems(tqList []models.TaskQueueItem, now time.Time, agingInterval time.Duration) {
+	sort.SliceStable(tqList, func(i, j int) bool {
+		pi := getEffectivePriority(&tqList[i], now, agingInterval)
+		pj := getEffectivePriority(&tqList[j], now, agingInterval)
+		if pi != pj {
+			return pi < pj
+		}
+		return bytes.Compare(tqList[i].Id[:], tqList[j].Id[:]) < 0
+	})
+}
+
+// getFairShareGroup fair-share group of the task queue item by the mode
+func getFairShareGroup(tq *models.TaskQueueItem, mode string) (group string) {
+	switch mode {
+	case constants.TaskFairShareModeProject:
+		if tq.ProjectId.IsZero() {
+			return ""
+		}
+		return tq.ProjectId.Hex()
+	case constants.TaskFairShareModeUser:
+		if tq.UserId.IsZero() {
+			return ""
+		}
+		return tq.UserId.Hex()
+	default:
+		return ""
+	}
+}
+
+// orderFairShare order task queue items sorted by priority so that groups take
+// turns in proportion to their weights. Each turn goes to the group with the
+// least share, i.e. its running and already ordered tasks divided by its
+// weight, where ties are broken by priority of their next items. Items of the
+// same group keep their relative order
+func orderFairShare(tqList []models.TaskQueueItem, mode string, usage map[string]int, weights map[string]float64) (res []models.TaskQueueItem) {
+	if mode == constants.TaskFairShareModeNone {
+		return tqList
+	}
+
+	// queues of groups, where items are referred by their indexes in the list
+	var groups []string
+	queues := map[string][]int{}
+	for i := range tqList {
+		g := getFairShareGroup(&tqList[i], mode)
+		if _, ok := queues[g]; !ok {
+			groups = append(groups, g)
+		}
+		queues[g] = append(queues[g], i)
+	}
+
+	// shares of groups
+	counts := map[string]int{}
+	for _, g := range groups {
+		counts[g] = usage[g]
+	}
+	share := func(g string) float64 {
+		w, ok := weights[g]
+		if !ok || w <= 0 {
+			w = 1
+		}
+		return float64(counts[g]) / w
+	}
+
+	// take turns
+	for len(res) < len(tqList) {
+		next := ""
+		found := false
+		for _, g := range groups {
+			if len(queues[g]) == 0 {
+				continue
+			}
+			if !found {
+				next, found = g, true
+				continue
+			}
+			sg, sn := share(g), share(next)
+			if sg < sn || (sg == sn && queues[g][0] < queues[next][0]) {
+				next = g
+			}
+		}
+		res = append(res, tqList[queues[next][0]])
+		queues[next] = queues[next][1:]
+		counts[next]++
+	}
+
+	return res
+}
+
+// estimateWait estimated waiting time in millisecond of the task at the 1-based
+// position in the queue, given available and total runners of the cluster and
+// average runtime of tasks in millisecond, which is -1 if unknown
+func estimateWait(position, availableRunners, totalRunners int, avgRuntime int64) (wait int64) {
+	if position <= availableRunners {
+		return 0
+	}
+	if totalRunners <= 0 || avgRuntime <= 0 {
+		return -1
+	}
+	rounds := (position - availableRunners + totalRunners - 1) / totalRunners
+	return int64(rounds) * avgRuntime
+}
+
+// getOrderedTaskQueueItems task queue items in dequeue order
+func (svc *Service) getOrderedTaskQueueItems() (tqList []models.TaskQueueItem, err error) {
+	if err := mongo.GetMongoCol(interfaces.ModelColNameTaskQueue).Find(nil, nil).All(&tqList); err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(tqList) == 0 {
+		return nil, nil
+	}
+
+	// priority with aging
+	sortTaskQueueItems(tqList, time.Now(), svc.agingInterval)
+
+	// fair share
+	if svc.fairShareMode == constants.TaskFairShareModeNone {
+		return tqList, nil
+	}
+	usage, err := svc.getFairShareUsage()
+	if err != nil {
+		return nil, err
+	}
+	return orderFairShare(tqList, svc.fairShareMode, usage, svc.fairShareWeights), nil
+}
+
+// getFairShareUsage number of running tasks of each fair-share group
+func (svc *Service) getFairShareUsage() (usage map[string]int, err error) {
+	usage = map[string]int{}
+
+	// running tasks
+	tasks, err := svc.modelSvc.GetTaskList(bson.M{"status": constants.TaskStatusRunning}, nil)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return usage, nil
+		}
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return usage, nil
+	}
+
+	switch svc.fairShareMode {
+	case constants.TaskFairShareModeProject:
+		// projects of spiders
+		var spiderIds []primitive.ObjectID
+		for _, t := range tasks {
+			spiderIds = append(spiderIds, t.SpiderId)
+		}
+		spiders, err := svc.modelSvc.GetSpiderList(bson.M{"_id": bson.M{"$in": spiderIds}}, nil)
+		if err != nil && err != mongo2.ErrNoDocuments {
+			return nil, err
+		}
+		projectIds := map[primitive.ObjectID]primitive.ObjectID{}
+		for _, s := range spiders {
+			projectIds[s.Id] = s.ProjectId
+		}
+		for _, t := range tasks {
+			g := getFairShareGroup(&models.TaskQueueItem{ProjectId: projectIds[t.SpiderId]}, svc.fairShareMode)
+			usage[g]++
+		}
+	case constants.TaskFairShareModeUser:
+		// creators of tasks
+		var ids []primitive.ObjectID
+		for _, t := range tasks {
+			ids = append(ids, t.Id)
+		}
+		artifacts, err := svc.modelSvc.GetArtifactList(bson.M{"_id": bson.M{"$in": ids}}, nil)
+		if err != nil && err != mongo2.ErrNoDocuments {
+			return nil, err
+		}
+		userIds := map[primitive.ObjectID]primitive.ObjectID{}
+		for _, a := range artifacts {
+			if a.Sys != nil {
+				userIds[a.Id] = a.Sys.CreateUid
+			}
+		}
+		for _, t := range tasks {
+			g := getFairShareGroup(&models.TaskQueueItem{UserId: userIds[t.Id]}, svc.fairShareMode)
+			usage[g]++
+		}
+	}
+
+	return usage, nil
+}
+
+func (svc *Service) GetQueueInfo() (res map[primitive.ObjectID]interfaces.TaskQueueInfo, err error) {
+	res = map[primitive.ObjectID]interfaces.TaskQueueInfo{}
+
+	// task queue items in dequeue order
+	tqList, err := svc.getOrderedTaskQueueItems()
+	if err != nil {
+		return nil, err
+	}
+	if len(tqList) == 0 {
+		return res, nil
+	}
+
+	// runners, whose availability is derived from slots held on nodes
+	nodes, err := svc.modelSvc.GetNodeList(bson.M{"enabled": true, "active": true}, nil)
+	if err != nil && err != mongo2.ErrNoDocuments {
+		return nil, err
+	}
+	counts, err := svc.getSlotCounts()
+	if err != nil {
+		return nil, err
+	}
+	availableRunners, totalRunners := 0, 0
+	for _, n := range nodes {
+		if ar := n.MaxRunners - counts[n.Id]; ar > 0 {
+			availableRunners += ar
+		}
+		totalRunners += n.MaxRunners
+	}
+
+	// average runtime of recently finished tasks
+	avgRuntime, err := svc.getAverageRuntime()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, tq := range tqList {
+		res[tq.Id] = interfaces.TaskQueueInfo{
+			Position:      i + 1,
+			EstimatedWait: estimateWait(i+1, availableRunners, totalRunners, avgRuntime),
+		}
+	}
+
+	return res, nil
+}
+
+// getAverageRuntime average runtime in millisecond of recently finished tasks
+func (svc *Service) getAverageRuntime() (avg int64, err error) {
+	stats, err := svc.modelSvc.GetTaskStatList(bson.M{
+		"runtime_duration": bson.M{"$gt": 0},
+	}, &mongo.FindOptions{
+		Sort:  bson.D{{Key: "end_ts", Value: -1}},
+		Limit: estimateSampleSize,
+	})
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(stats) == 0 {
+		return 0, nil
+	}
+	var sum int64
+	for _, s := range stats {
+		sum += s.RuntimeDuration
+	}
+	return sum / int64(len(stats)), nil
+}
@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestSortTaskQueueItems_Aging(t *testing.T) {
+	now := time.Now()
+	old := models.TaskQueueItem{Id: primitive.NewObjectID(), Priority: 9, Ts: now.Add(-50 * time.Minute)}
+	high := models.TaskQueueItem{Id: primitive.NewObjectID(), Priority: 2, Ts: now}
+	low := models.TaskQueueItem{Id: primitive.NewObjectID(), Priority: 5, Ts: now}
+
+	// without aging
+	tqList := []models.TaskQueueItem{old, high, low}
+	sortTaskQueueItems(tqList, now, 0)
+	require.Equal(t, []primitive.ObjectID{high.Id, low.Id, old.Id}, getIds(tqList))
+
+	// aged by one every 5 minutes, which is never beyond 1
+	require.Equal(t, 1, getEffectivePriority(&old, now, 5*time.Minute))
+	tqList = []models.TaskQueueItem{low, high, old}
+	sortTaskQueueItems(tqList, now, 5*time.Minute)
+	require.Equal(t, []primitive.ObjectID{old.Id, high.Id, low.Id}, getIds(tqList))
+}
+
+func TestOrderFairShare(t *testing.T) {
+	p1, p2 := primitive.NewObjectID(), primitive.NewObjectID()
+	var tqList []models.TaskQueueItem
+	for i := 0; i < 4; i++ {
+		tqList = append(tqList, models.TaskQueueItem{Id: primitive.NewObjectID(), Priority: 1, ProjectId: p1})
+	}
+	for i := 0; i < 4; i++ {
+		tqList = append(tqList, models.TaskQueueItem{Id: primitive.NewObjectID(), Priority: 5, ProjectId: p2})
+	}
+
+	// take turns
+	res := orderFairShare(tqList, constants.TaskFairShareModeProject, nil, nil)
+	require.Len(t, res, 8)
+	for i, tq := range res {
+		if i%2 == 0 {
+			require.Equal(t, p1, tq.ProjectId)
+		} else {
+			require.Equal(t, p2, tq.ProjectId)
+		}
+	}
+
+	// running tasks of p1 count against its share
+	res = orderFairShare(tqList, constants.TaskFairShareModeProject, map[string]int{p1.Hex(): 2}, nil)
+	require.Equal(t, p2, res[0].ProjectId)
+	require.Equal(t, p2, res[1].ProjectId)
+	require.Equal(t, p1, res[2].ProjectId)
+
+	// p2 weighs 3 times as much as p1
+	res = orderFairShare(tqList, constants.TaskFairShareModeProject, nil, map[string]float64{p2.Hex(): 3})
+	require.Equal(t, []primitive.ObjectID{p1, p2, p2, p2, p1, p2, p1, p1}, getProjectIds(res))
+
+	// disabled
+	res = orderFairShare(tqList, constants.TaskFairShareModeNone, nil, nil)
+	require.Equal(t, getIds(tqList), getIds(res))
+}
+
+func TestEstimateWait(t *testing.T) {
+	require.Equal(t, int64(0), estimateWait(2, 2, 4, 1000))
+	require.Equal(t, int64(1000), estimateWait(3, 2, 4, 1000))
+	require.Equal(t, int64(2000), estimateWait(7, 2, 4, 1000))
+	require.Equal(t, int64(-1), estimateWait(3, 2, 4, 0))
+	require.Equal(t, int64(-1), estimateWait(1, 0, 0, 1000))
+}
+
+func getIds(tqList []models.TaskQueueItem) (ids []primitive.ObjectID) {
+	for _, tq := range tqList {
+		ids = append(ids, tq.Id)
+	}
+	return ids
+}
+
+func getProjectIds(tqList []models.TaskQueueItem) (ids []primitive.ObjectID) {
+	for _, tq := range tqList {
+		ids = append(ids, tq.ProjectId)
+	}
+	return ids
+}
@@ -1,12 +1,14 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	grpc "github.com/crawlab-team/crawlab-grpc"
 	"github.com/crawlab-team/go-trace"
 	config2 "github.com/doubletrey/crawlab-core/config"
 	"github.com/doubletrey/crawlab-core/constants"
 	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/event"
 	"github.com/doubletrey/crawlab-core/grpc/server"
 	"github.com/doubletrey/crawlab-core/interfaces"
 	"github.com/doubletrey/crawlab-core/models/client"
@@ -14,6 +16,7 @@ import (
 	"github.com/doubletrey/crawlab-core/models/models"
 	"github.com/doubletrey/crawlab-core/models/service"
 	"github.com/doubletrey/crawlab-core/node/config"
+	"github.com/doubletrey/crawlab-core/node/leader"
 	"github.com/doubletrey/crawlab-core/task"
 	"github.com/doubletrey/crawlab-core/task/handler"
 	"github.com/doubletrey/crawlab-core/utils"
@@ -23,12 +26,16 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/dig"
 	"math/rand"
 	"sync"
 	"time"
 )
 
+// default max number of tasks dequeued per round
+const defaultBatchSize = 100
+
 type Service struct {
 	// dependencies
 	interfaces.TaskBaseService
@@ -36,13 +43,26 @@ type Service struct {
 	modelSvc   service.ModelService
 	svr        interfaces.GrpcServer
 	handlerSvc interfaces.TaskHandlerService
+	leaderSvc  interfaces.NodeLeaderService
+	eventSvc   interfaces.EventService
 
 	// settings
-	interval time.Duration
+	interval          time.Duration      // interval of fallback polling between triggers
+	agingInterval     time.Duration      // waiting time by which effective priority is raised by one
+	fairShareMode     string             // fair-share dequeue across projects or users if set
+	fairShareWeights  map[string]float64 // fair-share weights by project or user id
+	batchSize         int                // max number of tasks dequeued per round
+	reconcileInterval time.Duration      // interval of reconciling runner slots with tasks and nodes
+
+	// internals
+	trigger      chan struct{} // pending trigger of scheduling, which collapses bursts
+	reconciledTs time.Time     // last time when runner slots were reconciled
+	fencedToken  int64         // fencing token by which runner slots of all nodes were fenced
 }
 
 func (svc *Service) Start() {
 	go svc.initTaskStatus()
+	go svc.watchEvents()
 	go svc.DequeueAndSchedule()
 	svc.Wait()
 	svc.Stop()
@@ -67,6 +87,11 @@ func (svc *Service) Enqueue(t interfaces.Task) (err error) {
 	tq := &models.TaskQueueItem{
 		Id:       t.GetId(),
 		Priority: t.GetPriority(),
+		Ts:       time.Now(),
+		UserId:   t.GetUserId(),
+	}
+	if s, err := svc.modelSvc.GetSpiderById(t.GetSpiderId()); err == nil {
+		tq.ProjectId = s.ProjectId
 	}
 
 	// task stat
@@ -87,46 +112,88 @@ func (svc *Service) Enqueue(t interfaces.Task) (err error) {
 		return trace.TraceError(err)
 	}
 
+	// add task metric
+	_, err = mongo.GetMongoCol(interfaces.ModelColNameTaskMetric).Insert(&models.TaskMetric{
+		Id:     t.GetId(),
+		Points: []models.TaskMetricPoint{},
+	})
+	if err != nil {
+		return trace.TraceError(err)
+	}
+
+	// schedule now
+	svc.Trigger()
+
 	// success
 	return nil
 }
 
+func (svc *Service) Trigger() {
+	select {
+	case svc.trigger <- struct{}{}:
+	default:
+	}
+}
+
 func (svc *Service) DequeueAndSchedule() {
 	for {
 		if svc.IsStopped() {
 			return
 		}
 
-		// wait
-		time.Sleep(svc.interval)
+		// wait for a trigger, or poll as a fallback for triggers missed, e.g.
+		// tasks enqueued by other masters
+		select {
+		case <-svc.trigger:
+		case <-time.After(svc.interval):
+		}
 
-		if err := mongo.RunTransaction(func(sc mongo2.SessionContext) error {
-			// dequeue tasks
-			tasks, err := svc.Dequeue()
-			if err != nil {
-				return trace.TraceError(err)
-			}
+		// only the leader among masters dequeues tasks
+		if !svc.leaderSvc.IsLeader() {
+			continue
+		}
 
-			// skip if no tasks available
-			if tasks == nil || len(tasks) == 0 {
-				return nil
+		// reconcile runner slots periodically
+		if time.Since(svc.reconciledTs) >= svc.reconcileInterval {
+			if err := svc.reconcileSlots(svc.leaderSvc.GetToken()); err != nil {
+				trace.PrintError(err)
 			}
+			svc.reconciledTs = time.Now()
+		}
 
-			// schedule tasks
-			if err := svc.Schedule(tasks); err != nil {
-				return trace.TraceError(err)
-			}
+		// dequeue tasks
+		tasks, err := svc.Dequeue()
+		if err != nil {
+			trace.PrintError(err)
+			continue
+		}
 
-			return nil
-		}); err != nil {
+		// skip if no tasks available
+		if len(tasks) == 0 {
+			continue
+		}
+
+		// schedule tasks
+		if err := svc.Schedule(tasks); err != nil {
 			trace.PrintError(err)
 		}
 	}
 }
 
 func (svc *Service) Dequeue() (tasks []interfaces.Task, err error) {
-	// get task queue items
-	tqList, err := svc.getTaskQueueItems()
+	// fencing token of the leader, on which writes of runner slots are
+	// conditional so that a former leader cannot dequeue after another
+	// master has taken over
+	token := svc.leaderSvc.GetToken()
+	if token == 0 {
+		return nil, trace.TraceError(errors.ErrorNodeNotLeader)
+	}
+	if err := svc.fence(token); err != nil {
+		return nil, err
+	}
+
+	// get task queue items in order of priority with aging and fair share
+	tqList, err := svc.getOrderedTaskQueueItems()
 	if err != nil {
 		return nil, err
 	}
@@ -135,7 +202,7 @@ func (svc *Service) Dequeue() (tasks []interfaces.Task, err error) {
 	}
 
 	// match resources
-	tasks, nodesMap, err := svc.matchResources(tqList)
+	tasks, err = svc.matchResources(tqList)
 	if err != nil {
 		return nil, err
 	}
@@ -143,17 +210,32 @@ func (svc *Service) Dequeue() (tasks []interfaces.Task, err error) {
 		return nil, nil
 	}
 
-	// update resources
-	if err := svc.updateResources(nodesMap); err != nil {
+	// acquire runner slots, where tasks without slots acquired are left in
+	// the queue until runners are freed up
+	acquired, err := svc.acquireSlots(tasks, token, true)
+	if err != nil {
 		return nil, err
 	}
 
-	// dequeue tasks
-	if err := svc.dequeueTasks(tasks); err != nil {
+	// dequeue tasks, where only those still pending are to be scheduled and
+	// the others are left in the queue if pending with their slots released.
+	// Tasks dequeued before any failure of bulk writes are still scheduled
+	dequeued, err := svc.dequeueTasks(acquired, tqList)
+	if err != nil {
+		trace.PrintError(err)
+	}
+
+	// update resources
+	if err := svc.updateResources(tasks); err != nil {
 		return nil, err
 	}
 
-	return tasks, nil
+	// schedule the next batch right after this one if the batch is full
+	if len(tasks) >= svc.getBatchSize() {
+		svc.Trigger()
+	}
+
+	return dequeued, nil
 }
 
 func (svc *Service) Schedule(tasks []interfaces.Task) (err error) {
@@ -286,60 +368,86 @@ func (svc *Service) SetInterval(interval time.Duration) {
 	svc.interval = interval
 }
 
-func (svc *Service) getTaskQueueItems() (tqList []models.TaskQueueItem, err error) {
-	opts := &mongo.FindOptions{
-		Sort: bson.D{
-			{"p", 1},
-			{"_id", 1},
-		},
+func (svc *Service) SetBatchSize(size int) {
+	svc.batchSize = size
+}
+
+func (svc *Service) SetReconcileInterval(interval time.Duration) {
+	svc.reconcileInterval = interval
+}
+
+func (svc *Service) SetAgingInterval(interval time.Duration) {
+	svc.agingInterval = interval
+}
+
+func (svc *Service) SetFairShare(mode string, weights map[string]float64) {
+	svc.fairShareMode = mode
+	svc.fairShareWeights = weights
+}
+
+// fence raise fencing token of runner slots of all nodes once the master
+// becomes the leader with a new token, after which runner slots can no longer
+// be acquired by a former leader
+func (svc *Service) fence(token int64) (err error) {
+	if svc.fencedToken == token {
+		return nil
 	}
-	if err := mongo.GetMongoCol(interfaces.ModelColNameTaskQueue).Find(nil, opts).All(&tqList); err != nil {
-		if err == mongo2.ErrNoDocuments {
-			return nil, nil
-		}
-		return nil, err
+	nodes, err := svc.modelSvc.GetNodeList(nil, nil)
+	if err != nil && err != mongo2.ErrNoDocuments {
+		return err
+	}
+	var nodeIds []primitive.ObjectID
+	for _, n := range nodes {
+		nodeIds = append(nodeIds, n.Id)
+	}
+	if err := svc.fenceSlots(nodeIds, token); err != nil {
+		return err
 	}
-	return tqList, nil
+	svc.fencedToken = token
+	return nil
 }
 
-func (svc *Service) getResourcesAndNodesMap() (resources map[string]models.Node, nodesMap map[primitive.ObjectID]models.Node, err error) {
-	nodesMap = map[primitive.ObjectID]models.Node{}
+// getResources available runners of nodes, which are max runners minus
+// runner slots held on them
+func (svc *Service) getResources() (resources map[string]models.Node, err error) {
 	resources = map[string]models.Node{}
 	query := bson.M{
 		// enabled: true
 		"enabled": true,
 		// active: true
 		"active": true,
-		// available_runners > 0
-		"available_runners": bson.M{
-			"$gt": 0,
-		},
 	}
 	nodes, err := svc.modelSvc.GetNodeList(query, nil)
 	if err != nil {
 		if err == mongo2.ErrNoDocuments {
-			return nil, nil, nil
+			return nil, nil
 		}
-		return nil, nil, err
+		return nil, err
+	}
+	counts, err := svc.getSlotCounts()
+	if err != nil {
+		return nil, err
 	}
 	for _, n := range nodes {
-		nodesMap[n.Id] = n
-		for i := 0; i < n.AvailableRunners; i++ {
+		for i := 0; i < n.MaxRunners-counts[n.Id]; i++ {
 			key := fmt.Sprintf("%s:%d", n.Id.Hex(), i)
 			resources[key] = n
 		}
 	}
-	return resources, nodesMap, nil
+	return resources, nil
 }
 
-func (svc *Service) matchResources(tqList []models.TaskQueueItem) (tasks []interfaces.Task, nodesMap map[primitive.ObjectID]models.Node, err error) {
-	// get resources and nodes map
-	resources, nodesMap, err := svc.getResourcesAndNodesMap()
+// matchResources match task queue items in order with available runners,
+// where tasks are fetched in bulk by chunks of batch size until runners are
+// used up or the batch of the round is full
+func (svc *Service) matchResources(tqList []models.TaskQueueItem) (tasks []interfaces.Task, err error) {
+	// get resources
+	resources, err := svc.getResources()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if resources == nil || len(resources) == 0 {
-		return nil, nil, nil
+		return nil, nil
 	}
 
 	// resources list
@@ -354,64 +462,215 @@ func (svc *Service) matchResources(tqList []models.TaskQueueItem) (tasks []inter
 		resourcesList[i], resourcesList[j] = resourcesList[j], resourcesList[i]
 	})
 
-	// iterate task queue items
-	for _, tq := range tqList {
-		// task
-		t, err := svc.modelSvc.GetTaskById(tq.GetId())
+	// iterate task queue items by chunks
+	batchSize := svc.getBatchSize()
+	for start := 0; start < len(tqList); start += batchSize {
+		if len(resourcesList) == 0 || len(tasks) >= batchSize {
+			break
+		}
+		end := start + batchSize
+		if end > len(tqList) {
+			end = len(tqList)
+		}
+
+		// tasks of the chunk
+		tasksMap, err := svc.getTasksMap(tqList[start:end])
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
-		// iterate shuffled resources to match a resource
-		for i, r := range resourcesList {
-			// If node id is unset or node id of task matches with resource id (node id),
-			// assign corresponding resource id to the task
-			if t.GetNodeId().IsZero() ||
-				t.GetNodeId() == r.GetId() {
-				// assign resource id
-				t.NodeId = r.GetId()
+		for _, tq := range tqList[start:end] {
+			if len(resourcesList) == 0 || len(tasks) >= batchSize {
+				break
+			}
 
-				// append to tasks
-				tasks = append(tasks, t)
+			// task, which is skipped if deleted meanwhile
+			t, ok := tasksMap[tq.GetId()]
+			if !ok {
+				continue
+			}
 
-				// delete from resources list
-				resourcesList = append(resourcesList[:i], resourcesList[(i+1):]...)
+			// iterate shuffled resources to match a resource
+			for i, r := range resourcesList {
+				// If node id is unset or node id of task matches with resource id (node id),
+				// assign corresponding resource id to the task
+				if t.GetNodeId().IsZero() ||
+					t.GetNodeId() == r.GetId() {
+					// assign resource id
+					t.NodeId = r.GetId()
 
-				// decrement available runners
-				n := nodesMap[r.GetId()]
-				n.DecrementAvailableRunners()
+					// append to tasks
+					tasks = append(tasks, t)
 
-				// break loop
-				break
+					// delete from resources list
+					resourcesList = append(resourcesList[:i], resourcesList[(i+1):]...)
+
+					// break loop
+					break
+				}
 			}
 		}
 	}
 
-	return tasks, nodesMap, nil
+	return tasks, nil
+}
+
+// getBatchSize batch size, which falls back to the default if not positive
+func (svc *Service) getBatchSize() (size int) {
+	if svc.batchSize <= 0 {
+		return defaultBatchSize
+	}
+	return svc.batchSize
+}
+
+// getTasksMap tasks of the task queue items fetched in bulk
+func (svc *Service) getTasksMap(tqList []models.TaskQueueItem) (tasksMap map[primitive.ObjectID]*models.Task, err error) {
+	var ids []primitive.ObjectID
+	for _, tq := range tqList {
+		ids = append(ids, tq.GetId())
+	}
+	list, err := svc.modelSvc.GetTaskList(bson.M{"_id": bson.M{"$in": ids}}, nil)
+	if err != nil && err != mongo2.ErrNoDocuments {
+		return nil, err
+	}
+	tasksMap = map[primitive.ObjectID]*models.Task{}
+	for i := range list {
+		tasksMap[list[i].Id] = &list[i]
+	}
+	return tasksMap, nil
 }
 
-func (svc *Service) updateResources(nodesMap map[primitive.ObjectID]models.Node) (err error) {
-	for _, n := range nodesMap {
-		if err := delegate.NewModelNodeDelegate(&n).Save(); err != nil {
-			return err
+// updateResources refresh available runners of nodes assigned to the tasks
+func (svc *Service) updateResources(tasks []interfaces.Task) (err error) {
+	var nodeIds []primitive.ObjectID
+	seen := map[primitive.ObjectID]bool{}
+	for _, t := range tasks {
+		if seen[t.GetNodeId()] {
+			continue
 		}
+		seen[t.GetNodeId()] = true
+		nodeIds = append(nodeIds, t.GetNodeId())
 	}
-	return nil
+	if len(nodeIds) == 0 {
+		return nil
+	}
+	return svc.refreshAvailableRunners(nodeIds)
 }
 
-func (svc *Service) dequeueTasks(tasks []interfaces.Task) (err error) {
+// dequeueTasks remove task queue items of the tasks and save node ids of the
+// tasks in bulk, which are dequeued only if they are still pending, as they may
+// have been cancelled meanwhile. As bulk writes may fail partway, the tasks
+// dequeued are those found pending with node ids saved after the writes. The
+// other tasks have their slots released, and queue items restored if pending
+func (svc *Service) dequeueTasks(tasks []interfaces.Task, tqList []models.TaskQueueItem) (dequeued []interfaces.Task, err error) {
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	db := mongo.GetMongoDb("")
+
+	// task ids by node id
+	var ids []primitive.ObjectID
+	nodeTaskIds := map[primitive.ObjectID][]primitive.ObjectID{}
 	for _, t := range tasks {
-		// save task with node id
-		if err := delegate.NewModelDelegate(t).Save(); err != nil {
-			return err
+		ids = append(ids, t.GetId())
+		nodeTaskIds[t.GetNodeId()] = append(nodeTaskIds[t.GetNodeId()], t.GetId())
+	}
+
+	// remove task queue items, where no task is dequeued if it fails as
+	// queue items may have been left
+	if _, err := db.Collection(interfaces.ModelColNameTaskQueue).DeleteMany(context.Background(), bson.M{
+		"_id": bson.M{"$in": ids},
+	}); err != nil {
+		svc.undequeueTasks(tasks, nil, tqList)
+		return nil, trace.TraceError(err)
+	}
+
+	// save node ids of pending tasks
+	var errs multierror.Errors
+	for nodeId, taskIds := range nodeTaskIds {
+		if _, err := db.Collection(interfaces.ModelColNameTask).UpdateMany(context.Background(), bson.M{
+			"_id":    bson.M{"$in": taskIds},
+			"status": constants.TaskStatusPending,
+		}, bson.M{
+			"$set": bson.M{"node_id": nodeId},
+		}); err != nil {
+			errs = append(errs, trace.TraceError(err))
+			break
 		}
+	}
 
-		// remove task queue item
-		if err := mongo.GetMongoCol(interfaces.ModelColNameTaskQueue).DeleteId(t.GetId()); err != nil {
-			return err
+	// pending tasks with their node ids
+	var pendingTasks []models.Task
+	cur, err := db.Collection(interfaces.ModelColNameTask).Find(context.Background(), bson.M{
+		"_id":    bson.M{"$in": ids},
+		"status": constants.TaskStatusPending,
+	}, options.Find().SetProjection(bson.M{"_id": 1, "node_id": 1}))
+	if err == nil {
+		err = cur.All(context.Background(), &pendingTasks)
+	}
+	if err != nil {
+		// tasks are not known to be dequeued, which are hence to be dequeued
+		// again, whereas queue items of ended tasks are removed next time
+		errs = append(errs, trace.TraceError(err))
+		svc.undequeueTasks(tasks, nil, tqList)
+		return nil, errs.Err()
+	}
+	pendingNodeIds := map[primitive.ObjectID]primitive.ObjectID{}
+	for _, t := range pendingTasks {
+		pendingNodeIds[t.Id] = t.NodeId
+	}
+
+	// dequeued tasks
+	var others []interfaces.Task
+	for _, t := range tasks {
+		if nodeId, ok := pendingNodeIds[t.GetId()]; ok && nodeId == t.GetNodeId() {
+			dequeued = append(dequeued, t)
+			continue
+		}
+		others = append(others, t)
+	}
+	svc.undequeueTasks(others, pendingNodeIds, tqList)
+
+	return dequeued, errs.Err()
+}
+
+// undequeueTasks release slots of the tasks which are not dequeued and restore
+// their queue items, which are restored only if the tasks are pending if their
+// pending status is known, i.e. pendingNodeIds is not nil
+func (svc *Service) undequeueTasks(tasks []interfaces.Task, pendingNodeIds map[primitive.ObjectID]primitive.ObjectID, tqList []models.TaskQueueItem) {
+	if len(tasks) == 0 {
+		return
+	}
+
+	// release slots
+	if err := svc.releaseSlots(tasks); err != nil {
+		trace.PrintError(err)
+	}
+
+	// restore task queue items, where those not removed are kept
+	tqMap := map[primitive.ObjectID]models.TaskQueueItem{}
+	for _, tq := range tqList {
+		tqMap[tq.Id] = tq
+	}
+	var items []interface{}
+	for _, t := range tasks {
+		if pendingNodeIds != nil {
+			if _, ok := pendingNodeIds[t.GetId()]; !ok {
+				continue
+			}
+		}
+		if tq, ok := tqMap[t.GetId()]; ok {
+			items = append(items, tq)
+		}
+	}
+	if len(items) == 0 {
+		return
+	}
+	if _, err := mongo.GetMongoDb("").Collection(interfaces.ModelColNameTaskQueue).InsertMany(context.Background(), items, options.InsertMany().SetOrdered(false)); err != nil {
+		if !mongo2.IsDuplicateKeyError(err) {
+			trace.PrintError(err)
 		}
 	}
-	return nil
 }
 
 func (svc *Service) handleTaskError(n interfaces.Node, t interfaces.Task, err error) {
@@ -425,8 +684,13 @@ func (svc *Service) handleTaskError(n interfaces.Node, t interfaces.Task, err er
 	}
 }
 
-// initTaskStatus initialize task status of existing tasks
+// initTaskStatus initialize task status of existing tasks, which is skipped
+// if the master is not the leader as running tasks belong to the leader
 func (svc *Service) initTaskStatus() {
+	if !svc.leaderSvc.IsLeader() {
+		return
+	}
+
 	// set status of running tasks as TaskStatusAbnormal
 	runningTasks, err := svc.modelSvc.GetTaskList(bson.M{
 		"status": constants.TaskStatusRunning,
@@ -469,8 +733,12 @@ func NewTaskSchedulerService(opts ...Option) (svc2 interfaces.TaskSchedulerServi
 
 	// service
 	svc := &Service{
-		TaskBaseService: baseSvc,
-		interval:        5 * time.Second,
+		TaskBaseService:   baseSvc,
+		interval:          30 * time.Second,
+		agingInterval:     5 * time.Minute,
+		batchSize:         defaultBatchSize,
+		reconcileInterval: 1 * time.Minute,
+		trigger:           make(chan struct{}, 1),
 	}
 
 	// apply options
@@ -497,14 +765,24 @@ func NewTaskSchedulerService(opts ...Option) (svc2 interfaces.TaskSchedulerServi
 	if err := c.Provide(handler.ProvideGetTaskHandlerService(svc.GetConfigPath())); err != nil {
 		return nil, trace.TraceError(err)
 	}
+	if err := c.Provide(leader.ProvideGetNodeLeaderService(svc.GetConfigPath())); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	if err := c.Provide(event.NewEventService); err != nil {
+		return nil, trace.TraceError(err)
+	}
 	if err := c.Invoke(func(
 		modelSvc service.ModelService,
 		svr interfaces.GrpcServer,
 		handlerSvc interfaces.TaskHandlerService,
+		leaderSvc interfaces.NodeLeaderService,
+		eventSvc interfaces.EventService,
 	) {
 		svc.modelSvc = modelSvc
 		svc.svr = svr
 		svc.handlerSvc = handlerSvc
+		svc.leaderSvc = leaderSvc
+		svc.eventSvc = eventSvc
 	}); err != nil {
 		return nil, trace.TraceError(err)
 	}
@@ -558,6 +836,32 @@ func ProvideGetTaskSchedulerService(path string, opts ...Option) func() (svr int
 		opts = append(opts, WithInterval(time.Duration(intervalSeconds)*time.Second))
 	}
 
+	// batch size
+	if batchSize := viper.GetInt("task.scheduler.batchSize"); batchSize > 0 {
+		opts = append(opts, WithBatchSize(batchSize))
+	}
+
+	// reconcile interval
+	reconcileIntervalSeconds := viper.GetInt("task.scheduler.reconcileInterval")
+	if reconcileIntervalSeconds > 0 {
+		opts = append(opts, WithReconcileInterval(time.Duration(reconcileIntervalSeconds)*time.Second))
+	}
+
+	// priority aging
+	agingIntervalSeconds := viper.GetInt("task.scheduler.agingInterval")
+	if agingIntervalSeconds > 0 {
+		opts = append(opts, WithAgingInterval(time.Duration(agingIntervalSeconds)*time.Second))
+	}
+
+	// fair share
+	if mode := viper.GetString("task.scheduler.fairShare.mode"); mode != "" {
+		weights := map[string]float64{}
+		if err := viper.UnmarshalKey("task.scheduler.fairShare.weights", &weights); err != nil {
+			trace.PrintError(err)
+		}
+		opts = append(opts, WithFairShare(mode, weights))
+	}
+
 	return func() (svr interfaces.TaskSchedulerService, err error) {
 		return GetTaskSchedulerService(path, opts...)
 	}
@@ -0,0 +1,339 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"github.com/apex/log"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	mongo2 "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// collection of runner slots of nodes held by dequeued tasks, which is the
+// source of truth of runner usage of nodes. Slots are only acquired by the
+// leader while dequeuing within max runners of nodes, whereas available
+// runners of nodes are derived from slots for display
+const slotColName = "task_slots"
+
+// nodeSlots runner slots of a node, whose writes by the leader are fenced by
+// its token so that they are rejected once a newer leader has taken over
+type nodeSlots struct {
+	Id      primitive.ObjectID   `bson:"_id"`      // node id
+	Token   int64                `bson:"token"`    // fencing token of the last leader writing slots
+	TaskIds []primitive.ObjectID `bson:"task_ids"` // tasks holding slots
+	Ts      time.Time            `bson:"ts"`       // time when last acquired
+}
+
+func (svc *Service) getSlotCol() (col *mongo2.Collection) {
+	return mongo.GetMongoDb("").Collection(slotColName)
+}
+
+// getSlotCounts number of slots held on each node
+func (svc *Service) getSlotCounts() (counts map[primitive.ObjectID]int, err error) {
+	cur, err := svc.getSlotCol().Aggregate(context.Background(), mongo2.Pipeline{
+		{{Key: "$project", Value: bson.M{"count": bson.M{"$size": "$task_ids"}}}},
+	})
+	if err != nil {
+		return nil, trace.TraceError(err)
+	}
+	var res []struct {
+		Id    primitive.ObjectID `bson:"_id"`
+		Count int                `bson:"count"`
+	}
+	if err := cur.All(context.Background(), &res); err != nil {
+		return nil, trace.TraceError(err)
+	}
+	counts = map[primitive.ObjectID]int{}
+	for _, r := range res {
+		counts[r.Id] = r.Count
+	}
+	return counts, nil
+}
+
+// fenceSlots raise fencing token of slots of the nodes to the token, after
+// which slots of them can no longer be written by a former leader
+func (svc *Service) fenceSlots(nodeIds []primitive.ObjectID, token int64) (err error) {
+	for _, id := range nodeIds {
+		if _, err := svc.getSlotCol().UpdateOne(context.Background(), bson.M{
+			"_id":   id,
+			"token": bson.M{"$lte": token},
+		}, bson.M{
+			"$set":         bson.M{"token": token},
+			"$setOnInsert": bson.M{"task_ids": bson.A{}},
+		}, options.Update().SetUpsert(true)); err != nil {
+			if mongo2.IsDuplicateKeyError(err) {
+				// fenced by a newer leader
+				return trace.TraceError(errors.ErrorNodeStaleLeaderToken)
+			}
+			return trace.TraceError(err)
+		}
+	}
+	return nil
+}
+
+// acquireSlots acquire slots on assigned nodes for the tasks with the fencing
+// token of the leader, where slots already held by the tasks are kept. If
+// limited, slots are acquired only within max runners of nodes, beyond which
+// tasks are skipped. The whole acquisition is rejected if slots have been
+// fenced by a newer leader
+func (svc *Service) acquireSlots(tasks []interfaces.Task, token int64, limited bool) (acquired []interfaces.Task, err error) {
+	// max runners of nodes
+	maxRunners := map[primitive.ObjectID]int{}
+	if limited {
+		var nodeIds []primitive.ObjectID
+		for _, t := range tasks {
+			nodeIds = append(nodeIds, t.GetNodeId())
+		}
+		nodes, err := svc.modelSvc.GetNodeList(bson.M{"_id": bson.M{"$in": nodeIds}}, nil)
+		if err != nil && err != mongo2.ErrNoDocuments {
+			return nil, err
+		}
+		for _, n := range nodes {
+			maxRunners[n.Id] = n.MaxRunners
+		}
+	}
+
+	for _, t := range tasks {
+		limit := 0
+		if limited {
+			limit = maxRunners[t.GetNodeId()]
+			if limit <= 0 {
+				continue
+			}
+		}
+		ok, err := svc.acquireSlot(t, token, limit)
+		if err != nil {
+			return acquired, err
+		}
+		if ok {
+			acquired = append(acquired, t)
+		}
+	}
+	return acquired, nil
+}
+
+// acquireSlot acquire a slot on assigned node of the task, which is
+// conditional on the fencing token and, if limit is positive, on the number
+// of slots held on the node being less than limit in the same write
+func (svc *Service) acquireSlot(t interfaces.Task, token int64, limit int) (ok bool, err error) {
+	query := bson.M{
+		"_id":      t.GetNodeId(),
+		"token":    bson.M{"$lte": token},
+		"task_ids": bson.M{"$ne": t.GetId()},
+	}
+	if limit > 0 {
+		query[fmt.Sprintf("task_ids.%d", limit-1)] = bson.M{"$exists": false}
+	}
+	_, err = svc.getSlotCol().UpdateOne(context.Background(), query, bson.M{
+		"$set":  bson.M{"token": token, "ts": time.Now()},
+		"$push": bson.M{"task_ids": t.GetId()},
+	}, options.Update().SetUpsert(true))
+	if err == nil {
+		return true, nil
+	}
+	if !mongo2.IsDuplicateKeyError(err) {
+		return false, trace.TraceError(err)
+	}
+
+	// not matched as either the slot is already held by the task, slots of
+	// the node are used up, or they have been fenced by a newer leader
+	var s nodeSlots
+	if err := svc.getSlotCol().FindOne(context.Background(), bson.M{"_id": t.GetNodeId()}).Decode(&s); err != nil {
+		return false, trace.TraceError(err)
+	}
+	if s.Token > token {
+		return false, trace.TraceError(errors.ErrorNodeStaleLeaderToken)
+	}
+	for _, id := range s.TaskIds {
+		if id == t.GetId() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// releaseSlot release the slot held by the task if any, where available
+// runners of its node are refreshed
+func (svc *Service) releaseSlot(id primitive.ObjectID) (err error) {
+	var s nodeSlots
+	if err := svc.getSlotCol().FindOneAndUpdate(context.Background(), bson.M{
+		"task_ids": id,
+	}, bson.M{
+		"$pull": bson.M{"task_ids": id},
+	}).Decode(&s); err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return nil
+		}
+		return trace.TraceError(err)
+	}
+	return svc.refreshAvailableRunners([]primitive.ObjectID{s.Id})
+}
+
+// releaseSlots release slots held by the tasks if any in bulk, where
+// available runners of their nodes are refreshed
+func (svc *Service) releaseSlots(tasks []interfaces.Task) (err error) {
+	if len(tasks) == 0 {
+		return nil
+	}
+	var ids, nodeIds []primitive.ObjectID
+	for _, t := range tasks {
+		ids = append(ids, t.GetId())
+		nodeIds = append(nodeIds, t.GetNodeId())
+	}
+	if _, err := svc.getSlotCol().UpdateMany(context.Background(), bson.M{
+		"task_ids": bson.M{"$in": ids},
+	}, bson.M{
+		"$pull": bson.M{"task_ids": bson.M{"$in": ids}},
+	}); err != nil {
+		return trace.TraceError(err)
+	}
+	return svc.refreshAvailableRunners(nodeIds)
+}
+
+// refreshAvailableRunners set available runners of the nodes as max runners
+// minus slots held on them
+func (svc *Service) refreshAvailableRunners(nodeIds []primitive.ObjectID) (err error) {
+	nodes, err := svc.modelSvc.GetNodeList(bson.M{"_id": bson.M{"$in": nodeIds}}, nil)
+	if err != nil {
+		if err == mongo2.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+	counts, err := svc.getSlotCounts()
+	if err != nil {
+		return err
+	}
+	col := mongo.GetMongoDb("").Collection(interfaces.ModelColNameNode)
+	for _, n := range nodes {
+		ar := n.MaxRunners - counts[n.Id]
+		if ar < 0 {
+			ar = 0
+		}
+		if ar == n.AvailableRunners {
+			continue
+		}
+		if _, err := col.UpdateOne(context.Background(), bson.M{"_id": n.Id}, bson.M{
+			"$set": bson.M{"available_runners": ar},
+		}); err != nil {
+			return trace.TraceError(err)
+		}
+	}
+	return nil
+}
+
+// reconcileSlots fix drift of slots from tasks and nodes:
+//   - slots of tasks which have ended or been deleted are released
+//   - slots on nodes which have been lost are released, whose tasks are set
+//     as abnormal
+//   - slots are acquired for running tasks without them
+//
+// and available runners of nodes are refreshed afterwards
+func (svc *Service) reconcileSlots(token int64) (err error) {
+	if token == 0 {
+		return trace.TraceError(errors.ErrorNodeNotLeader)
+	}
+
+	// slots
+	var slots []nodeSlots
+	cur, err := svc.getSlotCol().Find(context.Background(), bson.M{})
+	if err != nil {
+		return trace.TraceError(err)
+	}
+	if err := cur.All(context.Background(), &slots); err != nil {
+		return trace.TraceError(err)
+	}
+	slotsMap := map[primitive.ObjectID]primitive.ObjectID{}
+	ids := []primitive.ObjectID{}
+	for _, s := range slots {
+		for _, id := range s.TaskIds {
+			slotsMap[id] = s.Id
+			ids = append(ids, id)
+		}
+	}
+
+	// tasks which hold slots or are running
+	tasks, err := svc.modelSvc.GetTaskList(bson.M{
+		"$or": []bson.M{
+			{"_id": bson.M{"$in": ids}},
+			{"status": constants.TaskStatusRunning},
+		},
+	}, nil)
+	if err != nil && err != mongo2.ErrNoDocuments {
+		return err
+	}
+	tasksMap := map[primitive.ObjectID]*models.Task{}
+	for i := range tasks {
+		tasksMap[tasks[i].Id] = &tasks[i]
+	}
+
+	// nodes which are alive
+	nodes, err := svc.modelSvc.GetNodeList(bson.M{"active": true}, nil)
+	if err != nil && err != mongo2.ErrNoDocuments {
+		return err
+	}
+	aliveNodes := map[primitive.ObjectID]bool{}
+	var nodeIds []primitive.ObjectID
+	for _, n := range nodes {
+		aliveNodes[n.Id] = true
+		nodeIds = append(nodeIds, n.Id)
+	}
+
+	// release slots of ended tasks or lost nodes
+	var releaseIds []primitive.ObjectID
+	for _, id := range ids {
+		t, ok := tasksMap[id]
+		if !ok || (t.Status != constants.TaskStatusPending && t.Status != constants.TaskStatusRunning) {
+			releaseIds = append(releaseIds, id)
+			continue
+		}
+		if nodeId := slotsMap[id]; !aliveNodes[nodeId] {
+			log.Warnf("task[%s] lost as node[%s] is not alive", t.Id.Hex(), nodeId.Hex())
+			if err := svc.SaveTask(t, constants.TaskStatusAbnormal); err != nil {
+				trace.PrintError(err)
+			}
+			releaseIds = append(releaseIds, id)
+		}
+	}
+	if len(releaseIds) > 0 {
+		log.Infof("[TaskSchedulerService] released %d drifted runner slots", len(releaseIds))
+		if _, err := svc.getSlotCol().UpdateMany(context.Background(), bson.M{
+			"token": bson.M{"$lte": token},
+		}, bson.M{
+			"$pull": bson.M{"task_ids": bson.M{"$in": releaseIds}},
+		}); err != nil {
+			return trace.TraceError(err)
+		}
+	}
+
+	// acquire slots of running tasks without them
+	var missing []interfaces.Task
+	for _, t := range tasks {
+		if _, ok := slotsMap[t.Id]; ok {
+			continue
+		}
+		if t.Status == constants.TaskStatusRunning && aliveNodes[t.NodeId] {
+			missing = append(missing, tasksMap[t.Id])
+		}
+	}
+	if len(missing) > 0 {
+		log.Infof("[TaskSchedulerService] acquired %d missing runner slots", len(missing))
+		if _, err := svc.acquireSlots(missing, token, false); err != nil {
+			return err
+		}
+	}
+
+	// available runners
+	if len(nodeIds) == 0 {
+		return nil
+	}
+	return svc.refreshAvailableRunners(nodeIds)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/doubletrey/crawlab-core/models/delegate"
 	"github.com/doubletrey/crawlab-core/models/models"
 	"github.com/doubletrey/crawlab-core/models/service"
+	"github.com/doubletrey/crawlab-core/node/leader"
 	ntest "github.com/doubletrey/crawlab-core/node/test"
 	"github.com/doubletrey/crawlab-core/spider/fs"
 	stest "github.com/doubletrey/crawlab-core/spider/test"
@@ -44,6 +45,7 @@ type Test struct {
 	workerFsSvc     interfaces.SpiderFsService
 	masterFsSvcLong interfaces.SpiderFsService
 	masterSyncSvc   interfaces.SpiderSyncService
+	leaderSvc       interfaces.NodeLeaderService
 	client          interfaces.GrpcClient
 	server          interfaces.GrpcServer
 	sub             grpc.NodeService_SubscribeClient
@@ -62,7 +64,10 @@ type Test struct {
 	ScriptLong      string
 }
 
-func (t *Test) Setup(t2 *testing.T) {
+func (t *Test) Setup(t2 testing.TB) {
+	// tasks are only dequeued by the leader
+	t.leaderSvc.Start()
+
 	// add test node
 	t.TestNode = t.NewNode()
 	if err := delegate.NewModelDelegate(t.TestNode).Add(); err != nil {
@@ -95,6 +100,7 @@ func (t *Test) Setup(t2 *testing.T) {
 }
 
 func (t *Test) Cleanup() {
+	t.leaderSvc.Stop()
 	_ = t.modelSvc.DropAll()
 }
 
@@ -153,7 +159,6 @@ func main() {
 	if err := c.Provide(handler.ProvideGetTaskHandlerService(
 		ntest.T.WorkerSvc.GetConfigPath(),
 		handler.WithReportInterval(t.ReportInterval),
-		handler.WithMaxRunners(t.MaxRunners),
 		handler.WithExitWatchDuration(t.ExitWatchDuration),
 	)); err != nil {
 		return nil, trace.TraceError(err)
@@ -172,6 +177,10 @@ func main() {
 	}); err != nil {
 		return nil, trace.TraceError(err)
 	}
+	t.leaderSvc, err = leader.GetNodeLeaderService(ntest.T.MasterSvc.GetConfigPath())
+	if err != nil {
+		return nil, err
+	}
 	t.masterFsSvc = stest.T.GetMasterFsSvc()
 	t.workerFsSvc = stest.T.GetWorkerFsSvc()
 	t.masterSyncSvc = stest.T.GetMasterSyncSvc()
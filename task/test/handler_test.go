@@ -67,8 +67,8 @@ func TestHandlerService_ReportStatus(t *testing.T) {
 	var n interfaces.Node
 	n, err = T.modelSvc.GetNodeByKey(T.TestNode.GetKey(), nil)
 	require.Nil(t, err)
-	require.Equal(t, T.handlerSvc.GetMaxRunners(), n.GetMaxRunners())
-	require.Equal(t, n.GetMaxRunners(), n.GetAvailableRunners())
+	require.Equal(t, T.MaxRunners, n.GetMaxRunners())
+	require.Equal(t, 0, n.GetRunners())
 
 	err = T.handlerSvc.Run(task.GetId())
 	require.Nil(t, err)
@@ -80,5 +80,5 @@ func TestHandlerService_ReportStatus(t *testing.T) {
 
 	n, err = T.modelSvc.GetNodeByKey(T.TestNode.GetKey(), nil)
 	require.Nil(t, err)
-	require.Equal(t, n.GetMaxRunners()-1, n.GetAvailableRunners())
+	require.Equal(t, 1, n.GetRunners())
 }
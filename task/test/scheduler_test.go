@@ -1,9 +1,17 @@
 package test
 
 import (
+	"context"
+	"fmt"
+	"github.com/doubletrey/crawlab-core/constants"
 	"github.com/doubletrey/crawlab-core/interfaces"
+	"github.com/doubletrey/crawlab-core/models/models"
+	"github.com/doubletrey/crawlab-db/mongo"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"testing"
+	"time"
 )
 
 func TestSchedulerService_Enqueue(t *testing.T) {
@@ -126,3 +134,199 @@ func TestSchedulerService_Schedule_Max(t *testing.T) {
 	err = T.schedulerSvc.Schedule(tasks)
 	require.Nil(t, err)
 }
+
+func TestSchedulerService_Dequeue_BatchSize(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	T.schedulerSvc.SetBatchSize(5)
+	defer T.schedulerSvc.SetBatchSize(0)
+
+	for i := 0; i < 10; i++ {
+		err = T.schedulerSvc.Enqueue(T.NewTask())
+		require.Nil(t, err)
+	}
+
+	tasks, err := T.schedulerSvc.Dequeue()
+	require.Nil(t, err)
+	require.Len(t, tasks, 5)
+
+	count, err := T.modelSvc.GetBaseService(interfaces.ModelIdTaskQueue).Count(nil)
+	require.Nil(t, err)
+	require.Equal(t, 5, count)
+
+	n, err := T.modelSvc.GetNodeById(T.TestNode.GetId())
+	require.Nil(t, err)
+	require.Equal(t, T.TestNode.GetAvailableRunners()-5, n.GetAvailableRunners())
+}
+
+func TestSchedulerService_Dequeue_StaleAvailableRunners(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	ar := T.TestNode.GetAvailableRunners()
+	for i := 0; i < ar; i++ {
+		err = T.schedulerSvc.Enqueue(T.NewTask())
+		require.Nil(t, err)
+	}
+
+	tasks, err := T.schedulerSvc.Dequeue()
+	require.Nil(t, err)
+	require.Len(t, tasks, ar)
+
+	// available runners overwritten by a stale save of the node, e.g. heartbeat
+	err = mongo.GetMongoCol(interfaces.ModelColNameNode).UpdateId(T.TestNode.GetId(), bson.M{
+		"$set": bson.M{"available_runners": ar},
+	})
+	require.Nil(t, err)
+
+	// runner slots are still held by dequeued tasks
+	err = T.schedulerSvc.Enqueue(T.NewTask())
+	require.Nil(t, err)
+	tasks, err = T.schedulerSvc.Dequeue()
+	require.Nil(t, err)
+	require.Len(t, tasks, 0)
+
+	count, err := T.modelSvc.GetBaseService(interfaces.ModelIdTaskQueue).Count(nil)
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestSchedulerService_Dequeue_NotPending(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	task := T.NewTask()
+	err = T.schedulerSvc.Enqueue(task)
+	require.Nil(t, err)
+	err = T.schedulerSvc.Enqueue(T.NewTask())
+	require.Nil(t, err)
+
+	// task no longer pending while its queue item is left
+	err = mongo.GetMongoCol(interfaces.ModelColNameTask).UpdateId(task.GetId(), bson.M{
+		"$set": bson.M{"status": constants.TaskStatusCancelled},
+	})
+	require.Nil(t, err)
+
+	// only the pending task is dequeued, where the slot of the other is released
+	tasks, err := T.schedulerSvc.Dequeue()
+	require.Nil(t, err)
+	require.Len(t, tasks, 1)
+	require.NotEqual(t, task.GetId(), tasks[0].GetId())
+	count, err := T.modelSvc.GetBaseService(interfaces.ModelIdTaskQueue).Count(nil)
+	require.Nil(t, err)
+	require.Equal(t, 0, count)
+	n, err := T.modelSvc.GetNodeById(T.TestNode.GetId())
+	require.Nil(t, err)
+	require.Equal(t, T.TestNode.GetAvailableRunners()-1, n.GetAvailableRunners())
+}
+
+func TestSchedulerService_GetQueueInfo_StaleAvailableRunners(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	ar := T.TestNode.GetAvailableRunners()
+	for i := 0; i < ar+1; i++ {
+		err = T.schedulerSvc.Enqueue(T.NewTask())
+		require.Nil(t, err)
+	}
+	tasks, err := T.schedulerSvc.Dequeue()
+	require.Nil(t, err)
+	require.Len(t, tasks, ar)
+	info, err := T.schedulerSvc.GetQueueInfo()
+	require.Nil(t, err)
+	require.Len(t, info, 1)
+
+	// available runners overwritten by a stale save of the node, e.g. heartbeat
+	err = mongo.GetMongoCol(interfaces.ModelColNameNode).UpdateId(T.TestNode.GetId(), bson.M{
+		"$set": bson.M{"available_runners": ar},
+	})
+	require.Nil(t, err)
+
+	// availability is still derived from runner slots
+	info2, err := T.schedulerSvc.GetQueueInfo()
+	require.Nil(t, err)
+	require.Equal(t, info, info2)
+}
+
+func TestSchedulerService_Dequeue_StaleToken(t *testing.T) {
+	var err error
+	T.Setup(t)
+
+	err = T.schedulerSvc.Enqueue(T.NewTask())
+	require.Nil(t, err)
+	tasks, err := T.schedulerSvc.Dequeue()
+	require.Nil(t, err)
+	require.Len(t, tasks, 1)
+
+	// runner slots fenced by a newer leader
+	_, err = mongo.GetMongoDb("").Collection("task_slots").UpdateMany(context.Background(), bson.M{}, bson.M{
+		"$inc": bson.M{"token": 1},
+	})
+	require.Nil(t, err)
+
+	// writes of the former leader are rejected
+	err = T.schedulerSvc.Enqueue(T.NewTask())
+	require.Nil(t, err)
+	_, err = T.schedulerSvc.Dequeue()
+	require.NotNil(t, err)
+	count, err := T.modelSvc.GetBaseService(interfaces.ModelIdTaskQueue).Count(nil)
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+}
+
+// BenchmarkSchedulerService_Dequeue a scheduling round with thousands of
+// queued tasks, which takes time by the batch size rather than the queue size
+// as tasks are fetched and saved in bulk
+func BenchmarkSchedulerService_Dequeue(b *testing.B) {
+	for _, size := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("queue-%d", size), func(b *testing.B) {
+			T.Setup(b)
+			runners := 100
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				benchmarkSetupQueue(b, size, runners)
+				b.StartTimer()
+
+				tasks, err := T.schedulerSvc.Dequeue()
+				require.Nil(b, err)
+				require.Len(b, tasks, runners)
+			}
+		})
+	}
+}
+
+// benchmarkSetupQueue reset the queue with tasks of the size, which are
+// inserted in bulk, and available runners of the test node, whose runner
+// slots are released
+func benchmarkSetupQueue(b *testing.B, size, runners int) {
+	_ = mongo.GetMongoCol(interfaces.ModelColNameTask).Delete(nil)
+	_ = mongo.GetMongoCol(interfaces.ModelColNameTaskQueue).Delete(nil)
+	_ = mongo.GetMongoCol("task_slots").Delete(nil)
+	var tasks, tqList []interface{}
+	for i := 0; i < size; i++ {
+		t := &models.Task{
+			Id:       primitive.NewObjectID(),
+			SpiderId: T.TestSpider.GetId(),
+			Status:   constants.TaskStatusPending,
+			Priority: 5,
+		}
+		tasks = append(tasks, t)
+		tqList = append(tqList, &models.TaskQueueItem{
+			Id:       t.Id,
+			Priority: t.Priority,
+			Ts:       time.Now(),
+		})
+	}
+	_, err := mongo.GetMongoCol(interfaces.ModelColNameTask).InsertMany(tasks)
+	require.Nil(b, err)
+	_, err = mongo.GetMongoCol(interfaces.ModelColNameTaskQueue).InsertMany(tqList)
+	require.Nil(b, err)
+	err = mongo.GetMongoCol(interfaces.ModelColNameNode).UpdateId(T.TestNode.GetId(), bson.M{
+		"$set": bson.M{
+			"available_runners": runners,
+			"max_runners":       runners,
+		},
+	})
+	require.Nil(b, err)
+}
@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/crawlab-team/go-trace"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+)
+
+// limits of archives to read, beyond which they are rejected so that
+// decompression bombs do not exhaust memory
+var (
+	archiveMaxSize  int64 = 1 << 30 // total size of decompressed files
+	archiveMaxFiles       = 10000   // number of files
+)
+
+// ArchiveWriter writer of files into a zip or tar.gz archive
+type ArchiveWriter struct {
+	zw *zip.Writer
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+// WriteFile write file data at the slash-separated path in the archive
+func (aw *ArchiveWriter) WriteFile(name string, data []byte) (err error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if aw.zw != nil {
+		w, err := aw.zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return trace.TraceError(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return trace.TraceError(err)
+		}
+		return nil
+	}
+	if err := aw.tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return trace.TraceError(err)
+	}
+	if _, err := aw.tw.Write(data); err != nil {
+		return trace.TraceError(err)
+	}
+	return nil
+}
+
+// Close flush the archive, which does not close the underlying writer
+func (aw *ArchiveWriter) Close() (err error) {
+	if aw.zw != nil {
+		if err := aw.zw.Close(); err != nil {
+			return trace.TraceError(err)
+		}
+		return nil
+	}
+	if err := aw.tw.Close(); err != nil {
+		return trace.TraceError(err)
+	}
+	if err := aw.gw.Close(); err != nil {
+		return trace.TraceError(err)
+	}
+	return nil
+}
+
+// NewArchiveWriter writer of a zip (default) or tar.gz archive
+func NewArchiveWriter(w io.Writer, format string) (aw *ArchiveWriter, err error) {
+	switch format {
+	case "", constants.ArchiveFormatZip:
+		return &ArchiveWriter{zw: zip.NewWriter(w)}, nil
+	case constants.ArchiveFormatTarGz:
+		gw := gzip.NewWriter(w)
+		return &ArchiveWriter{gw: gw, tw: tar.NewWriter(gw)}, nil
+	default:
+		return nil, errors.NewFsError(fmt.Sprintf("%s: unsupported format %s", errors.ErrorFsInvalidArchive.Error(), format))
+	}
+}
+
+// GetArchiveContentType content type of the archive format
+func GetArchiveContentType(format string) (contentType string) {
+	if format == constants.ArchiveFormatTarGz {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
+
+// DetectArchiveFormat format of the archive by its magic number, which is
+// empty if it is neither zip nor tar.gz
+func DetectArchiveFormat(data []byte) (format string) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) || bytes.HasPrefix(data, []byte("PK\x05\x06")) {
+		return constants.ArchiveFormatZip
+	}
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		return constants.ArchiveFormatTarGz
+	}
+	return ""
+}
+
+// CleanArchivePath cleaned slash-separated relative path of an archive entry,
+// which is rejected if it is absolute or escapes the root of the archive
+func CleanArchivePath(name string) (p string, err error) {
+	p = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(p, "/") || (len(p) >= 2 && p[1] == ':') {
+		return "", errors.NewFsError(fmt.Sprintf("%s: %s", errors.ErrorFsInvalidArchivePath.Error(), name))
+	}
+	p = path.Clean(p)
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", errors.NewFsError(fmt.Sprintf("%s: %s", errors.ErrorFsInvalidArchivePath.Error(), name))
+	}
+	if p == "." {
+		return "", nil
+	}
+	return p, nil
+}
+
+// ReadArchive files in the zip or tar.gz archive keyed by cleaned relative
+// paths, whose format is detected if not given. Directories, symlinks and
+// other special entries are skipped, and the whole archive is rejected if
+// any entry has an invalid path
+func ReadArchive(data []byte, format string) (files map[string][]byte, err error) {
+	if format == "" {
+		format = DetectArchiveFormat(data)
+	}
+	switch format {
+	case constants.ArchiveFormatZip:
+		return readZipArchive(data)
+	case constants.ArchiveFormatTarGz:
+		return readTarGzArchive(data)
+	default:
+		return nil, errors.NewFsError(fmt.Sprintf("%s: unsupported format %s", errors.ErrorFsInvalidArchive.Error(), format))
+	}
+}
+
+func readZipArchive(data []byte) (files map[string][]byte, err error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.NewFsError(fmt.Sprintf("%s: %v", errors.ErrorFsInvalidArchive.Error(), err))
+	}
+	files = map[string][]byte{}
+	var size int64
+	for _, f := range zr.File {
+		p, err := CleanArchivePath(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		if p == "" || f.FileInfo().IsDir() || !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, errors.NewFsError(fmt.Sprintf("%s: %v", errors.ErrorFsInvalidArchive.Error(), err))
+		}
+		fileData, err := readArchiveFile(rc, len(files), &size)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[p] = fileData
+	}
+	return files, nil
+}
+
+func readTarGzArchive(data []byte) (files map[string][]byte, err error) {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.NewFsError(fmt.Sprintf("%s: %v", errors.ErrorFsInvalidArchive.Error(), err))
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	files = map[string][]byte{}
+	var size int64
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.NewFsError(fmt.Sprintf("%s: %v", errors.ErrorFsInvalidArchive.Error(), err))
+		}
+		p, err := CleanArchivePath(h.Name)
+		if err != nil {
+			return nil, err
+		}
+		if p == "" || (h.Typeflag != tar.TypeReg && h.Typeflag != tar.TypeRegA) {
+			continue
+		}
+		fileData, err := readArchiveFile(tr, len(files), &size)
+		if err != nil {
+			return nil, err
+		}
+		files[p] = fileData
+	}
+	return files, nil
+}
+
+// readArchiveFile read data of the next file in archive, where n is the number
+// of files read and size is the total size of them, which is rejected if
+// either would exceed limits of archives
+func readArchiveFile(r io.Reader, n int, size *int64) (data []byte, err error) {
+	if n >= archiveMaxFiles {
+		return nil, errors.NewFsError(fmt.Sprintf("%s: more than %d files", errors.ErrorFsInvalidArchive.Error(), archiveMaxFiles))
+	}
+	remaining := archiveMaxSize - *size
+	data, err = ioutil.ReadAll(io.LimitReader(r, remaining+1))
+	if err != nil {
+		return nil, errors.NewFsError(fmt.Sprintf("%s: %v", errors.ErrorFsInvalidArchive.Error(), err))
+	}
+	if int64(len(data)) > remaining {
+		return nil, errors.NewFsError(fmt.Sprintf("%s: larger than %d bytes", errors.ErrorFsInvalidArchive.Error(), archiveMaxSize))
+	}
+	*size += int64(len(data))
+	return data, nil
+}
+
+// StripArchiveTopDir files with the top-level directory stripped from their
+// paths if all files are under the same one, otherwise files are returned as
+// they are and ok is false
+func StripArchiveTopDir(files map[string][]byte) (res map[string][]byte, ok bool) {
+	var topDir string
+	for p := range files {
+		parts := strings.SplitN(p, "/", 2)
+		if len(parts) < 2 || (topDir != "" && parts[0] != topDir) {
+			return files, false
+		}
+		topDir = parts[0]
+	}
+	if topDir == "" {
+		return files, false
+	}
+	res = map[string][]byte{}
+	for p, data := range files {
+		res[strings.TrimPrefix(p, topDir+"/")] = data
+	}
+	return res, true
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestArchive_WriteRead(t *testing.T) {
+	for _, format := range []string{constants.ArchiveFormatZip, constants.ArchiveFormatTarGz} {
+		buf := bytes.NewBuffer(nil)
+		aw, err := NewArchiveWriter(buf, format)
+		require.Nil(t, err)
+		require.Nil(t, aw.WriteFile("main.py", []byte("print('it works')")))
+		require.Nil(t, aw.WriteFile("/lib/utils.py", []byte("pass")))
+		require.Nil(t, aw.Close())
+
+		require.Equal(t, format, DetectArchiveFormat(buf.Bytes()))
+		files, err := ReadArchive(buf.Bytes(), "")
+		require.Nil(t, err)
+		require.Len(t, files, 2)
+		require.Equal(t, "print('it works')", string(files["main.py"]))
+		require.Equal(t, "pass", string(files["lib/utils.py"]))
+	}
+}
+
+func TestArchive_InvalidPath(t *testing.T) {
+	for _, name := range []string{"../evil.py", "a/../../evil.py", "/etc/passwd", "C:\\evil.py"} {
+		buf := bytes.NewBuffer(nil)
+		zw := zip.NewWriter(buf)
+		w, err := zw.Create(name)
+		require.Nil(t, err)
+		_, err = w.Write([]byte("evil"))
+		require.Nil(t, err)
+		require.Nil(t, zw.Close())
+
+		_, err = ReadArchive(buf.Bytes(), constants.ArchiveFormatZip)
+		require.NotNil(t, err, name)
+	}
+
+	p, err := CleanArchivePath("a/./b/../c.py")
+	require.Nil(t, err)
+	require.Equal(t, "a/c.py", p)
+}
+
+func TestArchive_Limits(t *testing.T) {
+	maxSize, maxFiles := archiveMaxSize, archiveMaxFiles
+	defer func() {
+		archiveMaxSize, archiveMaxFiles = maxSize, maxFiles
+	}()
+
+	for _, format := range []string{constants.ArchiveFormatZip, constants.ArchiveFormatTarGz} {
+		buf := bytes.NewBuffer(nil)
+		aw, err := NewArchiveWriter(buf, format)
+		require.Nil(t, err)
+		require.Nil(t, aw.WriteFile("a.txt", bytes.Repeat([]byte("a"), 1024)))
+		require.Nil(t, aw.WriteFile("b.txt", bytes.Repeat([]byte("b"), 1024)))
+		require.Nil(t, aw.Close())
+
+		// within limits
+		archiveMaxSize, archiveMaxFiles = 2048, 2
+		files, err := ReadArchive(buf.Bytes(), "")
+		require.Nil(t, err)
+		require.Len(t, files, 2)
+
+		// too large after decompression
+		archiveMaxSize, archiveMaxFiles = 2047, 2
+		_, err = ReadArchive(buf.Bytes(), "")
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), errors.ErrorFsInvalidArchive.Error())
+
+		// too many files
+		archiveMaxSize, archiveMaxFiles = 2048, 1
+		_, err = ReadArchive(buf.Bytes(), "")
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), errors.ErrorFsInvalidArchive.Error())
+	}
+}
+
+func TestArchive_UnsupportedFormat(t *testing.T) {
+	_, err := ReadArchive([]byte("not an archive"), "")
+	require.NotNil(t, err)
+	_, err = NewArchiveWriter(bytes.NewBuffer(nil), "rar")
+	require.NotNil(t, err)
+}
+
+func TestArchive_StripTopDir(t *testing.T) {
+	files, ok := StripArchiveTopDir(map[string][]byte{
+		"project/main.py":      []byte("main"),
+		"project/lib/utils.py": []byte("utils"),
+	})
+	require.True(t, ok)
+	require.Equal(t, "main", string(files["main.py"]))
+	require.Equal(t, "utils", string(files["lib/utils.py"]))
+
+	// multiple top-level entries
+	files, ok = StripArchiveTopDir(map[string][]byte{
+		"project/main.py": []byte("main"),
+		"README.md":       []byte("readme"),
+	})
+	require.False(t, ok)
+	require.Len(t, files, 2)
+	require.Contains(t, files, "project/main.py")
+}
@@ -1,6 +1,10 @@
 package utils
 
-import "github.com/doubletrey/crawlab-core/interfaces"
+import (
+	"github.com/doubletrey/crawlab-core/constants"
+	"github.com/doubletrey/crawlab-core/interfaces"
+	"reflect"
+)
 
 func GetUserFromArgs(args ...interface{}) (u interfaces.User) {
 	for _, arg := range args {
@@ -15,3 +19,17 @@ func GetUserFromArgs(args ...interface{}) (u interfaces.User) {
 	}
 	return nil
 }
+
+// GetAuditSourceFromArgs source of changes given in args, which is regarded as
+// http if not given while operated by a user, or system otherwise
+func GetAuditSourceFromArgs(args ...interface{}) (source constants.AuditSource) {
+	for _, arg := range args {
+		if source, ok := arg.(constants.AuditSource); ok {
+			return source
+		}
+	}
+	if u := GetUserFromArgs(args...); u != nil && !reflect.ValueOf(u).IsZero() {
+		return constants.AuditSourceHttp
+	}
+	return constants.AuditSourceSystem
+}
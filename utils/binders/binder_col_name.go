@@ -62,6 +62,20 @@ func (b *ColNameBinder) Bind() (res interface{}, err error) {
 		return interfaces.ModelColNamePluginStatus, nil
 	case interfaces.ModelIdGit:
 		return interfaces.ModelColNameGit, nil
+	case interfaces.ModelIdTaskMetric:
+		return interfaces.ModelColNameTaskMetric, nil
+	case interfaces.ModelIdDependencyEnv:
+		return interfaces.ModelColNameDependencyEnv, nil
+	case interfaces.ModelIdAuditLog:
+		return interfaces.ModelColNameAuditLog, nil
+	case interfaces.ModelIdGitWebhookDelivery:
+		return interfaces.ModelColNameGitWebhookDelivery, nil
+	case interfaces.ModelIdSshKey:
+		return interfaces.ModelColNameSshKey, nil
+	case interfaces.ModelIdScheduleSkip:
+		return interfaces.ModelColNameScheduleSkip, nil
+	case interfaces.ModelIdCalendar:
+		return interfaces.ModelColNameCalendar, nil
 
 	// invalid
 	default: